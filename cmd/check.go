@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+)
+
+// check <files...>
+func (c *cli) checkCommand(args []string) int {
+	if len(args) == 0 {
+		return c.usageError("files are not specified")
+	}
+
+	code := EXIT_OK
+	for _, path := range args {
+		src, err := c.readSource(path)
+		if err != nil {
+			_, _ = fmt.Fprintf(c.stderr, "error: %v\n", err)
+			code = EXIT_ERROR
+			continue
+		}
+
+		if c.parse(src) == nil {
+			code = EXIT_ERROR
+		}
+	}
+
+	return code
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/pmezard/go-difflib/difflib"
+	"oilang/internal/format"
+	"os"
+)
+
+// fmt [-w | -d] [files...]
+func (c *cli) fmtCommand(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	write := flags.Bool("w", false, "write result to the source file instead of the standard output")
+	diff := flags.Bool("d", false, "show diff between the source and formatted code")
+
+	if err := flags.Parse(args); err != nil {
+		return EXIT_USAGE
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{STDIN_PATH}
+	}
+
+	if *write && *diff {
+		return c.usageError("-w and -d could not be used together")
+	}
+	for _, path := range paths {
+		if *write && path == STDIN_PATH {
+			return c.usageError("-w could not be used with the standard input")
+		}
+	}
+
+	code := EXIT_OK
+	for _, path := range paths {
+		src, err := c.readSource(path)
+		if err != nil {
+			_, _ = fmt.Fprintf(c.stderr, "error: %v\n", err)
+			code = EXIT_ERROR
+			continue
+		}
+
+		formatted, parseErr := format.Source(src.text)
+		if parseErr != nil {
+			c.report(src, parseErr.Diagnostics()...)
+			code = EXIT_ERROR
+			continue
+		}
+
+		switch {
+		case *diff:
+			err = c.printDiff(src, formatted)
+		case *write:
+			if formatted != src.text {
+				err = os.WriteFile(path, []byte(formatted), 0o644)
+			}
+		default:
+			_, err = fmt.Fprint(c.stdout, formatted)
+		}
+
+		if err != nil {
+			_, _ = fmt.Fprintf(c.stderr, "error: %v\n", err)
+			code = EXIT_ERROR
+		}
+	}
+
+	return code
+}
+
+// Prints unified diff of the changes made by formatter, nothing is printed if the source is already formatted
+func (c *cli) printDiff(src *source, formatted string) error {
+	return difflib.WriteUnifiedDiff(c.stdout, difflib.UnifiedDiff{
+		A:        difflib.SplitLines(src.text),
+		B:        difflib.SplitLines(formatted),
+		FromFile: src.name,
+		ToFile:   src.name + " (formatted)",
+		Context:  3,
+	})
+}
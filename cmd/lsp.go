@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+	"oilang/internal/lsp"
+)
+
+// lsp
+func (c *cli) lspCommand(args []string) int {
+	if len(args) > 0 {
+		return c.usageError("lsp does not accept arguments")
+	}
+
+	if err := lsp.NewServer(c.stdin, c.stdout).Run(); err != nil {
+		_, _ = fmt.Fprintf(c.stderr, "error: %v\n", err)
+		return EXIT_ERROR
+	}
+
+	return EXIT_OK
+}
@@ -3,8 +3,11 @@ package main
 import (
 	"fmt"
 	"github.com/fatih/color"
+	"io"
+	"oilang/internal/readline"
 	"oilang/internal/repl"
 	"os"
+	"strings"
 )
 
 const BANNER = `░░░░░░░█████╗░██╗░░░░░░░█████╗░██╗░░░░░░
@@ -20,10 +23,84 @@ const BANNER = `░░░░░░░█████╗░██╗░░░░
 ███████╗██║░░██║░░╚██╔╝░╚██╔╝░██║██║░╚███║
 ╚══════╝╚═╝░░╚═╝░░░╚═╝░░░╚═╝░░╚═╝╚═╝░░╚══╝`
 
+const USAGE = `Usage:
+  oi                          start the REPL
+  oi <file> [args...]         run the file
+  oi run <file> [args...]     run the file, "-" reads it from the standard input,
+                              "--engine=vm" runs it on the bytecode virtual machine,
+                              which does not support import yet,
+                              "--concurrent" runs stages of pipelines concurrently
+  oi check <files...>         check files for errors without running them
+  oi fmt [-w | -d] [files...] format files, "-w" rewrites them and "-d" shows the diff
+  oi lsp                      start language server that talks over the standard input and output
+  oi -e <source> [args...]    evaluate the source and print its result
+  oi help                     show this message
+`
+
+// Exit codes of the program
+const (
+	EXIT_OK    = 0
+	EXIT_ERROR = 1 // Source has errors or failed at runtime
+	EXIT_USAGE = 2 // Command is called with wrong arguments
+)
+
+// cli holds the streams commands work with, so they could be replaced in tests
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
 func main() {
-	color.Magenta(BANNER)
-	color.White("Welcome to the REPL of oi language.\nFeel free to play around!")
-	fmt.Println()
+	c := &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(c.run(os.Args[1:]))
+}
+
+// Dispatches arguments to the command and returns exit code
+func (c *cli) run(args []string) int {
+	if len(args) == 0 {
+		c.startRepl()
+		return EXIT_OK
+	}
+
+	switch args[0] {
+	case "run":
+		return c.runCommand(args[1:])
+	case "check":
+		return c.checkCommand(args[1:])
+	case "fmt":
+		return c.fmtCommand(args[1:])
+	case "lsp":
+		return c.lspCommand(args[1:])
+	case "-e":
+		return c.evalCommand(args[1:])
+	case "help", "-h", "--help":
+		_, _ = fmt.Fprint(c.stdout, USAGE)
+		return EXIT_OK
+	}
+
+	if strings.HasPrefix(args[0], "-") && args[0] != "-" {
+		return c.usageError("unknown flag %s", args[0])
+	}
+
+	// Running file directly allows to use it in the shebang
+	return c.runCommand(args)
+}
+
+// Banner is shown only for interactive sessions, so piped input produces only the results
+func (c *cli) startRepl() {
+	if f, ok := c.stdin.(*os.File); ok && readline.IsTerminal(f) {
+		color.Magenta(BANNER)
+		color.White("Welcome to the REPL of oi language.\nFeel free to play around!")
+		fmt.Println()
+	}
+
+	repl.Start(c.stdin, c.stdout)
+}
+
+func (c *cli) usageError(format string, a ...any) int {
+	_, _ = fmt.Fprintf(c.stderr, "error: "+format+"\n\n", a...)
+	_, _ = fmt.Fprint(c.stderr, USAGE)
 
-	repl.Start(os.Stdin, os.Stdout)
+	return EXIT_USAGE
 }
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runCLI(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	c := &cli{stdin: strings.NewReader(stdin), stdout: &stdout, stderr: &stderr}
+
+	code := c.run(args)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestEval(t *testing.T) {
+	code, out, _ := runCLI("", "-e", "1 + 2")
+	assert.Equal(t, EXIT_OK, code)
+	assert.Equal(t, "3\n", out)
+
+	code, out, _ = runCLI("", "-e", "args", "a", "b")
+	assert.Equal(t, EXIT_OK, code)
+	assert.Equal(t, "[\"a\", \"b\"]\n", out)
+
+	code, _, errOut := runCLI("", "-e", "1 + true")
+	assert.Equal(t, EXIT_ERROR, code)
+	assert.Contains(t, errOut, "type mismatch")
+}
+
+func TestRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.oi")
+	assert.NoError(t, os.WriteFile(path, []byte("#!/usr/bin/env oi\nlet x = 1\n"), 0o644))
+
+	code, _, _ := runCLI("", "run", path)
+	assert.Equal(t, EXIT_OK, code)
+
+	code, _, _ = runCLI("", path)
+	assert.Equal(t, EXIT_OK, code)
+
+	code, _, errOut := runCLI("", "run", filepath.Join(t.TempDir(), "missing.oi"))
+	assert.Equal(t, EXIT_ERROR, code)
+	assert.Contains(t, errOut, "error:")
+
+	code, _, errOut = runCLI("let = 1", "run", "-")
+	assert.Equal(t, EXIT_ERROR, code)
+	assert.Contains(t, errOut, "<stdin>:1:5")
+}
+
+func TestRunModules(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "lib"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "lib", "text.oi"), []byte("export fn trim(s) { s[1:] }\n"), 0o644))
+	path := filepath.Join(dir, "main.oi")
+	assert.NoError(t, os.WriteFile(path, []byte("import \"./lib/text.oi\" as text\ntext.trim(\" a\") + text.pad\n"), 0o644))
+
+	code, _, errOut := runCLI("", "run", path)
+	assert.Equal(t, EXIT_ERROR, code)
+	assert.Contains(t, errOut, "module text.oi has no export pad")
+
+	code, _, errOut = runCLI("", "run", "--engine=vm", path)
+	assert.Equal(t, EXIT_ERROR, code)
+	assert.Contains(t, errOut, "import is not supported by the vm engine")
+
+	assert.NoError(t, os.WriteFile(path, []byte("import \"./lib/text.oi\" as text\nlet x = text.trim(\" a\")\n"), 0o644))
+	code, _, _ = runCLI("", "run", path)
+	assert.Equal(t, EXIT_OK, code)
+}
+
+func TestRunEngine(t *testing.T) {
+	for _, engine := range []string{ENGINE_EVAL, ENGINE_VM} {
+		code, _, _ := runCLI("args -> @fn() { @ + \"!\" }", "run", "--engine="+engine, "-", "a", "b")
+		assert.Equal(t, EXIT_OK, code, engine)
+
+		code, _, errOut := runCLI("let f = fn() { 1 / 0 }\nf()", "run", "--engine="+engine, "-")
+		assert.Equal(t, EXIT_ERROR, code, engine)
+		assert.Contains(t, errOut, "<stdin>:1:18", engine)
+
+		code, _, errOut = runCLI("let f = fn(n) { f(n + 1) }; f(0)", "run", "--engine="+engine, "-")
+		assert.Equal(t, EXIT_ERROR, code, engine)
+		assert.Contains(t, errOut, "<stdin>:1:18", engine)
+	}
+
+	code, _, errOut := runCLI("[1, 2, 3] -> @fn() { @ - 1 } -> @fn() { 6 / @ }", "run", "--concurrent", "-")
+	assert.Equal(t, EXIT_ERROR, code)
+	assert.Contains(t, errOut, "division by zero\n --> <stdin>:1:43")
+
+	code, _, errOut = runCLI("", "run", "--concurrent", "--engine=vm", "-")
+	assert.Equal(t, EXIT_USAGE, code)
+	assert.Contains(t, errOut, "concurrent pipelines are not supported by the vm engine")
+
+	code, _, errOut = runCLI("", "run", "--engine=jit", "-")
+	assert.Equal(t, EXIT_USAGE, code)
+	assert.Contains(t, errOut, "unknown engine jit")
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.oi")
+	bad := filepath.Join(dir, "bad.oi")
+	assert.NoError(t, os.WriteFile(good, []byte("let x = 1 + true\n"), 0o644))
+	assert.NoError(t, os.WriteFile(bad, []byte("let = 1\nlet y = )\n"), 0o644))
+
+	code, _, errOut := runCLI("", "check", good)
+	assert.Equal(t, EXIT_OK, code)
+	assert.Empty(t, errOut)
+
+	code, _, errOut = runCLI("", "check", good, bad)
+	assert.Equal(t, EXIT_ERROR, code)
+	assert.Equal(t, 2, strings.Count(errOut, "error:"))
+}
+
+func TestCheckAnalysis(t *testing.T) {
+	code, _, errOut := runCLI("return args", "check", "-")
+	assert.Equal(t, EXIT_ERROR, code)
+	assert.Contains(t, errOut, "return outside of function")
+
+	code, _, errOut = runCLI("fn f() { let x = 1; 2 }", "check", "-")
+	assert.Equal(t, EXIT_OK, code)
+	assert.Contains(t, errOut, "warning: unused variable x")
+
+	code, _, errOut = runCLI("", "-e", "print(y)")
+	assert.Equal(t, EXIT_ERROR, code)
+	assert.Contains(t, errOut, "identifier not found: y")
+}
+
+func TestLsp(t *testing.T) {
+	body := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`
+
+	code, out, _ := runCLI(fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body), "lsp")
+	assert.Equal(t, EXIT_OK, code)
+	assert.Contains(t, out, `"hoverProvider":true`)
+}
+
+func TestUsage(t *testing.T) {
+	for _, args := range [][]string{{"run"}, {"check"}, {"-e"}, {"--unknown"}} {
+		code, _, errOut := runCLI("", args...)
+		assert.Equal(t, EXIT_USAGE, code, args)
+		assert.Contains(t, errOut, "Usage:")
+	}
+}
+
+func TestFmt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.oi")
+	assert.NoError(t, os.WriteFile(path, []byte("let x=1\n"), 0o644))
+
+	code, out, _ := runCLI("", "fmt", "-d", path)
+	assert.Equal(t, EXIT_OK, code)
+	assert.Contains(t, out, "-let x=1\n+let x = 1\n")
+
+	code, out, _ = runCLI("", "fmt", "-w", path)
+	assert.Equal(t, EXIT_OK, code)
+	assert.Empty(t, out)
+
+	formatted, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "let x = 1\n", string(formatted))
+
+	code, out, _ = runCLI("", "fmt", "-d", path)
+	assert.Equal(t, EXIT_OK, code)
+	assert.Empty(t, out)
+
+	code, out, _ = runCLI("print( 1 )", "fmt")
+	assert.Equal(t, EXIT_OK, code)
+	assert.Equal(t, "print(1)\n", out)
+
+	code, _, _ = runCLI("let = 1", "fmt", "-")
+	assert.Equal(t, EXIT_ERROR, code)
+
+	code, _, errOut := runCLI("let x=1", "fmt", "-w", path, "-")
+	assert.Equal(t, EXIT_USAGE, code)
+	assert.Contains(t, errOut, "-w could not be used with the standard input")
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"oilang/internal/compiler"
+	"oilang/internal/evaluator"
+	"oilang/internal/modules"
+	"oilang/internal/vm"
+)
+
+// Name under which command line arguments of the script are available
+const ARGS_NAME = "args"
+
+// Engines that could execute the program
+const (
+	ENGINE_EVAL = "eval" // Tree-walking evaluator
+	ENGINE_VM   = "vm"   // Bytecode compiler and virtual machine
+)
+
+// run [--engine=eval|vm] [--concurrent] <file> [args...]
+func (c *cli) runCommand(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	engine := flags.String("engine", ENGINE_EVAL, `engine that executes the program, "eval" or "vm"`)
+	concurrent := flags.Bool("concurrent", false, "stream arrays through the stages of pipelines concurrently")
+
+	if err := flags.Parse(args); err != nil {
+		return EXIT_USAGE
+	}
+
+	args = flags.Args()
+	if len(args) == 0 {
+		return c.usageError("file is not specified")
+	}
+	if *engine != ENGINE_EVAL && *engine != ENGINE_VM {
+		return c.usageError("unknown engine %s", *engine)
+	}
+	if *concurrent && *engine == ENGINE_VM {
+		return c.usageError("concurrent pipelines are not supported by the vm engine")
+	}
+
+	src, err := c.readSource(args[0])
+	if err != nil {
+		_, _ = fmt.Fprintf(c.stderr, "error: %v\n", err)
+		return EXIT_ERROR
+	}
+
+	if *engine == ENGINE_VM {
+		_, code := c.executeBytecode(src, args[1:])
+		return code
+	}
+
+	_, code := c.execute(src, args[1:], *concurrent)
+	return code
+}
+
+// -e <source> [args...]
+func (c *cli) evalCommand(args []string) int {
+	if len(args) == 0 {
+		return c.usageError("source is not specified")
+	}
+
+	result, code := c.execute(&source{name: "<eval>", text: args[0]}, args[1:], false)
+	if code == EXIT_OK && result != nil && result != evaluator.NULL {
+		_, _ = fmt.Fprintln(c.stdout, result.Inspect())
+	}
+
+	return code
+}
+
+// Parses and evaluates the source, passing arguments to it. Concurrent mode streams arrays through pipelines concurrently.
+// Returns result of the evaluation and exit code
+func (c *cli) execute(src *source, args []string, concurrent bool) (evaluator.Object, int) {
+	program := c.parse(src)
+	if program == nil {
+		return nil, EXIT_ERROR
+	}
+
+	env := evaluator.NewEnvironment()
+	env.Set(ARGS_NAME, stringsToArray(args))
+	env.SetImporter(modules.NewLoader(src.path), src.path)
+	env.SetConcurrent(concurrent)
+	// Default limits only bound the depth of calls, so deep recursion is reported as error
+	env.SetGuard(evaluator.NewGuard(context.Background(), evaluator.Limits{}))
+
+	result := evaluator.Eval(program, env)
+	if err, ok := result.(*evaluator.Error); ok {
+		c.report(src, err.Diagnostic())
+		return nil, EXIT_ERROR
+	}
+
+	return result, EXIT_OK
+}
+
+// Same as execute, but compiles the program and runs it on the virtual machine
+func (c *cli) executeBytecode(src *source, args []string) (evaluator.Object, int) {
+	program := c.parse(src)
+	if program == nil {
+		return nil, EXIT_ERROR
+	}
+
+	comp := compiler.New()
+	argsIndex := comp.Define(ARGS_NAME)
+
+	bytecode, compileErr := comp.Compile(program)
+	if compileErr != nil {
+		c.report(src, compileErr.Diagnostic())
+		return nil, EXIT_ERROR
+	}
+
+	machine := vm.New(bytecode)
+	machine.SetGlobal(argsIndex, stringsToArray(args))
+	machine.SetGuard(evaluator.NewGuard(context.Background(), evaluator.Limits{}))
+
+	result := machine.Run()
+	if err, ok := result.(*evaluator.Error); ok {
+		c.report(src, err.Diagnostic())
+		return nil, EXIT_ERROR
+	}
+
+	return result, EXIT_OK
+}
+
+func stringsToArray(values []string) *evaluator.Array {
+	elements := make([]evaluator.Object, 0, len(values))
+	for _, v := range values {
+		elements = append(elements, &evaluator.String{Value: v})
+	}
+
+	return &evaluator.Array{Elements: elements}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"oilang/internal/analysis"
+	"oilang/internal/ast"
+	"oilang/internal/diagnostics"
+	"oilang/internal/lexer"
+	"oilang/internal/parser"
+	"oilang/internal/readline"
+	"os"
+)
+
+// Path that makes commands read the source from the standard input
+const STDIN_PATH = "-"
+
+// source is a program text and the name it's reported under
+type source struct {
+	name string
+	text string
+	// File the text is read from, empty if it does not come from a file. Imports are resolved relative to it
+	path string
+}
+
+func (c *cli) readSource(path string) (*source, error) {
+	if path == STDIN_PATH {
+		text, err := io.ReadAll(c.stdin)
+		if err != nil {
+			return nil, err
+		}
+
+		return &source{name: "<stdin>", text: string(text)}, nil
+	}
+
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &source{name: path, text: string(text), path: path}, nil
+}
+
+// Parses and analyzes the source, reporting errors and warnings. Returns nil if the source has errors
+func (c *cli) parse(src *source) *ast.Program {
+	program, err := parser.New(lexer.New(src.text)).Parse()
+	if err != nil {
+		c.report(src, err.Diagnostics()...)
+		return nil
+	}
+
+	ds := analysis.Analyze(program, ARGS_NAME)
+	if len(ds) > 0 {
+		c.report(src, ds...)
+	}
+	if ds.HasErrors() {
+		return nil
+	}
+
+	return program
+}
+
+// Writes diagnostics to the standard error
+func (c *cli) report(src *source, ds ...diagnostics.Diagnostic) {
+	_, _ = fmt.Fprint(c.stderr, c.renderer(src).RenderAll(ds))
+}
+
+// Diagnostics are colored only when they are shown in the terminal
+func (c *cli) renderer(src *source) *diagnostics.Renderer {
+	f, ok := c.stderr.(*os.File)
+	useColor := ok && readline.IsTerminal(f) && os.Getenv("NO_COLOR") == ""
+
+	return diagnostics.NewRenderer(src.name, src.text, useColor)
+}
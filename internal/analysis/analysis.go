@@ -0,0 +1,543 @@
+package analysis
+
+import (
+	"fmt"
+	"oilang/internal/ast"
+	"oilang/internal/diagnostics"
+	"oilang/internal/evaluator"
+	"oilang/internal/token"
+	"sort"
+	"strings"
+)
+
+// Diagnostics is a list of problems found in the program, in order of their position
+type Diagnostics []diagnostics.Diagnostic
+
+// HasErrors Tells if the program should not be run, warnings are not counted
+func (d Diagnostics) HasErrors() bool {
+	for _, diagnostic := range d {
+		if diagnostic.Severity == diagnostics.Error {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Analyze Checks the parsed program for problems that could be found without running it:
+// misplaced return, break, continue and "@", undefined names, duplicate parameters, redeclared and unused variables,
+// assignments to constants and builtin functions, names bound twice by patterns and match expressions that are not exhaustive.
+//
+// Predeclared names are treated as globals defined before the program, e.g. script arguments or
+// bindings of the previous REPL inputs. Builtin functions are always defined
+func Analyze(program *ast.Program, predeclared ...string) Diagnostics {
+	return Inspect(program, predeclared...).Diagnostics
+}
+
+// Inspect Analyzes the program the same way Analyze does and also tells which declarations
+// the names refer to and where they are visible, so tools could navigate the source
+func Inspect(program *ast.Program, predeclared ...string) *Info {
+	globals := newScope(nil, false)
+	for _, name := range evaluator.BuiltinNames() {
+		globals.bindings[name] = &binding{declared: true, used: true, builtin: true}
+	}
+	for _, name := range predeclared {
+		globals.bindings[name] = &binding{declared: true, used: true}
+	}
+
+	a := &analyzer{scope: globals, top: newScope(globals, false), info: &Info{Definitions: make(map[*ast.Identifier]*ast.Identifier)}}
+	a.statements(program.Statements, a.top, Position{}, endOfSource)
+
+	diagnostics := a.info.Diagnostics
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return positionOf(diagnostics[i].Token).Before(positionOf(diagnostics[j].Token))
+	})
+
+	return a.info
+}
+
+type analyzer struct {
+	scope *scope
+	// Scope of the program itself, where modules could be imported and names exported
+	top *scope
+	// Amount of functions around the current node
+	functions int
+	// Labels of the loops of the current function around the node, empty for loops without label
+	loops []string
+	info  *Info
+}
+
+func (a *analyzer) error(tok token.Token, format string, args ...any) {
+	a.info.Diagnostics = append(a.info.Diagnostics, diagnostics.New(diagnostics.Error, tok, fmt.Sprintf(format, args...)))
+}
+
+func (a *analyzer) warning(tok token.Token, message string, notes ...string) {
+	a.info.Diagnostics = append(a.info.Diagnostics, diagnostics.New(diagnostics.Warning, tok, message, notes...))
+}
+
+// Analyzes statements inside the scope. Names declared by the statements are known in advance,
+// so functions could refer to the ones declared after them
+func (a *analyzer) statements(stmts []ast.Statement, s *scope, start, end Position) {
+	visible := &Scope{Start: start, End: end}
+	for _, stmt := range stmts {
+		if name := declaredName(stmt); name != nil {
+			if _, ok := s.bindings[name.Value]; !ok {
+				s.bindings[name.Value] = &binding{ident: name, local: a.functions > 0, constant: isConstant(stmt)}
+				visible.Names = append(visible.Names, name)
+			}
+		}
+	}
+	a.info.Scopes = append(a.info.Scopes, visible)
+
+	outer := a.scope
+	a.scope = s
+	for _, stmt := range stmts {
+		a.statement(stmt)
+	}
+	a.scope = outer
+
+	a.reportUnused(s)
+}
+
+func (a *analyzer) statement(stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		a.expression(stmt.Value)
+		a.declare(stmt.Name)
+		a.info.Definitions[stmt.Name] = a.scope.bindings[stmt.Name.Value].ident
+		a.scope.bindings[stmt.Name.Value].stage = isStageLiteral(stmt.Value)
+	case *ast.ReturnStatement:
+		if a.functions == 0 {
+			a.error(stmt.Token, "return outside of function")
+		}
+		a.expression(stmt.ReturnValue)
+	case *ast.ImportStatement:
+		if a.scope != a.top {
+			a.error(stmt.Token, "import outside of top level")
+		}
+		a.declare(stmt.Alias)
+		a.info.Definitions[stmt.Alias] = stmt.Alias
+	case *ast.ExportStatement:
+		if a.scope != a.top {
+			a.error(stmt.Token, "export outside of top level")
+		}
+		a.statement(stmt.Statement)
+	case *ast.WhileStatement:
+		a.expression(stmt.Condition)
+		a.loop(stmt.Label, nil, stmt.Body)
+	case *ast.ForStatement:
+		a.expression(stmt.Iterable)
+		names := []*ast.Identifier{stmt.Value}
+		if stmt.Key != nil {
+			names = []*ast.Identifier{stmt.Key, stmt.Value}
+		}
+		a.loop(stmt.Label, names, stmt.Body)
+	case *ast.BreakStatement:
+		a.jump(stmt.Token, stmt.Label)
+	case *ast.ContinueStatement:
+		a.jump(stmt.Token, stmt.Label)
+	case *ast.ExpressionStatement:
+		// Named function is declared before its body, so it could call itself
+		if name := declaredName(stmt); name != nil {
+			fn := stmt.Expression.(*ast.FunctionLiteral)
+			a.declare(name)
+			a.info.Definitions[name] = a.scope.bindings[name.Value].ident
+			a.scope.bindings[name.Value].used = true
+			a.scope.bindings[name.Value].stage = fn.IsPipelineStage
+			a.function(fn, false)
+			return
+		}
+
+		a.expression(stmt.Expression)
+	}
+}
+
+func (a *analyzer) expression(exp ast.Expression) {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		a.resolve(exp)
+	case *ast.PrefixExpression:
+		a.expression(exp.Operand)
+	case *ast.InfixExpression:
+		a.expression(exp.Left)
+		a.expression(exp.Right)
+	case *ast.AssignExpression:
+		a.assign(exp)
+	case *ast.IfExpression:
+		a.expression(exp.Condition)
+		a.block(exp.Consequnce)
+		if exp.Alternative != nil {
+			a.block(exp.Alternative)
+		}
+	case *ast.MatchExpression:
+		a.match(exp)
+	case *ast.FunctionLiteral:
+		a.function(exp, exp.Name != nil)
+	case *ast.CallExpression:
+		if a.isStageFunction(exp.CalledExpression) {
+			a.error(exp.Token, "@fn could be called only as pipeline stage")
+		}
+
+		a.call(exp)
+	case *ast.ArrayLiteral:
+		for _, e := range exp.Elements {
+			a.expression(e)
+		}
+	case *ast.HashLiteral:
+		for _, pair := range exp.Pairs {
+			a.expression(pair.Key)
+			a.expression(pair.Value)
+		}
+	case *ast.IndexExpression:
+		a.expression(exp.Left)
+		a.expression(exp.Index)
+	case *ast.MemberExpression:
+		a.expression(exp.Object)
+	case *ast.SliceExpression:
+		a.expression(exp.Left)
+		a.expression(exp.Low)
+		a.expression(exp.High)
+	case *ast.TemplateLiteral:
+		for _, part := range exp.Parts {
+			a.expression(part)
+		}
+	case *ast.PipelineExpression:
+		a.expression(exp.Source)
+
+		// Each stage has its own "@" bound to the value passed to it
+		for _, stage := range exp.Stages {
+			s := newScope(a.scope, false)
+			s.bindings[evaluator.PIPELINE_CONTEXT] = &binding{declared: true, used: true}
+
+			outer := a.scope
+			a.scope = s
+			// Stage function gets the value along with the arguments when it's called at the pipe site
+			if call, ok := stage.(*ast.CallExpression); ok {
+				a.call(call)
+			} else {
+				a.expression(stage)
+			}
+			a.scope = outer
+		}
+	}
+}
+
+func (a *analyzer) call(call *ast.CallExpression) {
+	a.expression(call.CalledExpression)
+	for _, arg := range call.Arguments {
+		a.expression(arg)
+	}
+}
+
+func (a *analyzer) block(block *ast.BlockStatement) {
+	a.statements(block.Statements, newScope(a.scope, false), positionOf(block.Token), positionOf(block.Rbrace))
+}
+
+// Named function literal that is not a declaration sees its own name, unless a parameter hides it
+func (a *analyzer) function(fn *ast.FunctionLiteral, selfBound bool) {
+	s := newScope(a.scope, true)
+	params := &Scope{Start: positionOf(fn.Body.Token), End: positionOf(fn.Body.Rbrace)}
+	a.info.Scopes = append(a.info.Scopes, params)
+
+	for _, param := range fn.Parameters {
+		a.info.Definitions[param] = param
+		if _, ok := s.bindings[param.Value]; ok {
+			a.error(param.Token, "duplicate parameter %s", param.Value)
+			continue
+		}
+
+		s.bindings[param.Value] = &binding{ident: param, declared: true, used: true}
+		params.Names = append(params.Names, param)
+	}
+	// Stage function receives the value passed through pipeline as "@"
+	if fn.IsPipelineStage {
+		s.bindings[evaluator.PIPELINE_CONTEXT] = &binding{declared: true, used: true}
+	}
+	if selfBound {
+		if _, isParameter := s.bindings[fn.Name.Value]; !isParameter {
+			a.info.Definitions[fn.Name] = fn.Name
+			s.bindings[fn.Name.Value] = &binding{ident: fn.Name, declared: true, used: true, stage: fn.IsPipelineStage}
+			params.Names = append(params.Names, fn.Name)
+		}
+	}
+
+	// Loops around the function could not be stopped from inside of it
+	outerLoops := a.loops
+	a.loops = nil
+
+	a.functions++
+	a.statements(fn.Body.Statements, newScope(s, false), params.Start, params.End)
+	a.functions--
+
+	a.loops = outerLoops
+}
+
+// Names bound by the patterns of the arm are visible in its guard and value until the next arm starts.
+// Match without an arm that matches any value is reported, because it silently produces null for the values it misses
+func (a *analyzer) match(exp *ast.MatchExpression) {
+	a.expression(exp.Subject)
+
+	exhaustive := false
+	for i, arm := range exp.Arms {
+		end := positionOf(exp.Rbrace)
+		if i+1 < len(exp.Arms) {
+			end = positionOf(patternToken(exp.Arms[i+1].Patterns[0]))
+		}
+
+		s := newScope(a.scope, false)
+		visible := &Scope{Start: positionOf(patternToken(arm.Patterns[0])), End: end}
+		a.info.Scopes = append(a.info.Scopes, visible)
+
+		for _, pattern := range arm.Patterns {
+			names := ast.PatternNames(pattern)
+			// Names are still bound, so their usages are not reported as undefined
+			if len(arm.Patterns) > 1 && len(names) > 0 {
+				a.error(names[0].Token, "alternative patterns could not bind names")
+			}
+
+			seen := make(map[string]bool)
+			for _, n := range names {
+				a.info.Definitions[n] = n
+				if seen[n.Value] {
+					a.error(n.Token, "duplicate pattern variable %s", n.Value)
+					continue
+				}
+				seen[n.Value] = true
+
+				if _, ok := s.bindings[n.Value]; !ok {
+					s.bindings[n.Value] = &binding{ident: n, declared: true, local: a.functions > 0}
+					visible.Names = append(visible.Names, n)
+				}
+			}
+
+			exhaustive = exhaustive || arm.Guard == nil && matchesAny(pattern)
+		}
+
+		outer := a.scope
+		a.scope = s
+		a.expression(arm.Guard)
+		if arm.Body != nil {
+			a.block(arm.Body)
+		} else {
+			a.expression(arm.Value)
+		}
+		a.scope = outer
+
+		a.reportUnused(s)
+	}
+
+	if !exhaustive {
+		a.warning(exp.Token, "match is not exhaustive", `add "_" arm to handle the rest of the values`)
+	}
+}
+
+// Analyzes body of the loop, where the names of for loop are declared
+func (a *analyzer) loop(label *ast.Identifier, names []*ast.Identifier, body *ast.BlockStatement) {
+	name := ""
+	if label != nil {
+		name = label.Value
+		for _, l := range a.loops {
+			if l == name {
+				a.error(label.Token, "label %s is already used by the enclosing loop", name)
+			}
+		}
+	}
+
+	s := newScope(a.scope, false)
+	visible := &Scope{Start: positionOf(body.Token), End: positionOf(body.Rbrace)}
+	a.info.Scopes = append(a.info.Scopes, visible)
+
+	for _, n := range names {
+		a.info.Definitions[n] = n
+		if _, ok := s.bindings[n.Value]; ok {
+			a.error(n.Token, "duplicate loop variable %s", n.Value)
+			continue
+		}
+
+		s.bindings[n.Value] = &binding{ident: n, declared: true, local: a.functions > 0}
+		visible.Names = append(visible.Names, n)
+	}
+
+	a.loops = append(a.loops, name)
+
+	outer := a.scope
+	a.scope = s
+	a.block(body)
+	a.scope = outer
+
+	a.loops = a.loops[:len(a.loops)-1]
+	a.reportUnused(s)
+}
+
+// Checks that break or continue has the loop to jump to
+func (a *analyzer) jump(tok token.Token, label *ast.Identifier) {
+	if label == nil {
+		if len(a.loops) == 0 {
+			a.error(tok, "%s outside of loop", tok.Literal)
+		}
+		return
+	}
+
+	for _, l := range a.loops {
+		if l == label.Value {
+			return
+		}
+	}
+
+	a.error(label.Token, "unknown label %s", label.Value)
+}
+
+// Marks the name as declared in the current scope. Binding is created in advance by the statements
+func (a *analyzer) declare(name *ast.Identifier) {
+	b := a.scope.bindings[name.Value]
+	if b.declared {
+		a.error(name.Token, "%s is already declared in this block", name.Value)
+		return
+	}
+
+	b.declared = true
+}
+
+func (a *analyzer) resolve(ident *ast.Identifier) {
+	if b := a.lookup(ident.Value); b != nil {
+		b.used = true
+		if b.ident != nil {
+			a.info.Definitions[ident] = b.ident
+		}
+		return
+	}
+
+	if ident.Value == evaluator.PIPELINE_CONTEXT {
+		a.error(ident.Token, "%s used outside of pipeline stage", ident.Value)
+		return
+	}
+
+	a.error(ident.Token, "identifier not found: %s", ident.Value)
+}
+
+// Variable that is assigned is considered used, so the assignment does not produce a warning
+func (a *analyzer) assign(exp *ast.AssignExpression) {
+	a.expression(exp.Target)
+	a.expression(exp.Value)
+
+	ident, ok := exp.Target.(*ast.Identifier)
+	if !ok {
+		return
+	}
+
+	switch b := a.lookup(ident.Value); {
+	case b == nil:
+	case b.constant:
+		a.error(ident.Token, "cannot assign to constant %s", ident.Value)
+	case b.builtin:
+		a.error(ident.Token, "cannot assign to builtin function %s", ident.Value)
+	}
+}
+
+// Returns binding the name refers to, or nil if it's not defined
+func (a *analyzer) lookup(name string) *binding {
+	// Function could be called only after the enclosing scope has declared all of its names
+	crossedFunction := false
+
+	for s := a.scope; s != nil; s = s.outer {
+		if b, ok := s.bindings[name]; ok && (b.declared || crossedFunction) {
+			return b
+		}
+
+		crossedFunction = crossedFunction || s.function
+	}
+
+	return nil
+}
+
+// Tells if the expression is known to produce stage function
+func (a *analyzer) isStageFunction(exp ast.Expression) bool {
+	if ident, ok := exp.(*ast.Identifier); ok {
+		b := a.lookup(ident.Value)
+		return b != nil && b.stage
+	}
+
+	return isStageLiteral(exp)
+}
+
+func (a *analyzer) reportUnused(s *scope) {
+	for name, b := range s.bindings {
+		if b.local && !b.used && !strings.HasPrefix(name, "_") {
+			a.warning(b.ident.Token, "unused variable "+name, `remove it or prefix its name with "_"`)
+		}
+	}
+}
+
+// Tells if the pattern matches any value, e.g. "_" or a name
+func matchesAny(pattern ast.Pattern) bool {
+	switch pattern.(type) {
+	case *ast.WildcardPattern, *ast.BindingPattern:
+		return true
+	}
+
+	return false
+}
+
+// Returns the first token of the pattern
+func patternToken(pattern ast.Pattern) token.Token {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return pattern.Token
+	case *ast.BindingPattern:
+		return pattern.Name.Token
+	case *ast.ArrayPattern:
+		return pattern.Token
+	case *ast.HashPattern:
+		return pattern.Token
+	case *ast.LiteralPattern:
+		switch value := pattern.Value.(type) {
+		case *ast.IntegerLiteral:
+			return value.Token
+		case *ast.FloatLiteral:
+			return value.Token
+		case *ast.StringLiteral:
+			return value.Token
+		case *ast.BoolExpression:
+			return value.Token
+		case *ast.PrefixExpression:
+			return value.Token
+		}
+	}
+
+	return token.Token{}
+}
+
+func isStageLiteral(exp ast.Expression) bool {
+	fn, ok := exp.(*ast.FunctionLiteral)
+	return ok && fn.IsPipelineStage
+}
+
+// Tells if the statement declares constant, which could not be assigned
+func isConstant(stmt ast.Statement) bool {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return stmt.Token.Type == token.CONST
+	case *ast.ExportStatement:
+		return isConstant(stmt.Statement)
+	}
+
+	return false
+}
+
+// Returns name that is bound by the statement in its block
+func declaredName(stmt ast.Statement) *ast.Identifier {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return stmt.Name
+	case *ast.ImportStatement:
+		return stmt.Alias
+	case *ast.ExportStatement:
+		return declaredName(stmt.Statement)
+	case *ast.ExpressionStatement:
+		if fn, ok := stmt.Expression.(*ast.FunctionLiteral); ok && fn.Name != nil {
+			return fn.Name
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package analysis
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"oilang/internal/lexer"
+	"oilang/internal/parser"
+	"testing"
+)
+
+func analyze(t *testing.T, input string, predeclared ...string) []string {
+	program, err := parser.New(lexer.New(input)).Parse()
+	assert.Nil(t, err, input)
+
+	var result []string
+	for _, d := range Analyze(program, predeclared...) {
+		result = append(result, fmt.Sprintf("%s %d:%d: %s", d.Severity, d.Token.Line+1, d.Token.Col+1, d.Message))
+	}
+
+	return result
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1\nprint(x + len([]))", nil},
+		{"return 1", []string{"error 1:1: return outside of function"}},
+		{"fn f() { if true { return 1 } }", nil},
+		{"[1] -> @fn() { return @ }", nil},
+		{"print(y)", []string{"error 1:7: identifier not found: y"}},
+		{"let x = x", []string{"error 1:9: identifier not found: x"}},
+		{"fn f(a, b, a) { a + b }", []string{"error 1:12: duplicate parameter a"}},
+		{"let x = 1\nlet x = 2", []string{"error 2:5: x is already declared in this block"}},
+		{"fn f() { 1 }; fn f() { 2 }", []string{"error 1:18: f is already declared in this block"}},
+		{"let x = 1\nif x { let x = 2; x }", nil},
+		{"if true { let y = 1; }; y", []string{"error 1:25: identifier not found: y"}},
+		{"@ + 1", []string{"error 1:1: @ used outside of pipeline stage"}},
+		{"fn f() { @ }", []string{"error 1:10: @ used outside of pipeline stage"}},
+		{"[1] -> @ + 1 -> fn(x) { @ * x }", nil},
+		{"fn f() { let x = 1; let _y = 2; 3 }", []string{"warning 1:14: unused variable x"}},
+		{"let unusedGlobal = 1", nil},
+		{"@fn double() { @ * 2 }\n[1] -> double -> double()", nil},
+		{"let scale = @fn(by) { @ * by }\n[1] -> scale(len(@))", nil},
+		{"@fn double() { @ * 2 }\ndouble()", []string{"error 2:7: @fn could be called only as pipeline stage"}},
+		{"let f = @fn() { 1 }\n1 -> f() + 1", []string{"error 2:7: @fn could be called only as pipeline stage"}},
+		{"@fn(x) { x }(1)", []string{"error 1:13: @fn could be called only as pipeline stage"}},
+		{"let f = @fn() { 1 }\nfn g(f) { f() }", nil},
+		// Functions are called after the names they refer to are declared
+		{"fn even(n) { if n == 0 { true } else { odd(n - 1) } }\nfn odd(n) { if n == 0 { false } else { even(n - 1) } }", nil},
+		{"fn fact(n) { if n < 2 { 1 } else { n * fact(n - 1) } }", nil},
+		// Named function literal sees only its own name
+		{"let f = fn fact(n) { if n < 2 { 1 } else { n * fact(n - 1) } }; f(3)", nil},
+		{"let f = fn fact(n) { 1 }; fact(3)", []string{"error 1:27: identifier not found: fact"}},
+		{"[1] -> fn go(go) { go }", nil},
+		{"let f = @fn go() { go() }", []string{"error 1:22: @fn could be called only as pipeline stage"}},
+		{"y + 1\nlet y = 1", []string{"error 1:1: identifier not found: y"}},
+		{"print(args)", []string{"error 1:7: identifier not found: args"}},
+		{"import \"./a.oi\" as a\nexport let b = a.c\nexport fn d() { b.e }", nil},
+		{"fn f() { import \"./a.oi\" as a; export let b = a; }", []string{
+			"error 1:10: import outside of top level", "error 1:32: export outside of top level", "warning 1:43: unused variable b",
+		}},
+		{"import \"./a.oi\" as a\nimport \"./b.oi\" as a", []string{"error 2:20: a is already declared in this block"}},
+		{"x.y", []string{"error 1:1: identifier not found: x"}},
+		{"return a", []string{"error 1:1: return outside of function", "error 1:8: identifier not found: a"}},
+		{"for i, x in [1] { print(i + x) }\nwhile true { break }", nil},
+		{"for x in [1] { let y = x; }; y + x", []string{"error 1:30: identifier not found: y", "error 1:34: identifier not found: x"}},
+		{"for x in x { x }", []string{"error 1:10: identifier not found: x"}},
+		{"for x, x in [] {}", []string{"error 1:8: duplicate loop variable x"}},
+		{"fn f() { for i, x in [] { x } }", []string{"warning 1:14: unused variable i"}},
+		{"break\ncontinue", []string{"error 1:1: break outside of loop", "error 2:1: continue outside of loop"}},
+		{"while true { fn() { break } }", []string{"error 1:21: break outside of loop"}},
+		{"a: while true { b: for x in [] { continue a }; break b }", []string{"error 1:54: unknown label b"}},
+		{"a: while true { a: while true {} }", []string{"error 1:17: label a is already used by the enclosing loop"}},
+		{"let x = 1\nx = 2\nx += x", nil},
+		{"fn f() { let n = 0; fn() { n += 1 } }", nil},
+		{"let xs = [1]; xs[0] = 2; let h = {}; h.a = xs", nil},
+		{"z = 1", []string{"error 1:1: identifier not found: z"}},
+		{"const c = 1\nc = 2\nc += 1", []string{"error 2:1: cannot assign to constant c", "error 3:1: cannot assign to constant c"}},
+		{"const xs = [1]; xs[0] = 2", nil},
+		{"fn f() { c = 2 }\nexport const c = 1", []string{"error 1:10: cannot assign to constant c"}},
+		{"const c = 1\nif true { let c = 2; c = 3 }", nil},
+		{"len = 1", []string{"error 1:1: cannot assign to builtin function len"}},
+		{"fn f() { let x = 1; x = 2 }", nil},
+		{"if x { 1 } else if y { 2 }", []string{"error 1:4: identifier not found: x", "error 1:20: identifier not found: y"}},
+		{"match 1 { 1 | 2 => 3, [a, ...b] => a + len(b), {n} if n > 1 => n, _ => 0 }", nil},
+		{"match 1 { 1 => 2 }", []string{"warning 1:1: match is not exhaustive"}},
+		{"match 1 { n if n > 1 => 2 }", []string{"warning 1:1: match is not exhaustive"}},
+		{"match 1 { n => n }\nn", []string{"error 2:1: identifier not found: n"}},
+		{"match 1 { [a] => a, _ => a }", []string{"error 1:26: identifier not found: a"}},
+		{"match 1 { [a, a] => a, _ => 0 }", []string{"error 1:15: duplicate pattern variable a"}},
+		{"match 1 { [a] | a => a, _ => 0 }", []string{"error 1:12: alternative patterns could not bind names", "error 1:17: alternative patterns could not bind names"}},
+		{"fn f(x) { match x { [head, ...rest] => head, _ => 0 } }", []string{"warning 1:31: unused variable rest"}},
+		{"match y { _ => 1 }", []string{"error 1:7: identifier not found: y"}},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, analyze(t, test.input), test.input)
+	}
+}
+
+func TestPredeclared(t *testing.T) {
+	assert.Nil(t, analyze(t, "print(args)", "args"))
+}
+
+func TestHasErrors(t *testing.T) {
+	program, _ := parser.New(lexer.New("fn f() { let x = 1; 2 }")).Parse()
+	assert.False(t, Analyze(program).HasErrors())
+
+	program, _ = parser.New(lexer.New("return")).Parse()
+	assert.True(t, Analyze(program).HasErrors())
+}
+
+func TestInspect(t *testing.T) {
+	input := "let x = 1\nfn f(a) {\n    let b = a + x\n    b\n}\nf(x)"
+	program, _ := parser.New(lexer.New(input)).Parse()
+	info := Inspect(program)
+
+	definitions := make(map[string]string)
+	for use, def := range info.Definitions {
+		key := fmt.Sprintf("%s %d:%d", use.Value, use.Token.Line+1, use.Token.Col+1)
+		definitions[key] = fmt.Sprintf("%d:%d", def.Token.Line+1, def.Token.Col+1)
+	}
+
+	assert.Equal(t, map[string]string{
+		"x 1:5": "1:5", "f 2:4": "2:4", "a 2:6": "2:6", "b 3:9": "3:9",
+		"a 3:13": "2:6", "x 3:17": "1:5", "b 4:5": "3:9",
+		"f 6:1": "2:4", "x 6:3": "1:5",
+	}, definitions)
+
+	var names []string
+	for _, name := range info.VisibleNames(Position{Line: 3, Col: 4}) {
+		names = append(names, name.Value)
+	}
+	assert.Equal(t, []string{"b", "a", "x", "f"}, names)
+
+	names = nil
+	for _, name := range info.VisibleNames(Position{Line: 5, Col: 0}) {
+		names = append(names, name.Value)
+	}
+	assert.Equal(t, []string{"x", "f"}, names)
+}
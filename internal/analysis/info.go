@@ -0,0 +1,70 @@
+package analysis
+
+import (
+	"math"
+	"oilang/internal/ast"
+	"oilang/internal/token"
+)
+
+// Info is everything the analysis has found out about the program
+type Info struct {
+	Diagnostics Diagnostics
+	// Declaring identifiers of the names by their usages. Declarations refer to themselves,
+	// while builtins, predeclared and undefined names are not included
+	Definitions map[*ast.Identifier]*ast.Identifier
+	// Regions of the source with names declared in them, outer scopes come before the inner ones
+	Scopes []*Scope
+}
+
+// Position is a place in the source, both line and column start from 0
+type Position struct {
+	Line int
+	Col  int
+}
+
+// Position after any token of the source
+var endOfSource = Position{Line: math.MaxInt, Col: math.MaxInt}
+
+func positionOf(tok token.Token) Position {
+	return Position{Line: tok.Line, Col: tok.Col}
+}
+
+// Before Tells if the position comes before the other one
+func (p Position) Before(other Position) bool {
+	return p.Line < other.Line || p.Line == other.Line && p.Col < other.Col
+}
+
+// Scope is a region of the source between its start and end where the names are visible,
+// e.g. the whole program or the body of a block
+type Scope struct {
+	Start Position
+	End   Position
+	Names []*ast.Identifier
+}
+
+// Contains Tells if the position is inside the scope
+func (s *Scope) Contains(p Position) bool {
+	return !p.Before(s.Start) && p.Before(s.End)
+}
+
+// VisibleNames Returns identifiers declared in the scopes that contain the position, inner declarations
+// come first and shadow the outer ones with the same name
+func (i *Info) VisibleNames(p Position) []*ast.Identifier {
+	var result []*ast.Identifier
+	seen := make(map[string]bool)
+
+	for j := len(i.Scopes) - 1; j >= 0; j-- {
+		if !i.Scopes[j].Contains(p) {
+			continue
+		}
+
+		for _, name := range i.Scopes[j].Names {
+			if !seen[name.Value] {
+				seen[name.Value] = true
+				result = append(result, name)
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,31 @@
+package analysis
+
+import "oilang/internal/ast"
+
+// binding is a name declared in the scope
+type binding struct {
+	// Identifier that declares the name, nil for builtins, predeclared names and "@"
+	ident *ast.Identifier
+	// Statement that declares the name was already visited, so it could be used
+	declared bool
+	used     bool
+	// Declared inside a function, so it's reported when it's not used
+	local bool
+	// Bound to the stage function (@fn), which could not be called outside of pipeline
+	stage bool
+	// Declared with const, so it could not be assigned
+	constant bool
+	builtin  bool
+}
+
+// scope holds names declared in a block, a function parameters list or a pipeline stage
+type scope struct {
+	outer    *scope
+	bindings map[string]*binding
+	// Scope of function parameters, names inside of it are resolved only when the function is called
+	function bool
+}
+
+func newScope(outer *scope, function bool) *scope {
+	return &scope{outer: outer, bindings: make(map[string]*binding), function: function}
+}
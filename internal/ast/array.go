@@ -0,0 +1,21 @@
+package ast
+
+import (
+	"oilang/internal/token"
+	"strings"
+)
+
+type ArrayLiteral struct {
+	Token    token.Token // The "[" token
+	Elements []Expression
+}
+
+func (*ArrayLiteral) expressionNode() {}
+func (al *ArrayLiteral) String() string {
+	var elements []string
+	for _, e := range al.Elements {
+		elements = append(elements, e.String())
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
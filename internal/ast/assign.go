@@ -0,0 +1,47 @@
+package ast
+
+import (
+	"fmt"
+	"oilang/internal/token"
+)
+
+// Infix operators that are applied by compound assignments before the value is stored
+var compoundOperators = map[token.TokenType]token.TokenType{
+	token.PLUS_ASSIGN:     token.PLUS,
+	token.MINUS_ASSIGN:    token.MINUS,
+	token.MULTIPLY_ASSIGN: token.MULTIPLY,
+	token.DIVIDE_ASSIGN:   token.DIVIDE,
+	token.POWER_ASSIGN:    token.POWER,
+}
+
+// AssignExpression stores the value in the variable, element of the collection or member of the hash, e.g. x = 1 or xs[0] += 2.
+// Its value is the stored value, so assignments could be chained: a = b = 0
+type AssignExpression struct {
+	Token  token.Token // Assignment operator, e.g. "=" or "+="
+	Target Expression  // Either *Identifier, *IndexExpression or *MemberExpression
+	Value  Expression
+}
+
+func (*AssignExpression) expressionNode() {}
+func (ae *AssignExpression) Operator() string {
+	return ae.Token.Literal
+}
+func (ae *AssignExpression) String() string {
+	return fmt.Sprintf("(%s %s %s)", ae.Target, ae.Operator(), ae.Value)
+}
+
+// Compound Returns the infix operator that combines the current value of the target with the assigned one, e.g. "+" for "+=".
+// Operator has the position of the assignment, so errors point to it. Returns false for the plain assignment
+func (ae *AssignExpression) Compound() (token.Token, bool) {
+	t, ok := compoundOperators[ae.Token.Type]
+	if !ok {
+		return token.Token{}, false
+	}
+
+	tok := ae.Token
+	tok.Type = t
+	tok.Literal = tok.Literal[:len(tok.Literal)-1]
+	tok.Trivia = nil
+
+	return tok, true
+}
@@ -62,5 +62,6 @@ type Program = StatementCollection
 // BlockStatement is a block of code that inside curly brackets, so it attached to token.LBRACE
 type BlockStatement struct {
 	StatementCollection
-	Token token.Token
+	Token  token.Token
+	Rbrace token.Token // The closing "}" token
 }
@@ -33,3 +33,59 @@ func TestProgram_String(t *testing.T) {
 	assert.Equal(t, "let var = another;return var;", program.String())
 
 }
+
+func TestDump(t *testing.T) {
+	node := &ExpressionStatement{
+		Token: token.Token{Type: token.IDENT, Literal: "a"},
+		Expression: &InfixExpression{
+			Token: token.Token{Type: token.PLUS, Literal: "+"},
+			Left:  &Identifier{Token: token.Token{Type: token.IDENT, Literal: "a"}, Value: "a"},
+			Right: &ArrayLiteral{
+				Token: token.Token{Type: token.LBRACKET, Literal: "["},
+				Elements: []Expression{
+					&IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "1"}, Value: 1},
+					&HashLiteral{
+						Token: token.Token{Type: token.LBRACE, Literal: "{"},
+						Pairs: []HashPair{{
+							Key:   &StringLiteral{Token: token.Token{Type: token.STRING, Literal: "k"}, Value: "k"},
+							Value: &BoolExpression{Token: token.Token{Type: token.TRUE, Literal: "true"}, Value: true},
+						}},
+					},
+				},
+			},
+		},
+	}
+
+	expected := `ExpressionStatement "a"
+└─ Expression: InfixExpression "+"
+   ├─ Left: Identifier "a"
+   └─ Right: ArrayLiteral "["
+      ├─ Elements[0]: IntegerLiteral "1"
+      └─ Elements[1]: HashLiteral "{"
+         ├─ Pairs[0].Key: StringLiteral "k"
+         └─ Pairs[0].Value: BoolExpression "true"
+`
+
+	assert.Equal(t, expected, Dump(node))
+}
+
+func TestDumpBlock(t *testing.T) {
+	node := &FunctionLiteral{
+		Token:      token.Token{Type: token.FN, Literal: "fn"},
+		Parameters: []*Identifier{{Token: token.Token{Type: token.IDENT, Literal: "x"}, Value: "x"}},
+		Body: &BlockStatement{
+			Token: token.Token{Type: token.LBRACE, Literal: "{"},
+			StatementCollection: StatementCollection{Statements: []Statement{
+				&ReturnStatement{Token: token.Token{Type: token.RETURN, Literal: "return"}},
+			}},
+		},
+	}
+
+	expected := `FunctionLiteral "fn"
+├─ Parameters[0]: Identifier "x"
+└─ Body: BlockStatement "{"
+   └─ Statements[0]: ReturnStatement "return"
+`
+
+	assert.Equal(t, expected, Dump(node))
+}
@@ -0,0 +1,132 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Only these types are shown in the tree. Node interface is not used, since any Stringer (e.g. token) implements it
+var (
+	statementType  = reflect.TypeOf((*Statement)(nil)).Elem()
+	expressionType = reflect.TypeOf((*Expression)(nil)).Elem()
+	blockType      = reflect.TypeOf((*BlockStatement)(nil))
+)
+
+// Dump Returns tree view of the node with its children, where each child is labeled with the field it's stored in, e.g.:
+//
+//	ExpressionStatement "a"
+//	└─ Expression: InfixExpression "+"
+//	   ├─ Left: Identifier "a"
+//	   └─ Right: IntegerLiteral "1"
+func Dump(node Node) string {
+	var out strings.Builder
+	out.WriteString(describeNode(reflect.ValueOf(node)) + "\n")
+	dumpChildren(&out, reflect.ValueOf(node), "")
+
+	return out.String()
+}
+
+type labeledNode struct {
+	label string
+	value reflect.Value
+}
+
+func dumpChildren(out *strings.Builder, v reflect.Value, indent string) {
+	children := collectChildren(v)
+
+	for i, child := range children {
+		branch, nextIndent := "├─ ", "│  "
+		if i == len(children)-1 {
+			branch, nextIndent = "└─ ", "   "
+		}
+
+		out.WriteString(fmt.Sprintf("%s%s%s: %s\n", indent, branch, child.label, describeNode(child.value)))
+		dumpChildren(out, child.value, indent+nextIndent)
+	}
+}
+
+// Collects all fields of the node that contain other nodes
+func collectChildren(v reflect.Value) []labeledNode {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var children []labeledNode
+	for i := 0; i < v.NumField(); i++ {
+		field, value := v.Type().Field(i), v.Field(i)
+		if !field.IsExported() || field.Type.Kind() == reflect.Interface && field.Anonymous {
+			continue
+		}
+
+		// Embedded collections are shown as if their fields belong to the node itself
+		if field.Anonymous && value.Kind() == reflect.Struct {
+			children = append(children, collectChildren(value)...)
+			continue
+		}
+
+		switch {
+		case value.Kind() == reflect.Slice:
+			for j := 0; j < value.Len(); j++ {
+				children = append(children, collectElement(fmt.Sprintf("%s[%d]", field.Name, j), value.Index(j))...)
+			}
+		case isNode(value):
+			children = append(children, labeledNode{field.Name, value})
+		}
+	}
+
+	return children
+}
+
+// Slice elements are either nodes or structs that group nodes (e.g. HashPair)
+func collectElement(label string, v reflect.Value) []labeledNode {
+	if isNode(v) {
+		return []labeledNode{{label, v}}
+	}
+
+	var result []labeledNode
+	for _, child := range collectChildren(v) {
+		result = append(result, labeledNode{label + "." + child.label, child.value})
+	}
+
+	return result
+}
+
+func isNode(v reflect.Value) bool {
+	t := v.Type()
+	if !t.Implements(statementType) && !t.Implements(expressionType) && t != blockType {
+		return false
+	}
+
+	return !((v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil())
+}
+
+// Returns name of the node type and literal of its token, if it has one
+func describeNode(v reflect.Value) string {
+	for v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	name := v.Type().String()
+	name = name[strings.LastIndex(name, ".")+1:]
+
+	elem := v
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+
+	if tok := elem.FieldByName("Token"); tok.IsValid() {
+		if lit := tok.FieldByName("Literal"); lit.IsValid() && lit.String() != "" {
+			return fmt.Sprintf("%s %q", name, lit.String())
+		}
+	}
+
+	return name
+}
@@ -0,0 +1,28 @@
+package ast
+
+import (
+	"oilang/internal/token"
+	"strings"
+)
+
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+// HashLiteral is a map from keys to values, e.g. { name: "oi", "key": 1 }.
+// Pairs are stored in the same order as they appear in the source
+type HashLiteral struct {
+	Token token.Token // The "{" token
+	Pairs []HashPair
+}
+
+func (*HashLiteral) expressionNode() {}
+func (hl *HashLiteral) String() string {
+	var pairs []string
+	for _, p := range hl.Pairs {
+		pairs = append(pairs, p.Key.String()+": "+p.Value.String())
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
@@ -16,9 +16,27 @@ func (*IfExpression) expressionNode() {}
 func (ie *IfExpression) String() string {
 	first := fmt.Sprintf("if "+ie.Condition.String()+" { %s }", ie.Consequnce)
 
-	if ie.Alternative != nil {
+	if next := ie.ElseIf(); next != nil {
+		first += " else " + next.String()
+	} else if ie.Alternative != nil {
 		first += fmt.Sprintf(" else { %s }", ie.Alternative)
 	}
 
 	return first
 }
+
+// ElseIf Returns the next if of the "else if" chain, or nil if the alternative is a plain block.
+// Parser stores "else if" as the alternative block that starts with the if token and holds only that if expression
+func (ie *IfExpression) ElseIf() *IfExpression {
+	if ie.Alternative == nil || ie.Alternative.Token.Type != token.IF || len(ie.Alternative.Statements) != 1 {
+		return nil
+	}
+
+	stmt, ok := ie.Alternative.Statements[0].(*ExpressionStatement)
+	if !ok {
+		return nil
+	}
+
+	next, _ := stmt.Expression.(*IfExpression)
+	return next
+}
@@ -0,0 +1,38 @@
+package ast
+
+import (
+	"oilang/internal/token"
+)
+
+// IndexExpression is an access to the element of the collection, e.g. xs[1] or m["key"]
+type IndexExpression struct {
+	Token token.Token // The "[" token
+	Left  Expression
+	Index Expression
+}
+
+func (*IndexExpression) expressionNode() {}
+func (ie *IndexExpression) String() string {
+	return ie.Left.String() + "[" + ie.Index.String() + "]"
+}
+
+// SliceExpression takes part of the collection, e.g. xs[1:3]. Both bounds are optional
+type SliceExpression struct {
+	Token token.Token // The "[" token
+	Left  Expression
+	Low   Expression
+	High  Expression
+}
+
+func (*SliceExpression) expressionNode() {}
+func (se *SliceExpression) String() string {
+	var low, high string
+	if se.Low != nil {
+		low = se.Low.String()
+	}
+	if se.High != nil {
+		high = se.High.String()
+	}
+
+	return se.Left.String() + "[" + low + ":" + high + "]"
+}
@@ -0,0 +1,81 @@
+package ast
+
+import (
+	"oilang/internal/token"
+)
+
+// WhileStatement repeats the body while the condition is truthy, e.g. while n > 0 { ... }
+type WhileStatement struct {
+	Token token.Token // The "while" token
+	// Name that break and continue refer to the loop by, nil if the loop is not labeled
+	Label     *Identifier
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (*WhileStatement) statementNode() {}
+func (ws *WhileStatement) String() string {
+	return labelString(ws.Label) + ws.Token.Literal + " " + ws.Condition.String() + " { " + ws.Body.String() + " }"
+}
+
+// ForStatement runs the body for each element of the collection, e.g. for i, x in xs { ... }
+//
+// Single name is bound to the element of the array or string, or to the key of the hash.
+// With two names, Key is bound to the index of the element or the key of the hash, and Value to the element or the value
+type ForStatement struct {
+	Token    token.Token // The "for" token
+	Label    *Identifier
+	Key      *Identifier // nil if only one name is bound
+	Value    *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (*ForStatement) statementNode() {}
+func (fs *ForStatement) String() string {
+	names := fs.Value.String()
+	if fs.Key != nil {
+		names = fs.Key.String() + ", " + names
+	}
+
+	return labelString(fs.Label) + fs.Token.Literal + " " + names + " in " + fs.Iterable.String() + " { " + fs.Body.String() + " }"
+}
+
+// BreakStatement stops the loop with the label, or the innermost one if there is no label
+type BreakStatement struct {
+	Token token.Token // The "break" token
+	Label *Identifier
+}
+
+func (*BreakStatement) statementNode() {}
+func (bs *BreakStatement) String() string {
+	return bs.Token.Literal + jumpLabelString(bs.Label) + ";"
+}
+
+// ContinueStatement skips the rest of the body and starts the next iteration of the loop with the label,
+// or the innermost one if there is no label
+type ContinueStatement struct {
+	Token token.Token // The "continue" token
+	Label *Identifier
+}
+
+func (*ContinueStatement) statementNode() {}
+func (cs *ContinueStatement) String() string {
+	return cs.Token.Literal + jumpLabelString(cs.Label) + ";"
+}
+
+func labelString(label *Identifier) string {
+	if label == nil {
+		return ""
+	}
+
+	return label.String() + ": "
+}
+
+func jumpLabelString(label *Identifier) string {
+	if label == nil {
+		return ""
+	}
+
+	return " " + label.String()
+}
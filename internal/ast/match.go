@@ -0,0 +1,140 @@
+package ast
+
+import (
+	"oilang/internal/token"
+	"strings"
+)
+
+// MatchExpression compares the value with patterns of the arms and evaluates the first arm that matches, e.g.
+//
+//	match x { 1 | 2 => "small", n if n > 10 => "big", _ => "other" }
+type MatchExpression struct {
+	Token   token.Token // The "match" token
+	Subject Expression
+	Arms    []*MatchArm
+	Rbrace  token.Token // The closing "}" token
+}
+
+func (*MatchExpression) expressionNode() {}
+func (me *MatchExpression) String() string {
+	var arms []string
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.String())
+	}
+
+	return me.Token.Literal + " " + me.Subject.String() + " { " + strings.Join(arms, ", ") + " }"
+}
+
+// MatchArm is selected when any of its patterns matches the value and the guard is truthy
+type MatchArm struct {
+	Patterns []Pattern // Alternatives separated by "|"
+	Guard    Expression
+	// Arm either has a value after "=>" or a block in braces
+	Value Expression
+	Body  *BlockStatement
+}
+
+func (ma *MatchArm) String() string {
+	var patterns []string
+	for _, p := range ma.Patterns {
+		patterns = append(patterns, p.String())
+	}
+
+	out := strings.Join(patterns, " | ")
+	if ma.Guard != nil {
+		out += " if " + ma.Guard.String()
+	}
+
+	if ma.Body != nil {
+		return out + " => { " + ma.Body.String() + " }"
+	}
+
+	return out + " => " + ma.Value.String()
+}
+
+// Pattern describes the shape of the value in the arm of match expression and names bound to its parts
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+// WildcardPattern "_" matches any value without binding it
+type WildcardPattern struct {
+	Token token.Token
+}
+
+func (*WildcardPattern) patternNode()      {}
+func (wp *WildcardPattern) String() string { return wp.Token.Literal }
+
+// BindingPattern matches any value and binds it to the name
+type BindingPattern struct {
+	Name *Identifier
+}
+
+func (*BindingPattern) patternNode()      {}
+func (bp *BindingPattern) String() string { return bp.Name.String() }
+
+// LiteralPattern matches value that is equal to the number, string or boolean literal
+type LiteralPattern struct {
+	Value Expression // Literal or negated number literal
+}
+
+func (*LiteralPattern) patternNode()      {}
+func (lp *LiteralPattern) String() string { return lp.Value.String() }
+
+// ArrayPattern matches array of the same length with elements that match the patterns, e.g. [a, b].
+// With the rest pattern the array could be longer and the array of remaining elements is bound to it: [head, ...tail]
+type ArrayPattern struct {
+	Token    token.Token // The "[" token
+	Elements []Pattern
+	Rest     Pattern // Either *BindingPattern or *WildcardPattern, nil if there is no rest
+}
+
+func (*ArrayPattern) patternNode() {}
+func (ap *ArrayPattern) String() string {
+	var elements []string
+	for _, e := range ap.Elements {
+		elements = append(elements, e.String())
+	}
+	if ap.Rest != nil {
+		elements = append(elements, "..."+ap.Rest.String())
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// HashPatternPair matches value of the hash stored under the key
+type HashPatternPair struct {
+	Key   *StringLiteral
+	Value Pattern
+}
+
+// HashPattern matches hash that has all the keys with values matching their patterns, other keys are ignored, e.g. { name: n }
+type HashPattern struct {
+	Token token.Token // The "{" token
+	Pairs []HashPatternPair
+}
+
+func (*HashPattern) patternNode() {}
+func (hp *HashPattern) String() string {
+	var pairs []string
+	for _, p := range hp.Pairs {
+		pairs = append(pairs, p.Key.String()+": "+p.Value.String())
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// PatternNames Returns names bound by the pattern in order of their appearance
+func PatternNames(pattern Pattern) []*Identifier {
+	var names []*Identifier
+	Walk(pattern, func(n Node) bool {
+		if binding, ok := n.(*BindingPattern); ok {
+			names = append(names, binding.Name)
+		}
+
+		return true
+	})
+
+	return names
+}
@@ -0,0 +1,41 @@
+package ast
+
+import (
+	"oilang/internal/token"
+)
+
+// ImportStatement evaluates the module and binds its exports to the alias, e.g. import "./lib/text.oi" as text
+type ImportStatement struct {
+	Token token.Token // The "import" token
+	Path  *StringLiteral
+	Alias *Identifier
+}
+
+func (*ImportStatement) statementNode() {}
+func (is *ImportStatement) String() string {
+	return is.Token.Literal + " " + is.Path.String() + " as " + is.Alias.String() + ";"
+}
+
+// ExportStatement makes the name declared by the statement available to the modules that import it.
+// Statement is either *LetStatement (let or const) or *ExpressionStatement with the named function
+type ExportStatement struct {
+	Token     token.Token // The "export" token
+	Statement Statement
+}
+
+func (*ExportStatement) statementNode() {}
+func (es *ExportStatement) String() string {
+	return es.Token.Literal + " " + es.Statement.String()
+}
+
+// MemberExpression is an access to the named member of the value, e.g. exported name of the module: text.trim
+type MemberExpression struct {
+	Token    token.Token // The "." token
+	Object   Expression
+	Property *Identifier
+}
+
+func (*MemberExpression) expressionNode() {}
+func (me *MemberExpression) String() string {
+	return me.Object.String() + "." + me.Property.String()
+}
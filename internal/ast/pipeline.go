@@ -0,0 +1,26 @@
+package ast
+
+import (
+	"oilang/internal/token"
+	"strings"
+)
+
+// PipelineExpression is a chain of stages connected with "->" operator.
+// Value of the source is passed to the first stage, result of each stage is passed to the next one:
+//
+//	source -> stage(@) -> @fn (x) { x * 2 }
+type PipelineExpression struct {
+	Token  token.Token // The first "->" token
+	Source Expression
+	Stages []Expression
+}
+
+func (*PipelineExpression) expressionNode() {}
+func (pe *PipelineExpression) String() string {
+	parts := []string{pe.Source.String()}
+	for _, s := range pe.Stages {
+		parts = append(parts, s.String())
+	}
+
+	return "(" + strings.Join(parts, " -> ") + ")"
+}
@@ -0,0 +1,39 @@
+package ast
+
+import (
+	"oilang/internal/token"
+	"strconv"
+	"strings"
+)
+
+type StringLiteral struct {
+	Token token.Token
+	Value string
+}
+
+func (*StringLiteral) expressionNode()   {}
+func (sl *StringLiteral) String() string { return strconv.Quote(sl.Value) }
+
+// TemplateLiteral is a string with interpolated expressions, e.g. "Hello, ${name}!".
+// Parts are either *StringLiteral for the text or any other expression for the interpolation
+type TemplateLiteral struct {
+	Token token.Token
+	Parts []Expression
+}
+
+func (*TemplateLiteral) expressionNode() {}
+func (tl *TemplateLiteral) String() string {
+	out := "\""
+
+	for _, p := range tl.Parts {
+		if s, ok := p.(*StringLiteral); ok {
+			quoted := strconv.Quote(s.Value)
+			out += strings.ReplaceAll(quoted[1:len(quoted)-1], "${", "\\${")
+			continue
+		}
+
+		out += "${" + p.String() + "}"
+	}
+
+	return out + "\""
+}
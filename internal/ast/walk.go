@@ -0,0 +1,145 @@
+package ast
+
+// Walk traverses the node and all of its children in depth-first order.
+// If fn returns false for the node, its children are not visited
+func Walk(node Node, fn func(Node) bool) {
+	if node == nil || !fn(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *StatementCollection:
+		walkStatements(n.Statements, fn)
+	case *BlockStatement:
+		walkStatements(n.Statements, fn)
+	case *ExpressionStatement:
+		walkExpression(n.Expression, fn)
+	case *LetStatement:
+		Walk(n.Name, fn)
+		walkExpression(n.Value, fn)
+	case *ReturnStatement:
+		walkExpression(n.ReturnValue, fn)
+	case *ImportStatement:
+		Walk(n.Path, fn)
+		Walk(n.Alias, fn)
+	case *ExportStatement:
+		Walk(n.Statement, fn)
+	case *WhileStatement:
+		walkIdentifier(n.Label, fn)
+		walkExpression(n.Condition, fn)
+		Walk(n.Body, fn)
+	case *ForStatement:
+		walkIdentifier(n.Label, fn)
+		walkIdentifier(n.Key, fn)
+		Walk(n.Value, fn)
+		walkExpression(n.Iterable, fn)
+		Walk(n.Body, fn)
+	case *BreakStatement:
+		walkIdentifier(n.Label, fn)
+	case *ContinueStatement:
+		walkIdentifier(n.Label, fn)
+	case *PrefixExpression:
+		walkExpression(n.Operand, fn)
+	case *InfixExpression:
+		walkExpression(n.Left, fn)
+		walkExpression(n.Right, fn)
+	case *AssignExpression:
+		walkExpression(n.Target, fn)
+		walkExpression(n.Value, fn)
+	case *IfExpression:
+		walkExpression(n.Condition, fn)
+		Walk(n.Consequnce, fn)
+		if n.Alternative != nil {
+			Walk(n.Alternative, fn)
+		}
+	case *MatchExpression:
+		walkExpression(n.Subject, fn)
+		for _, arm := range n.Arms {
+			for _, p := range arm.Patterns {
+				Walk(p, fn)
+			}
+			walkExpression(arm.Guard, fn)
+			walkExpression(arm.Value, fn)
+			if arm.Body != nil {
+				Walk(arm.Body, fn)
+			}
+		}
+	case *BindingPattern:
+		Walk(n.Name, fn)
+	case *LiteralPattern:
+		walkExpression(n.Value, fn)
+	case *ArrayPattern:
+		for _, e := range n.Elements {
+			Walk(e, fn)
+		}
+		if n.Rest != nil {
+			Walk(n.Rest, fn)
+		}
+	case *HashPattern:
+		for _, p := range n.Pairs {
+			Walk(p.Key, fn)
+			Walk(p.Value, fn)
+		}
+	case *FunctionLiteral:
+		if n.Name != nil {
+			Walk(n.Name, fn)
+		}
+		for _, p := range n.Parameters {
+			Walk(p, fn)
+		}
+		Walk(n.Body, fn)
+	case *CallExpression:
+		walkExpression(n.CalledExpression, fn)
+		for _, a := range n.Arguments {
+			walkExpression(a, fn)
+		}
+	case *ArrayLiteral:
+		for _, e := range n.Elements {
+			walkExpression(e, fn)
+		}
+	case *HashLiteral:
+		for _, p := range n.Pairs {
+			walkExpression(p.Key, fn)
+			walkExpression(p.Value, fn)
+		}
+	case *IndexExpression:
+		walkExpression(n.Left, fn)
+		walkExpression(n.Index, fn)
+	case *MemberExpression:
+		walkExpression(n.Object, fn)
+		Walk(n.Property, fn)
+	case *SliceExpression:
+		walkExpression(n.Left, fn)
+		walkExpression(n.Low, fn)
+		walkExpression(n.High, fn)
+	case *TemplateLiteral:
+		for _, p := range n.Parts {
+			walkExpression(p, fn)
+		}
+	case *PipelineExpression:
+		walkExpression(n.Source, fn)
+		for _, s := range n.Stages {
+			walkExpression(s, fn)
+		}
+	}
+}
+
+func walkStatements(stmts []Statement, fn func(Node) bool) {
+	for _, s := range stmts {
+		Walk(s, fn)
+	}
+}
+
+// Expressions are stored as interfaces, so nil values should be skipped explicitly
+func walkExpression(exp Expression, fn func(Node) bool) {
+	if exp != nil {
+		Walk(exp, fn)
+	}
+}
+
+// Optional identifiers (e.g. labels of the loops) are nil pointers when absent, which Walk does not detect
+func walkIdentifier(ident *Identifier, fn func(Node) bool) {
+	if ident != nil {
+		Walk(ident, fn)
+	}
+}
@@ -0,0 +1,82 @@
+package compiler
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/evaluator"
+)
+
+// Parts of the target are evaluated before the value, the same way the evaluator does it.
+// Compound assignment reads the current value of the target and applies the operator before storing the result
+func (c *Compiler) compileAssign(e *ast.AssignExpression) *CompileError {
+	switch target := e.Target.(type) {
+	case *ast.Identifier:
+		symbol, ok := c.symbols.Resolve(target.Value)
+		if !ok {
+			symbol = c.symbols.Global().Define(target.Value)
+		}
+
+		if err := c.compileAssignedValue(e, func() { c.emitGet(target.Token, symbol) }); err != nil {
+			return err
+		}
+		c.emitAssign(target, symbol)
+	case *ast.IndexExpression:
+		if err := c.compileExpressions([]ast.Expression{target.Left, target.Index}); err != nil {
+			return err
+		}
+
+		err := c.compileAssignedValue(e, func() {
+			c.emit(target.Token, OpDupTwo)
+			c.emit(target.Token, OpIndex)
+		})
+		if err != nil {
+			return err
+		}
+		c.emit(target.Token, OpSetIndex)
+	case *ast.MemberExpression:
+		if err := c.compileExpression(target.Object); err != nil {
+			return err
+		}
+
+		name := c.addConstant(&evaluator.String{Value: target.Property.Value})
+		err := c.compileAssignedValue(e, func() {
+			c.emit(target.Token, OpDup)
+			c.emit(target.Token, OpMember, name)
+		})
+		if err != nil {
+			return err
+		}
+		c.emit(target.Token, OpSetMember, name)
+	default:
+		return &CompileError{Message: "invalid assignment target", Token: e.Token}
+	}
+
+	return nil
+}
+
+// Leaves the value that should be stored on the stack. Current value of the target is pushed by the supplied function
+func (c *Compiler) compileAssignedValue(e *ast.AssignExpression, current func()) *CompileError {
+	op, compound := e.Compound()
+	if !compound {
+		return c.compileExpression(e.Value)
+	}
+
+	current()
+	if err := c.compileExpression(e.Value); err != nil {
+		return err
+	}
+	c.emit(op, infixOperators[op.Type])
+
+	return nil
+}
+
+// Identifier is used by the VM to report assignment to the name that is not defined
+func (c *Compiler) emitAssign(ident *ast.Identifier, symbol Symbol) {
+	switch symbol.Scope {
+	case GlobalScope:
+		c.emit(ident.Token, OpAssignGlobal, symbol.Index)
+	case LocalScope:
+		c.emit(ident.Token, OpAssignLocal, symbol.Index)
+	case FreeScope:
+		c.emit(ident.Token, OpAssignFree, symbol.Depth, symbol.Index)
+	}
+}
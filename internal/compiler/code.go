@@ -0,0 +1,276 @@
+package compiler
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Instructions is a sequence of encoded opcodes with their operands
+type Instructions []byte
+
+// Opcode is the first byte of the instruction that tells the VM what to do
+type Opcode byte
+
+const (
+	// Pushes constant with the index from the constants pool
+	OpConstant Opcode = iota
+	OpPop
+	OpDup
+	// Duplicates two values on the top of the stack, keeping their order
+	OpDupTwo
+	OpTrue
+	OpFalse
+	OpNull
+
+	// Binary operators pop both operands and push the result
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpPow
+	OpEqual
+	OpNotEqual
+	OpLess
+	OpGreater
+	OpLessEqual
+	OpGreaterEqual
+
+	// Unary operators replace the operand with the result
+	OpMinus
+	OpNot
+	// Replaces the value with boolean telling if it's truthy
+	OpToBool
+
+	// Jumps to the absolute position in the instructions of the function
+	OpJump
+	// Pops the value and jumps if it's not truthy
+	OpJumpNotTruthy
+
+	// Replaces the collection with the iterator over its elements
+	OpIterator
+	// Pops the iterator and pushes its next element, or jumps to the operand position if there are no more elements.
+	// Second operand is the amount of loop names: with two of them the key is pushed before the element
+	OpIterNext
+
+	OpGetGlobal
+	OpSetGlobal
+	OpGetLocal
+	OpSetLocal
+	// Reads local of the enclosing function, operands are the amount of functions to go up and index of the local there
+	OpGetFree
+	// Assignments change the variable that must be already defined. Value is kept on the stack as the result of the assignment
+	OpAssignGlobal
+	OpAssignLocal
+	OpAssignFree
+
+	// Collections are created from the amount of values on the stack. Hash takes amount of pairs
+	OpArray
+	OpHash
+	OpIndex
+	// Pops the value, index and collection, stores the value in the collection and pushes it back
+	OpSetIndex
+	// Reads member of the value, operand is the constant with its name
+	OpMember
+	// Pops the value and the object, stores the value in the member with the name from the constant and pushes it back
+	OpSetMember
+	// Operand tells which bounds are on the stack: 1 for the low one, 2 for the high one
+	OpSlice
+	// Converts values on the stack into strings and concatenates them
+	OpTemplate
+
+	// Pops the value and matches it against the pattern from the constants pool. If it matches,
+	// values of the names bound by the pattern are pushed followed by true, otherwise only false is pushed
+	OpMatch
+
+	// Starts the scope of the block with the amount of locals in the operand, e.g. iteration of the loop.
+	// Closures created in the block keep its scope, so each of them sees its own values of the block's names
+	OpEnterScope
+	// Leaves the amount of the innermost block scopes in the operand
+	OpLeaveScope
+
+	// Creates closure from the function in the constants pool
+	OpClosure
+	// Pushes closure of the function that is being executed
+	OpCurrentClosure
+	// Calls the function below the arguments on the stack
+	OpCall
+	OpReturnValue
+	// Returns from the function without value
+	OpReturn
+
+	// Passes the value to the function stage of the pipeline: pops the value and the function and pushes result
+	OpPipeStage
+	// Same as OpPipeStage, but value is passed to the function before the additional arguments.
+	// Functions that get all of their parameters from the arguments are just called with them and their result is passed
+	// as OpPipeStage does, unless it's not a function. With PIPE_CALL_STAGE_ONLY flag the value is passed only to stage functions, others are just called
+	OpPipeCall
+	// Collects the stream the pipeline ends with into an array, other values are left as they are
+	OpCollect
+)
+
+// Flag of OpPipeCall that is set when the arguments of the call refer to "@"
+const PIPE_CALL_STAGE_ONLY = 1
+
+// Definition describes the opcode for debugging and tells sizes of its operands in bytes
+type Definition struct {
+	Name          string
+	OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}},
+	OpPop:      {"OpPop", []int{}},
+	OpDup:      {"OpDup", []int{}},
+	OpDupTwo:   {"OpDupTwo", []int{}},
+	OpTrue:     {"OpTrue", []int{}},
+	OpFalse:    {"OpFalse", []int{}},
+	OpNull:     {"OpNull", []int{}},
+
+	OpAdd:          {"OpAdd", []int{}},
+	OpSub:          {"OpSub", []int{}},
+	OpMul:          {"OpMul", []int{}},
+	OpDiv:          {"OpDiv", []int{}},
+	OpPow:          {"OpPow", []int{}},
+	OpEqual:        {"OpEqual", []int{}},
+	OpNotEqual:     {"OpNotEqual", []int{}},
+	OpLess:         {"OpLess", []int{}},
+	OpGreater:      {"OpGreater", []int{}},
+	OpLessEqual:    {"OpLessEqual", []int{}},
+	OpGreaterEqual: {"OpGreaterEqual", []int{}},
+
+	OpMinus:  {"OpMinus", []int{}},
+	OpNot:    {"OpNot", []int{}},
+	OpToBool: {"OpToBool", []int{}},
+
+	OpJump:          {"OpJump", []int{2}},
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}},
+
+	OpIterator: {"OpIterator", []int{}},
+	OpIterNext: {"OpIterNext", []int{2, 1}},
+
+	OpGetGlobal: {"OpGetGlobal", []int{2}},
+	OpSetGlobal: {"OpSetGlobal", []int{2}},
+	OpGetLocal:  {"OpGetLocal", []int{2}},
+	OpSetLocal:  {"OpSetLocal", []int{2}},
+	OpGetFree:   {"OpGetFree", []int{1, 2}},
+
+	OpAssignGlobal: {"OpAssignGlobal", []int{2}},
+	OpAssignLocal:  {"OpAssignLocal", []int{2}},
+	OpAssignFree:   {"OpAssignFree", []int{1, 2}},
+
+	OpArray:     {"OpArray", []int{2}},
+	OpHash:      {"OpHash", []int{2}},
+	OpIndex:     {"OpIndex", []int{}},
+	OpSetIndex:  {"OpSetIndex", []int{}},
+	OpMember:    {"OpMember", []int{2}},
+	OpSetMember: {"OpSetMember", []int{2}},
+	OpSlice:     {"OpSlice", []int{1}},
+	OpTemplate:  {"OpTemplate", []int{2}},
+
+	OpMatch: {"OpMatch", []int{2}},
+
+	OpEnterScope: {"OpEnterScope", []int{2}},
+	OpLeaveScope: {"OpLeaveScope", []int{1}},
+
+	OpClosure:        {"OpClosure", []int{2}},
+	OpCurrentClosure: {"OpCurrentClosure", []int{}},
+	OpCall:           {"OpCall", []int{1}},
+	OpReturnValue:    {"OpReturnValue", []int{}},
+	OpReturn:         {"OpReturn", []int{}},
+
+	OpPipeStage: {"OpPipeStage", []int{}},
+	OpPipeCall:  {"OpPipeCall", []int{1, 1}},
+	OpCollect:   {"OpCollect", []int{}},
+}
+
+// Lookup Returns definition of the opcode
+func Lookup(op Opcode) (*Definition, error) {
+	def, ok := definitions[op]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+
+	return def, nil
+}
+
+// Make Encodes the instruction. Operands are written in big endian order
+func Make(op Opcode, operands ...int) Instructions {
+	def, ok := definitions[op]
+	if !ok {
+		return Instructions{}
+	}
+
+	length := 1
+	for _, w := range def.OperandWidths {
+		length += w
+	}
+
+	instruction := make(Instructions, length)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for i, o := range operands {
+		switch def.OperandWidths[i] {
+		case 1:
+			instruction[offset] = byte(o)
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		}
+		offset += def.OperandWidths[i]
+	}
+
+	return instruction
+}
+
+// ReadOperands Decodes operands of the instruction. Returns them and amount of bytes they take
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+
+	for i, w := range def.OperandWidths {
+		switch w {
+		case 1:
+			operands[i] = int(ins[offset])
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		}
+		offset += w
+	}
+
+	return operands, offset
+}
+
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+// String Disassembles instructions, one per line, prefixed with their position
+func (ins Instructions) String() string {
+	var out strings.Builder
+
+	for i := 0; i < len(ins); {
+		def, err := Lookup(Opcode(ins[i]))
+		if err != nil {
+			_, _ = fmt.Fprintf(&out, "ERROR: %s\n", err)
+			i++
+			continue
+		}
+
+		operands, read := ReadOperands(def, ins[i+1:])
+		_, _ = fmt.Fprintf(&out, "%04d %s\n", i, formatInstruction(def, operands))
+
+		i += 1 + read
+	}
+
+	return out.String()
+}
+
+func formatInstruction(def *Definition, operands []int) string {
+	parts := []string{def.Name}
+	for _, o := range operands {
+		parts = append(parts, fmt.Sprint(o))
+	}
+
+	return strings.Join(parts, " ")
+}
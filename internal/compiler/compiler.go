@@ -0,0 +1,577 @@
+package compiler
+
+import (
+	"fmt"
+	"oilang/internal/ast"
+	"oilang/internal/diagnostics"
+	"oilang/internal/evaluator"
+	"oilang/internal/token"
+)
+
+// CompileError is an error found while lowering the AST, with the token of the node that could not be compiled
+type CompileError struct {
+	Message string
+	Token   token.Token
+}
+
+func (e *CompileError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Token.Line+1, e.Token.Col+1, e.Message)
+}
+
+// Diagnostic Converts the error to diagnostic that could be shown to the user
+func (e *CompileError) Diagnostic() diagnostics.Diagnostic {
+	return diagnostics.New(diagnostics.Error, e.Token, e.Message)
+}
+
+// Bytecode is a compiled program that could be executed by the VM
+type Bytecode struct {
+	Main      *CompiledFunction
+	Constants []evaluator.Object
+	// Names of the global slots by their index
+	Globals []string
+}
+
+// Compiler lowers the AST to bytecode.
+//
+// Semantics follow the tree-walking evaluator: blocks of if share the scope of the function,
+// names that are not defined yet are looked up in globals at runtime and closures see later changes of the variables they use
+type Compiler struct {
+	constants []evaluator.Object
+	symbols   *SymbolTable
+	// Functions that are being compiled, the last one is the current
+	functions []*CompiledFunction
+	// Loops of the current function around the code that is being compiled, the last one is the innermost
+	loops []*loop
+	// Amount of block scopes of the current function around the code that is being compiled
+	scopes int
+}
+
+// New Creates compiler for the program
+func New() *Compiler {
+	return &Compiler{
+		symbols:   NewSymbolTable(),
+		functions: []*CompiledFunction{{Name: "main", Tokens: make(map[int]token.Token)}},
+	}
+}
+
+// Define Declares global before compilation, so its value could be set before running the program (e.g. command line arguments).
+// Returns index of the global slot
+func (c *Compiler) Define(name string) int {
+	return c.symbols.Define(name).Index
+}
+
+// Compile Lowers the program to bytecode
+func (c *Compiler) Compile(program *ast.Program) (*Bytecode, *CompileError) {
+	c.hoist(program.Statements)
+
+	hasValue, err := c.compileStatements(program.Statements)
+	if err != nil {
+		return nil, err
+	}
+
+	// Program that ends with a statement without value (e.g. let) does not produce result
+	if hasValue {
+		c.emit(token.Token{}, OpReturnValue)
+	} else {
+		c.emit(token.Token{}, OpReturn)
+	}
+
+	main := c.currentFunction()
+	main.NumLocals = c.symbols.NumDefinitions()
+
+	return &Bytecode{Main: main, Constants: c.constants, Globals: c.symbols.Names()}, nil
+}
+
+// Named functions are visible in the whole scope they are declared in, so they could call each other regardless of the order
+func (c *Compiler) hoist(statements []ast.Statement) {
+	for _, s := range statements {
+		if name, ok := declaredFunction(s); ok {
+			c.symbols.Define(name)
+		}
+	}
+}
+
+// Returns name of the function if the statement is a function declaration, e.g. "fn add(a, b) { a + b }"
+func declaredFunction(s ast.Statement) (string, bool) {
+	if export, ok := s.(*ast.ExportStatement); ok {
+		s = export.Statement
+	}
+
+	stmt, ok := s.(*ast.ExpressionStatement)
+	if !ok {
+		return "", false
+	}
+
+	fn, ok := stmt.Expression.(*ast.FunctionLiteral)
+	if !ok || fn.Name == nil {
+		return "", false
+	}
+
+	return fn.Name.Value, true
+}
+
+// Compiles statements and tells if the last one leaves its value on the stack. Values of other statements are dropped
+func (c *Compiler) compileStatements(statements []ast.Statement) (bool, *CompileError) {
+	hasValue := false
+
+	for i, s := range statements {
+		hasValue = false
+
+		stmt, ok := s.(*ast.ExpressionStatement)
+		if _, isDeclaration := declaredFunction(s); !ok || isDeclaration {
+			if err := c.compileStatement(s); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		if err := c.compileExpression(stmt.Expression); err != nil {
+			return false, err
+		}
+
+		if i == len(statements)-1 {
+			hasValue = true
+		} else {
+			c.emit(stmt.Token, OpPop)
+		}
+	}
+
+	return hasValue, nil
+}
+
+func (c *Compiler) compileStatement(s ast.Statement) *CompileError {
+	switch s := s.(type) {
+	case *ast.LetStatement:
+		// Value is compiled before the name is defined, so it refers to the outer variable with the same name
+		if err := c.compileExpression(s.Value); err != nil {
+			return err
+		}
+
+		c.emitSet(s.Token, c.symbols.Define(s.Name.Value))
+	case *ast.ReturnStatement:
+		if s.ReturnValue == nil {
+			c.emit(s.Token, OpNull)
+		} else if err := c.compileExpression(s.ReturnValue); err != nil {
+			return err
+		}
+
+		c.emit(s.Token, OpReturnValue)
+	case *ast.ExpressionStatement:
+		fn := s.Expression.(*ast.FunctionLiteral)
+		symbol := c.symbols.Define(fn.Name.Value)
+
+		if err := c.compileFunction(fn, false); err != nil {
+			return err
+		}
+
+		c.emitSet(s.Token, symbol)
+	case *ast.ExportStatement:
+		// Program that is run is not imported by anything, so its exports are just declarations
+		return c.compileStatement(s.Statement)
+	case *ast.ImportStatement:
+		return &CompileError{Message: "import is not supported by the vm engine", Token: s.Token}
+	case *ast.WhileStatement:
+		return c.compileWhile(s)
+	case *ast.ForStatement:
+		return c.compileFor(s)
+	case *ast.BreakStatement:
+		return c.compileBreak(s)
+	case *ast.ContinueStatement:
+		return c.compileContinue(s)
+	default:
+		return &CompileError{Message: fmt.Sprintf("unable to compile %T", s)}
+	}
+
+	return nil
+}
+
+// Compiles block as an expression, so it leaves its value or null on the stack
+func (c *Compiler) compileBlock(block *ast.BlockStatement) *CompileError {
+	hasValue, err := c.compileStatements(block.Statements)
+	if err != nil {
+		return err
+	}
+
+	if !hasValue {
+		c.emit(block.Token, OpNull)
+	}
+
+	return nil
+}
+
+// Compiles block that has its own scope, so its let statements do not change names of the enclosing one
+func (c *Compiler) compileScopedBlock(block *ast.BlockStatement) *CompileError {
+	scope := c.enterScope(block.Token)
+	if err := c.compileBlock(block); err != nil {
+		return err
+	}
+	c.leaveScope(block.Rbrace, scope)
+
+	return nil
+}
+
+func (c *Compiler) compileExpression(e ast.Expression) *CompileError {
+	switch e := e.(type) {
+	case *ast.IntegerLiteral:
+		c.emit(e.Token, OpConstant, c.addConstant(&evaluator.Integer{Value: e.Value}))
+	case *ast.FloatLiteral:
+		c.emit(e.Token, OpConstant, c.addConstant(&evaluator.Float{Value: e.Value}))
+	case *ast.StringLiteral:
+		c.emit(e.Token, OpConstant, c.addConstant(&evaluator.String{Value: e.Value}))
+	case *ast.BoolExpression:
+		if e.Value {
+			c.emit(e.Token, OpTrue)
+		} else {
+			c.emit(e.Token, OpFalse)
+		}
+	case *ast.TemplateLiteral:
+		for _, part := range e.Parts {
+			if err := c.compileExpression(part); err != nil {
+				return err
+			}
+		}
+		c.emit(e.Token, OpTemplate, len(e.Parts))
+	case *ast.Identifier:
+		c.compileIdentifier(e)
+	case *ast.PrefixExpression:
+		return c.compilePrefix(e)
+	case *ast.InfixExpression:
+		return c.compileInfix(e)
+	case *ast.AssignExpression:
+		return c.compileAssign(e)
+	case *ast.IfExpression:
+		return c.compileIf(e)
+	case *ast.MatchExpression:
+		return c.compileMatch(e)
+	case *ast.FunctionLiteral:
+		return c.compileFunction(e, e.Name != nil)
+	case *ast.CallExpression:
+		if err := c.compileExpressions(append([]ast.Expression{e.CalledExpression}, e.Arguments...)); err != nil {
+			return err
+		}
+		c.emit(e.Token, OpCall, len(e.Arguments))
+	case *ast.ArrayLiteral:
+		if err := c.compileExpressions(e.Elements); err != nil {
+			return err
+		}
+		c.emit(e.Token, OpArray, len(e.Elements))
+	case *ast.HashLiteral:
+		for _, pair := range e.Pairs {
+			if err := c.compileExpressions([]ast.Expression{pair.Key, pair.Value}); err != nil {
+				return err
+			}
+		}
+		c.emit(e.Token, OpHash, len(e.Pairs))
+	case *ast.IndexExpression:
+		if err := c.compileExpressions([]ast.Expression{e.Left, e.Index}); err != nil {
+			return err
+		}
+		c.emit(e.Token, OpIndex)
+	case *ast.MemberExpression:
+		if err := c.compileExpression(e.Object); err != nil {
+			return err
+		}
+		c.emit(e.Token, OpMember, c.addConstant(&evaluator.String{Value: e.Property.Value}))
+	case *ast.SliceExpression:
+		return c.compileSlice(e)
+	case *ast.PipelineExpression:
+		return c.compilePipeline(e)
+	default:
+		return &CompileError{Message: fmt.Sprintf("unable to compile %T", e)}
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileExpressions(exps []ast.Expression) *CompileError {
+	for _, e := range exps {
+		if err := c.compileExpression(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Names that are not defined yet are considered globals, so they could be defined later (e.g. after the function that uses them)
+func (c *Compiler) compileIdentifier(ident *ast.Identifier) {
+	symbol, ok := c.symbols.Resolve(ident.Value)
+	if !ok {
+		symbol = c.symbols.Global().Define(ident.Value)
+	}
+
+	c.emitGet(ident.Token, symbol)
+}
+
+func (c *Compiler) emitGet(tok token.Token, symbol Symbol) {
+	switch symbol.Scope {
+	case GlobalScope:
+		c.emit(tok, OpGetGlobal, symbol.Index)
+	case LocalScope:
+		c.emit(tok, OpGetLocal, symbol.Index)
+	case FreeScope:
+		c.emit(tok, OpGetFree, symbol.Depth, symbol.Index)
+	}
+}
+
+func (c *Compiler) emitSet(tok token.Token, symbol Symbol) {
+	if symbol.Scope == GlobalScope {
+		c.emit(tok, OpSetGlobal, symbol.Index)
+	} else {
+		c.emit(tok, OpSetLocal, symbol.Index)
+	}
+}
+
+var prefixOperators = map[token.TokenType]Opcode{
+	token.MINUS: OpMinus,
+	token.NOT:   OpNot,
+}
+
+func (c *Compiler) compilePrefix(e *ast.PrefixExpression) *CompileError {
+	op, ok := prefixOperators[e.Token.Type]
+	if !ok {
+		return &CompileError{Message: "unknown operator: " + e.Operator(), Token: e.Token}
+	}
+
+	if err := c.compileExpression(e.Operand); err != nil {
+		return err
+	}
+
+	c.emit(e.Token, op)
+	return nil
+}
+
+var infixOperators = map[token.TokenType]Opcode{
+	token.PLUS:     OpAdd,
+	token.MINUS:    OpSub,
+	token.MULTIPLY: OpMul,
+	token.DIVIDE:   OpDiv,
+	token.POWER:    OpPow,
+	token.EQ:       OpEqual,
+	token.NEQ:      OpNotEqual,
+	token.LT:       OpLess,
+	token.GT:       OpGreater,
+	token.LTE:      OpLessEqual,
+	token.GTE:      OpGreaterEqual,
+}
+
+func (c *Compiler) compileInfix(e *ast.InfixExpression) *CompileError {
+	switch e.Token.Type {
+	case token.AND, token.OR:
+		return c.compileLogical(e)
+	}
+
+	op, ok := infixOperators[e.Token.Type]
+	if !ok {
+		return &CompileError{Message: "unknown operator: " + e.Operator(), Token: e.Token}
+	}
+
+	if err := c.compileExpressions([]ast.Expression{e.Left, e.Right}); err != nil {
+		return err
+	}
+
+	c.emit(e.Token, op)
+	return nil
+}
+
+// Logical operators are short-circuited, so right operand is evaluated only when it decides the result
+func (c *Compiler) compileLogical(e *ast.InfixExpression) *CompileError {
+	if err := c.compileExpression(e.Left); err != nil {
+		return err
+	}
+
+	jumpToRight := c.emit(e.Token, OpJumpNotTruthy, 0)
+	var jumpToEnd int
+
+	if e.Token.Type == token.AND {
+		if err := c.compileExpression(e.Right); err != nil {
+			return err
+		}
+		c.emit(e.Token, OpToBool)
+		jumpToEnd = c.emit(e.Token, OpJump, 0)
+
+		c.changeOperand(jumpToRight, c.position())
+		c.emit(e.Token, OpFalse)
+	} else {
+		c.emit(e.Token, OpTrue)
+		jumpToEnd = c.emit(e.Token, OpJump, 0)
+
+		c.changeOperand(jumpToRight, c.position())
+		if err := c.compileExpression(e.Right); err != nil {
+			return err
+		}
+		c.emit(e.Token, OpToBool)
+	}
+
+	c.changeOperand(jumpToEnd, c.position())
+	return nil
+}
+
+func (c *Compiler) compileIf(e *ast.IfExpression) *CompileError {
+	if err := c.compileExpression(e.Condition); err != nil {
+		return err
+	}
+
+	jumpToAlternative := c.emit(e.Token, OpJumpNotTruthy, 0)
+	if err := c.compileScopedBlock(e.Consequnce); err != nil {
+		return err
+	}
+	jumpToEnd := c.emit(e.Token, OpJump, 0)
+
+	c.changeOperand(jumpToAlternative, c.position())
+	if e.Alternative != nil {
+		if err := c.compileScopedBlock(e.Alternative); err != nil {
+			return err
+		}
+	} else {
+		c.emit(e.Token, OpNull)
+	}
+
+	c.changeOperand(jumpToEnd, c.position())
+	return nil
+}
+
+// Function that is self bound has its name defined as a local that holds the closure itself,
+// so the named function literal could call itself. Declarations are bound in the enclosing scope instead
+func (c *Compiler) compileFunction(e *ast.FunctionLiteral, selfBound bool) *CompileError {
+	fn := &CompiledFunction{Tokens: make(map[int]token.Token), IsPipelineStage: e.IsPipelineStage}
+	if e.Name != nil {
+		fn.Name = e.Name.Value
+	}
+
+	c.functions = append(c.functions, fn)
+	c.symbols = NewEnclosedSymbolTable(c.symbols)
+	// Break and continue could not leave the function
+	outerLoops, outerScopes := c.loops, c.scopes
+	c.loops, c.scopes = nil, 0
+
+	for _, param := range e.Parameters {
+		fn.Parameters = append(fn.Parameters, param.Value)
+		c.symbols.Define(param.Value)
+	}
+	// Value passed to the stage function is stored right after its arguments
+	if e.IsPipelineStage {
+		c.symbols.Define(evaluator.PIPELINE_CONTEXT)
+	}
+	// Parameters with the same name hide the function
+	if _, isParameter := c.symbols.store[fn.Name]; selfBound && !isParameter {
+		c.emit(e.Name.Token, OpCurrentClosure)
+		c.emitSet(e.Name.Token, c.symbols.Define(fn.Name))
+	}
+
+	c.hoist(e.Body.Statements)
+	hasValue, err := c.compileStatements(e.Body.Statements)
+	if err != nil {
+		return err
+	}
+
+	if hasValue {
+		c.emit(e.Body.Rbrace, OpReturnValue)
+	} else {
+		c.emit(e.Body.Rbrace, OpReturn)
+	}
+
+	fn.NumLocals = c.symbols.NumDefinitions()
+	c.symbols = c.symbols.Outer
+	c.functions = c.functions[:len(c.functions)-1]
+	c.loops, c.scopes = outerLoops, outerScopes
+
+	c.emit(e.Token, OpClosure, c.addConstant(fn))
+	return nil
+}
+
+func (c *Compiler) compileSlice(e *ast.SliceExpression) *CompileError {
+	if err := c.compileExpression(e.Left); err != nil {
+		return err
+	}
+
+	bounds := 0
+	for i, bound := range []ast.Expression{e.Low, e.High} {
+		if bound == nil {
+			continue
+		}
+
+		if err := c.compileExpression(bound); err != nil {
+			return err
+		}
+		bounds |= 1 << i
+	}
+
+	c.emit(e.Token, OpSlice, bounds)
+	return nil
+}
+
+// compilePipeline passes the value through stages. Each stage binds the value to "@" in its own block,
+// call stages that don't use "@" or call stage functions get it as the first argument unless the call already has
+// all parameters of the function, and other stages are called with it if they produce a function.
+// Stream the pipeline ends with is collected into an array
+func (c *Compiler) compilePipeline(e *ast.PipelineExpression) *CompileError {
+	if err := c.compileExpression(e.Source); err != nil {
+		return err
+	}
+
+	for _, stage := range e.Stages {
+		c.symbols = NewBlockSymbolTable(c.symbols)
+		c.emit(e.Token, OpDup)
+		c.emitSet(e.Token, c.symbols.Define(evaluator.PIPELINE_CONTEXT))
+
+		var err *CompileError
+		if call, ok := stage.(*ast.CallExpression); ok {
+			flags := 0
+			if evaluator.ReferencesContext(call) {
+				flags = PIPE_CALL_STAGE_ONLY
+			}
+
+			err = c.compileExpressions(append([]ast.Expression{call.CalledExpression}, call.Arguments...))
+			c.emit(call.Token, OpPipeCall, len(call.Arguments), flags)
+		} else {
+			err = c.compileExpression(stage)
+			c.emit(evaluator.StageToken(stage, e.Token), OpPipeStage)
+		}
+
+		c.symbols = c.symbols.Outer
+		if err != nil {
+			return err
+		}
+	}
+
+	c.emit(e.Token, OpCollect)
+	return nil
+}
+
+func (c *Compiler) currentFunction() *CompiledFunction {
+	return c.functions[len(c.functions)-1]
+}
+
+// Returns position of the next instruction
+func (c *Compiler) position() int {
+	return len(c.currentFunction().Instructions)
+}
+
+// Adds the instruction to the current function. Returns its position
+func (c *Compiler) emit(tok token.Token, op Opcode, operands ...int) int {
+	fn := c.currentFunction()
+	pos := len(fn.Instructions)
+
+	fn.Instructions = append(fn.Instructions, Make(op, operands...)...)
+	fn.Tokens[pos] = tok
+
+	return pos
+}
+
+// Replaces the first operand of the instruction, used to set position of the jump after its target is compiled
+func (c *Compiler) changeOperand(pos int, operand int) {
+	fn := c.currentFunction()
+	op := Opcode(fn.Instructions[pos])
+	def, _ := Lookup(op)
+
+	operands, _ := ReadOperands(def, fn.Instructions[pos+1:])
+	operands[0] = operand
+
+	copy(fn.Instructions[pos:], Make(op, operands...))
+}
+
+func (c *Compiler) addConstant(obj evaluator.Object) int {
+	c.constants = append(c.constants, obj)
+	return len(c.constants) - 1
+}
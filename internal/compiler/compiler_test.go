@@ -0,0 +1,246 @@
+package compiler
+
+import (
+	"github.com/stretchr/testify/assert"
+	"oilang/internal/evaluator"
+	"oilang/internal/lexer"
+	"oilang/internal/parser"
+	"strings"
+	"testing"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		op       Opcode
+		operands []int
+		expected Instructions
+	}{
+		{OpConstant, []int{65534}, Instructions{byte(OpConstant), 255, 254}},
+		{OpAdd, []int{}, Instructions{byte(OpAdd)}},
+		{OpGetFree, []int{2, 258}, Instructions{byte(OpGetFree), 2, 1, 2}},
+	}
+
+	for _, test := range tests {
+		ins := Make(test.op, test.operands...)
+		assert.Equal(t, test.expected, ins)
+
+		def, err := Lookup(test.op)
+		assert.NoError(t, err)
+
+		operands, read := ReadOperands(def, ins[1:])
+		assert.Equal(t, len(ins)-1, read)
+		assert.Equal(t, test.operands, operands)
+	}
+}
+
+func TestInstructionsString(t *testing.T) {
+	var ins Instructions
+	for _, i := range []Instructions{Make(OpConstant, 1), Make(OpGetFree, 1, 2), Make(OpCall, 3)} {
+		ins = append(ins, i...)
+	}
+
+	assert.Equal(t, "0000 OpConstant 1\n0003 OpGetFree 1 2\n0007 OpCall 3\n", ins.String())
+}
+
+func compile(t *testing.T, input string) *Bytecode {
+	program, perr := parser.New(lexer.New(input)).Parse()
+	assert.Nil(t, perr)
+
+	bytecode, err := New().Compile(program)
+	assert.Nil(t, err)
+
+	return bytecode
+}
+
+// Joins instructions as they appear in the disassembly
+func disassembly(lines ...string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  string
+		constants []evaluator.Object
+	}{
+		{
+			"1 + 2",
+			disassembly("0000 OpConstant 0", "0003 OpConstant 1", "0006 OpAdd", "0007 OpReturnValue"),
+			[]evaluator.Object{&evaluator.Integer{Value: 1}, &evaluator.Integer{Value: 2}},
+		},
+		{
+			"let x = 1; x; -x",
+			disassembly(
+				"0000 OpConstant 0", "0003 OpSetGlobal 0",
+				"0006 OpGetGlobal 0", "0009 OpPop",
+				"0010 OpGetGlobal 0", "0013 OpMinus",
+				"0014 OpReturnValue",
+			),
+			[]evaluator.Object{&evaluator.Integer{Value: 1}},
+		},
+		{
+			"if true { 1 }; let y = 2",
+			disassembly(
+				"0000 OpTrue", "0001 OpJumpNotTruthy 15", "0004 OpEnterScope 0", "0007 OpConstant 0", "0010 OpLeaveScope 1",
+				"0012 OpJump 16", "0015 OpNull", "0016 OpPop", "0017 OpConstant 1", "0020 OpSetGlobal 0",
+				"0023 OpReturn",
+			),
+			[]evaluator.Object{&evaluator.Integer{Value: 1}, &evaluator.Integer{Value: 2}},
+		},
+		{
+			"a and b",
+			disassembly(
+				"0000 OpGetGlobal 0", "0003 OpJumpNotTruthy 13", "0006 OpGetGlobal 1", "0009 OpToBool", "0010 OpJump 14",
+				"0013 OpFalse",
+				"0014 OpReturnValue",
+			),
+			nil,
+		},
+		{
+			"[1] -> push(2)",
+			disassembly(
+				"0000 OpConstant 0", "0003 OpArray 1",
+				"0006 OpDup", "0007 OpSetGlobal 0",
+				"0010 OpGetGlobal 1", "0013 OpConstant 1", "0016 OpPipeCall 1 0",
+				"0019 OpCollect",
+				"0020 OpReturnValue",
+			),
+			[]evaluator.Object{&evaluator.Integer{Value: 1}, &evaluator.Integer{Value: 2}},
+		},
+		{
+			"while x { break }",
+			disassembly(
+				"0000 OpGetGlobal 0", "0003 OpJumpNotTruthy 19",
+				"0006 OpEnterScope 0", "0009 OpLeaveScope 1", "0011 OpJump 19", "0014 OpLeaveScope 1", "0016 OpJump 0",
+				"0019 OpReturn",
+			),
+			nil,
+		},
+		{
+			"for i, x in xs { continue }",
+			disassembly(
+				"0000 OpGetGlobal 0", "0003 OpIterator", "0004 OpSetGlobal 1",
+				"0007 OpGetGlobal 1", "0010 OpIterNext 33 2",
+				"0014 OpEnterScope 2", "0017 OpSetLocal 0", "0020 OpSetLocal 1",
+				"0023 OpLeaveScope 1", "0025 OpJump 7", "0028 OpLeaveScope 1", "0030 OpJump 7",
+				"0033 OpReturn",
+			),
+			nil,
+		},
+		{
+			"x = 1; xs[0] += 1",
+			disassembly(
+				"0000 OpConstant 0", "0003 OpAssignGlobal 0", "0006 OpPop",
+				"0007 OpGetGlobal 1", "0010 OpConstant 1", "0013 OpDupTwo", "0014 OpIndex", "0015 OpConstant 2", "0018 OpAdd", "0019 OpSetIndex",
+				"0020 OpReturnValue",
+			),
+			[]evaluator.Object{&evaluator.Integer{Value: 1}, &evaluator.Integer{Value: 0}, &evaluator.Integer{Value: 1}},
+		},
+	}
+
+	for _, test := range tests {
+		bytecode := compile(t, test.input)
+		assert.Equal(t, test.expected, bytecode.Main.Instructions.String(), test.input)
+		assert.Equal(t, test.constants, bytecode.Constants, test.input)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"break", "break outside of loop"},
+		{"while true { fn() { continue } }", "continue outside of loop"},
+		{"outer: while true { while true { break inner } }", "unknown label inner"},
+		{`import "a.oi" as a`, "import is not supported by the vm engine"},
+	}
+
+	for _, test := range tests {
+		program, perr := parser.New(lexer.New(test.input)).Parse()
+		assert.Nil(t, perr)
+
+		_, err := New().Compile(program)
+		if assert.NotNil(t, err, test.input) {
+			assert.Equal(t, test.message, err.Message, test.input)
+		}
+	}
+}
+
+func TestCompileFunctions(t *testing.T) {
+	bytecode := compile(t, "fn f(a) { let b = 1; fn() { a + b } }")
+
+	assert.Equal(t, disassembly("0000 OpClosure 2", "0003 OpSetGlobal 0", "0006 OpReturn"), bytecode.Main.Instructions.String())
+	assert.Equal(t, []string{"f"}, bytecode.Globals)
+
+	outer := bytecode.Constants[2].(*CompiledFunction)
+	assert.Equal(t, "fn f(a)", outer.Inspect())
+	assert.Equal(t, 2, outer.NumLocals)
+	assert.Equal(t, disassembly(
+		"0000 OpConstant 0", "0003 OpSetLocal 1",
+		"0006 OpClosure 1",
+		"0009 OpReturnValue",
+	), outer.Instructions.String())
+
+	inner := bytecode.Constants[1].(*CompiledFunction)
+	assert.Equal(t, disassembly("0000 OpGetFree 1 0", "0004 OpGetFree 1 1", "0008 OpAdd", "0009 OpReturnValue"), inner.Instructions.String())
+}
+
+func TestCompileSelfBoundFunctions(t *testing.T) {
+	bytecode := compile(t, "let f = fn g(n) { g(n) }")
+
+	fn := bytecode.Constants[0].(*CompiledFunction)
+	assert.Equal(t, 2, fn.NumLocals)
+	assert.Equal(t, disassembly(
+		"0000 OpCurrentClosure", "0001 OpSetLocal 1",
+		"0004 OpGetLocal 1", "0007 OpGetLocal 0", "0010 OpCall 1",
+		"0012 OpReturnValue",
+	), fn.Instructions.String())
+
+	// Parameter hides the name of the function
+	bytecode = compile(t, "let f = fn g(g) { g }")
+	fn = bytecode.Constants[0].(*CompiledFunction)
+	assert.Equal(t, disassembly("0000 OpGetLocal 0", "0003 OpReturnValue"), fn.Instructions.String())
+}
+
+func TestSymbolTable(t *testing.T) {
+	global := NewSymbolTable()
+	assert.Equal(t, Symbol{Name: "a", Scope: GlobalScope, Index: 0}, global.Define("a"))
+	assert.Equal(t, Symbol{Name: "a", Scope: GlobalScope, Index: 0}, global.Define("a"))
+
+	fn := NewEnclosedSymbolTable(global)
+	assert.Equal(t, Symbol{Name: "b", Scope: LocalScope, Index: 0}, fn.Define("b"))
+
+	block := NewBlockSymbolTable(fn)
+	assert.Equal(t, Symbol{Name: "b", Scope: LocalScope, Index: 1}, block.Define("b"))
+	assert.Equal(t, 2, fn.NumDefinitions())
+
+	inner := NewEnclosedSymbolTable(block)
+	tests := []struct {
+		name     string
+		expected Symbol
+	}{
+		{"a", Symbol{Name: "a", Scope: GlobalScope, Index: 0}},
+		{"b", Symbol{Name: "b", Scope: FreeScope, Index: 1, Depth: 1}},
+	}
+
+	for _, test := range tests {
+		symbol, ok := inner.Resolve(test.name)
+		assert.True(t, ok)
+		assert.Equal(t, test.expected, symbol)
+	}
+
+	symbol, _ := fn.Resolve("b")
+	assert.Equal(t, Symbol{Name: "b", Scope: LocalScope, Index: 0}, symbol)
+
+	_, ok := inner.Resolve("c")
+	assert.False(t, ok)
+
+	// Block scope has its own slots, so names outside of it are read the same way as free variables
+	scope := NewScopeSymbolTable(fn)
+	assert.Equal(t, Symbol{Name: "c", Scope: LocalScope, Index: 0}, scope.Define("c"))
+	assert.Equal(t, 2, fn.NumDefinitions())
+
+	symbol, _ = NewEnclosedSymbolTable(scope).Resolve("b")
+	assert.Equal(t, Symbol{Name: "b", Scope: FreeScope, Index: 0, Depth: 2}, symbol)
+}
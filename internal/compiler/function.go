@@ -0,0 +1,35 @@
+package compiler
+
+import (
+	"fmt"
+	"oilang/internal/evaluator"
+	"oilang/internal/token"
+	"strings"
+)
+
+// CompiledFunction is a function lowered to bytecode. It's stored in the constants pool and turned into closures at runtime
+type CompiledFunction struct {
+	Instructions Instructions
+	// Tokens of the instructions by their position, used for reporting errors
+	Tokens    map[int]token.Token
+	NumLocals int
+
+	Name            string
+	Parameters      []string
+	IsPipelineStage bool
+}
+
+func (*CompiledFunction) Type() evaluator.ObjectType { return evaluator.FUNCTION_OBJ }
+func (f *CompiledFunction) Inspect() string {
+	kind := "fn"
+	if f.IsPipelineStage {
+		kind = "@fn"
+	}
+
+	return fmt.Sprintf("%s %s(%s)", kind, f.Name, strings.Join(f.Parameters, ", "))
+}
+
+// TokenAt Returns token of the instruction at the position
+func (f *CompiledFunction) TokenAt(ip int) token.Token {
+	return f.Tokens[ip]
+}
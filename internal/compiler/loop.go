@@ -0,0 +1,176 @@
+package compiler
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/token"
+)
+
+// Name of the hidden slot that holds iterator of the for loop. It's not a valid identifier, so it could not be referenced by the code
+const iteratorSlot = "<iterator>"
+
+// loop is a loop that is being compiled, so break and continue statements could jump out of it or to its next iteration
+type loop struct {
+	label string
+	// Position where the next iteration starts
+	start int
+	// Amount of block scopes around the loop, so break and continue leave the ones inside of it
+	scopes int
+	// Positions of the jumps out of the loop, which are set once the end of the loop is known
+	breaks []int
+}
+
+func (c *Compiler) compileWhile(s *ast.WhileStatement) *CompileError {
+	l := c.enterLoop(s.Label)
+
+	if err := c.compileExpression(s.Condition); err != nil {
+		return err
+	}
+	jumpToEnd := c.emit(s.Token, OpJumpNotTruthy, 0)
+
+	scope := c.enterScope(s.Token)
+	if err := c.compileLoopBody(s.Body); err != nil {
+		return err
+	}
+	c.leaveScope(s.Body.Rbrace, scope)
+	c.emit(s.Token, OpJump, l.start)
+
+	c.changeOperand(jumpToEnd, c.position())
+	c.leaveLoop(l)
+
+	return nil
+}
+
+// Each iteration has its own scope, where loop names are defined together with let statements of the body,
+// while the iterator is kept in the slot that is visible only to the loop itself
+func (c *Compiler) compileFor(s *ast.ForStatement) *CompileError {
+	if err := c.compileExpression(s.Iterable); err != nil {
+		return err
+	}
+	c.emit(s.Token, OpIterator)
+
+	c.symbols = NewBlockSymbolTable(c.symbols)
+	iterator := c.symbols.Define(iteratorSlot)
+	c.symbols = c.symbols.Outer
+	c.emitSet(s.Token, iterator)
+
+	l := c.enterLoop(s.Label)
+	c.emitGet(s.Token, iterator)
+
+	names := []*ast.Identifier{s.Value}
+	if s.Key != nil {
+		names = []*ast.Identifier{s.Key, s.Value}
+	}
+	next := c.emit(s.Token, OpIterNext, 0, len(names))
+
+	scope := c.enterScope(s.Token)
+	// The last value is on the top of the stack
+	for i := len(names) - 1; i >= 0; i-- {
+		c.emitSet(names[i].Token, c.symbols.Define(names[i].Value))
+	}
+
+	if err := c.compileLoopBody(s.Body); err != nil {
+		return err
+	}
+	c.leaveScope(s.Body.Rbrace, scope)
+	c.emit(s.Token, OpJump, l.start)
+
+	c.changeOperand(next, c.position())
+	c.leaveLoop(l)
+
+	return nil
+}
+
+// Body is compiled as statements, so the values of its expressions are dropped
+func (c *Compiler) compileLoopBody(body *ast.BlockStatement) *CompileError {
+	hasValue, err := c.compileStatements(body.Statements)
+	if err != nil {
+		return err
+	}
+
+	if hasValue {
+		c.emit(body.Rbrace, OpPop)
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileBreak(s *ast.BreakStatement) *CompileError {
+	l, err := c.findLoop(s.Token, s.Label)
+	if err != nil {
+		return err
+	}
+
+	c.leaveScopes(s.Token, c.scopes-l.scopes)
+	l.breaks = append(l.breaks, c.emit(s.Token, OpJump, 0))
+	return nil
+}
+
+func (c *Compiler) compileContinue(s *ast.ContinueStatement) *CompileError {
+	l, err := c.findLoop(s.Token, s.Label)
+	if err != nil {
+		return err
+	}
+
+	c.leaveScopes(s.Token, c.scopes-l.scopes)
+	c.emit(s.Token, OpJump, l.start)
+	return nil
+}
+
+// Starts the block scope, which names are defined in the new symbol table. Returns position of the instruction,
+// which gets the amount of locals of the scope once the block is compiled
+func (c *Compiler) enterScope(tok token.Token) int {
+	c.symbols = NewScopeSymbolTable(c.symbols)
+	c.scopes++
+
+	return c.emit(tok, OpEnterScope, 0)
+}
+
+// Finishes the block scope started at the position
+func (c *Compiler) leaveScope(tok token.Token, start int) {
+	c.changeOperand(start, c.symbols.NumDefinitions())
+	c.symbols = c.symbols.Outer
+	c.scopes--
+
+	c.leaveScopes(tok, 1)
+}
+
+func (c *Compiler) leaveScopes(tok token.Token, n int) {
+	if n > 0 {
+		c.emit(tok, OpLeaveScope, n)
+	}
+}
+
+// Returns the loop with the label, or the innermost one if there is no label
+func (c *Compiler) findLoop(tok token.Token, label *ast.Identifier) (*loop, *CompileError) {
+	for i := len(c.loops) - 1; i >= 0; i-- {
+		if label == nil || c.loops[i].label == label.Value {
+			return c.loops[i], nil
+		}
+	}
+
+	if label != nil {
+		return nil, &CompileError{Message: "unknown label " + label.Value, Token: tok}
+	}
+
+	return nil, &CompileError{Message: tok.Literal + " outside of loop", Token: tok}
+}
+
+// Starts the loop at the current position
+func (c *Compiler) enterLoop(label *ast.Identifier) *loop {
+	l := &loop{start: c.position(), scopes: c.scopes}
+	if label != nil {
+		l.label = label.Value
+	}
+
+	c.loops = append(c.loops, l)
+	return l
+}
+
+// Finishes the loop at the current position, which is where break statements jump to
+func (c *Compiler) leaveLoop(l *loop) {
+	for _, pos := range l.breaks {
+		c.changeOperand(pos, c.position())
+	}
+
+	c.loops = c.loops[:len(c.loops)-1]
+}
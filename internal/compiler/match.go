@@ -0,0 +1,87 @@
+package compiler
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/evaluator"
+)
+
+// Name of the hidden slot that holds the value being matched
+const subjectSlot = "<subject>"
+
+// Pattern is the pattern of the match arm. It's stored in the constants pool, so the VM could match values against it
+type Pattern struct {
+	Pattern ast.Pattern
+}
+
+func (*Pattern) Type() evaluator.ObjectType { return evaluator.PATTERN_OBJ }
+func (p *Pattern) Inspect() string          { return p.Pattern.String() }
+
+// Arms are tried in order. Each arm has its own scope, where patterns bind their names before the guard is checked,
+// and the arm that did not match leaves its scope and jumps to the next one. Match leaves null if no arm has matched
+func (c *Compiler) compileMatch(e *ast.MatchExpression) *CompileError {
+	if err := c.compileExpression(e.Subject); err != nil {
+		return err
+	}
+
+	// Subject is defined outside of the arms, which read it from their scopes
+	c.symbols = NewBlockSymbolTable(c.symbols)
+	c.emitSet(e.Token, c.symbols.Define(subjectSlot))
+
+	var jumpsToEnd []int
+	for _, arm := range e.Arms {
+		scope := c.enterScope(e.Token)
+		subject, _ := c.symbols.Resolve(subjectSlot)
+		var jumpsToArm []int
+		for _, pattern := range arm.Patterns {
+			c.emitGet(e.Token, subject)
+			c.emit(e.Token, OpMatch, c.addConstant(&Pattern{Pattern: pattern}))
+			jumpToNextPattern := c.emit(e.Token, OpJumpNotTruthy, 0)
+
+			// The last value is on the top of the stack
+			names := ast.PatternNames(pattern)
+			for i := len(names) - 1; i >= 0; i-- {
+				c.emitSet(names[i].Token, c.symbols.Define(names[i].Value))
+			}
+
+			jumpsToArm = append(jumpsToArm, c.emit(e.Token, OpJump, 0))
+			c.changeOperand(jumpToNextPattern, c.position())
+		}
+		jumpToNextArm := []int{c.emit(e.Token, OpJump, 0)}
+
+		for _, pos := range jumpsToArm {
+			c.changeOperand(pos, c.position())
+		}
+
+		if arm.Guard != nil {
+			if err := c.compileExpression(arm.Guard); err != nil {
+				return err
+			}
+			jumpToNextArm = append(jumpToNextArm, c.emit(e.Token, OpJumpNotTruthy, 0))
+		}
+
+		var err *CompileError
+		if arm.Body != nil {
+			err = c.compileBlock(arm.Body)
+		} else {
+			err = c.compileExpression(arm.Value)
+		}
+		if err != nil {
+			return err
+		}
+		c.leaveScope(e.Token, scope)
+		jumpsToEnd = append(jumpsToEnd, c.emit(e.Token, OpJump, 0))
+
+		for _, pos := range jumpToNextArm {
+			c.changeOperand(pos, c.position())
+		}
+		c.leaveScopes(e.Token, 1)
+	}
+	c.symbols = c.symbols.Outer
+
+	c.emit(e.Token, OpNull)
+	for _, pos := range jumpsToEnd {
+		c.changeOperand(pos, c.position())
+	}
+
+	return nil
+}
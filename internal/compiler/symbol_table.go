@@ -0,0 +1,128 @@
+package compiler
+
+type SymbolScope string
+
+const (
+	GlobalScope SymbolScope = "GLOBAL"
+	LocalScope  SymbolScope = "LOCAL"
+	// Local of the enclosing function
+	FreeScope SymbolScope = "FREE"
+)
+
+// Symbol is a name resolved to the slot where its value is stored
+type Symbol struct {
+	Name  string
+	Scope SymbolScope
+	Index int
+	// Amount of functions between the usage and the definition of free symbol
+	Depth int
+}
+
+// SymbolTable maps names of the scope to their slots.
+//
+// Each function has its own table and slots for its locals, while blocks that bind names only for a part
+// of the function (e.g. "@" in the pipeline stage) have tables that share slots of the function.
+// Blocks that have their own scope (e.g. loop bodies) allocate their own slots, the same way functions do
+type SymbolTable struct {
+	Outer *SymbolTable
+
+	store map[string]Symbol
+	// Table of the function or global table that allocates slots for this one
+	owner *SymbolTable
+	// Amount of slots allocated by the owner table
+	numDefinitions int
+	// Names of the slots by their index, used for global slots only
+	names []string
+}
+
+// NewSymbolTable Creates global table
+func NewSymbolTable() *SymbolTable {
+	s := &SymbolTable{store: make(map[string]Symbol)}
+	s.owner = s
+
+	return s
+}
+
+// NewEnclosedSymbolTable Creates table of the function defined inside the outer scope
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewSymbolTable()
+	s.Outer = outer
+
+	return s
+}
+
+// NewScopeSymbolTable Creates table for the block that has its own scope when it runs, e.g. iteration of the loop.
+// Its names have their own slots, which are read from the enclosing function the same way as its free variables
+func NewScopeSymbolTable(outer *SymbolTable) *SymbolTable {
+	return NewEnclosedSymbolTable(outer)
+}
+
+// NewBlockSymbolTable Creates table for the block inside the outer scope, which names are not visible outside
+func NewBlockSymbolTable(outer *SymbolTable) *SymbolTable {
+	return &SymbolTable{Outer: outer, store: make(map[string]Symbol), owner: outer.owner}
+}
+
+func (s *SymbolTable) isGlobal() bool {
+	return s.owner.Outer == nil
+}
+
+// Define Binds the name to the slot. Name that is already defined in the same scope keeps its slot
+func (s *SymbolTable) Define(name string) Symbol {
+	if symbol, ok := s.store[name]; ok {
+		return symbol
+	}
+
+	symbol := Symbol{Name: name, Scope: LocalScope, Index: s.owner.numDefinitions}
+	if s.isGlobal() {
+		symbol.Scope = GlobalScope
+		s.owner.names = append(s.owner.names, name)
+	}
+
+	s.owner.numDefinitions++
+	s.store[name] = symbol
+
+	return symbol
+}
+
+// Resolve Looks up the name in the current scope and then in the outer ones
+func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
+	depth := 0
+
+	for table := s; table != nil; table = table.Outer {
+		if symbol, ok := table.store[name]; ok {
+			if symbol.Scope == LocalScope && depth > 0 {
+				symbol.Scope = FreeScope
+				symbol.Depth = depth
+			}
+
+			return symbol, true
+		}
+
+		// Leaving the function or the block scope
+		if table.owner == table && table.Outer != nil {
+			depth++
+		}
+	}
+
+	return Symbol{}, false
+}
+
+// Global Returns the global table
+func (s *SymbolTable) Global() *SymbolTable {
+	table := s
+	for table.Outer != nil {
+		table = table.Outer
+	}
+
+	return table
+}
+
+// NumDefinitions Returns amount of slots allocated by the function or global scope
+func (s *SymbolTable) NumDefinitions() int {
+	return s.owner.numDefinitions
+}
+
+// Names Returns names of the global slots by their index
+func (s *SymbolTable) Names() []string {
+	return s.Global().names
+}
@@ -0,0 +1,354 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"oilang/internal/ast"
+	"oilang/internal/compiler"
+	"oilang/internal/evaluator"
+	"oilang/internal/lexer"
+	"oilang/internal/modules"
+	"oilang/internal/parser"
+	"oilang/internal/vm"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// engine runs the parsed program of the file under the guard and returns its result. Imports are resolved relative to the file
+type engine func(program *ast.Program, file string, g *evaluator.Guard) evaluator.Object
+
+var engines = map[string]engine{
+	"evaluator": func(program *ast.Program, file string, g *evaluator.Guard) evaluator.Object {
+		env := evaluator.NewEnvironment()
+		env.SetImporter(modules.NewLoader(file), file)
+		env.SetGuard(g)
+
+		return evaluator.Eval(program, env)
+	},
+	"concurrent evaluator": func(program *ast.Program, file string, g *evaluator.Guard) evaluator.Object {
+		env := evaluator.NewEnvironment()
+		env.SetImporter(modules.NewLoader(file), file)
+		env.SetConcurrent(true)
+		env.SetGuard(g)
+
+		return evaluator.Eval(program, env)
+	},
+	"vm": func(program *ast.Program, _ string, g *evaluator.Guard) evaluator.Object {
+		bytecode, err := compiler.New().Compile(program)
+		if err != nil {
+			return evaluator.NewError(err.Token, "compile error: %s", err.Message)
+		}
+
+		machine := vm.New(bytecode)
+		machine.SetGuard(g)
+		return machine.Run()
+	},
+}
+
+// Features some engines do not support yet, with the errors they report instead of the result
+var unsupported = map[string]map[string]string{
+	"import": {"vm": "error 1:1: compile error: import is not supported by the vm engine"},
+}
+
+// Describes result in a way that could be compared between engines, including position of the errors
+func describe(result evaluator.Object) string {
+	switch result := result.(type) {
+	case nil:
+		return "<none>"
+	case *evaluator.Error:
+		return fmt.Sprintf("error %d:%d: %s", result.Token.Line+1, result.Token.Col+1, result.Message)
+	}
+
+	return result.Inspect()
+}
+
+func TestConformance(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// Literals and operators
+		{"1 + 2 * 3", "7"},
+		{"2 ** 10 - 7 / 2", "1021"},
+		{"2 ** -1", "0.5"},
+		{"1.5 * 2", "3.0"},
+		{"-5 + 10", "5"},
+		{"not true", "false"},
+		{"not if false { 1 }", "true"},
+		{"1 < 2 and 2 <= 2", "true"},
+		{"1 > 2 or 3 >= 4", "false"},
+		{"1 == 1.0", "true"},
+		{`"a" + "b" == "ab"`, "true"},
+		{`"a" < "b"`, "true"},
+		{"true != false", "true"},
+		{"if false { 1 } == if false { 2 }", "true"},
+		{`"x" and 0`, "true"},
+		{"false and undefined", "false"},
+		{"true or undefined", "true"},
+		{`"n: ${1 + 1}, ${[1, "a"]}!"`, `"n: 2, [1, \"a\"]!"`},
+
+		// Bindings and blocks
+		{"let x = 5; x * 2", "10"},
+		{"let x = 5", "<none>"},
+		{"let x = 1; let x = x + 1; x", "2"},
+		{"if 1 > 2 { 1 }", "null"},
+		{"if 1 < 2 { 1 } else { 2 }", "1"},
+		{"if false { 1 } else { let y = 2; }", "null"},
+		{"if true { let y = 2; }; y", "error 1:25: identifier not found: y"},
+		{"let q = 1; if true { let q = 2; }; q", "1"},
+		{"let q = 1; if false { 1 } else { let q = 2; q }", "2"},
+		{"let q = 1; if true { q = 2; }; q", "2"},
+		{"let fs = []; for x in [1, 2] { if true { let y = x; fs = push(fs, fn() { y }) } }; [fs[0](), fs[1]()]", "[1, 2]"},
+		{"let s = 0; for x in [1, 2, 3] { if x == 2 { let y = x; continue } s += x }; s", "4"},
+		{"if true { 1 }; return 5; 10", "5"},
+
+		// Functions
+		{"fn add(a, b) { a + b }; add(1, 2)", "3"},
+		{"fn add(a, b) { a + b }", "<none>"},
+		{"let f = fn(x) { return x * 2; 100 }; f(4)", "8"},
+		{"fn f() { let y = 1; }; f()", "null"},
+		{"fn f() {}; f()", "null"},
+		{"fn fact(n) { if n <= 1 { 1 } else { n * fact(n - 1) } }; fact(10)", "3628800"},
+		{"fn even(n) { if n == 0 { true } else { odd(n - 1) } }; fn odd(n) { if n == 0 { false } else { even(n - 1) } }; even(10)", "true"},
+		{"fn adder(x) { fn(y) { x + y } }; adder(2)(3)", "5"},
+		{"fn counter() { let n = 1; let get = fn() { n }; let n = 2; get() }; counter()", "2"},
+		{"let x = 1; fn f() { x }; let x = 2; f()", "2"},
+		{"fn outer() { fn inner() { helper() }; fn helper() { 42 }; inner() }; outer()", "42"},
+		{"fn f(a) { fn(b) { fn(c) { a + b + c } } }; f(1)(2)(3)", "6"},
+		{"fn(x) { x }", "fn (x)"},
+		{"let f = fn fact(n) { if n <= 1 { 1 } else { n * fact(n - 1) } }; f(5)", "120"},
+		{"let fact = 0; let f = fn fact(n) { if n <= 1 { 1 } else { n * fact(n - 1) } }; [f(4), fact]", "[24, 0]"},
+		{"let f = fn me() { me }; f() == f", "true"},
+		{"fn f(f) { f }; f(1)", "1"},
+		{"let f = fn g(g) { g }; f(1)", "1"},
+		{"let h = {count: fn count(n) { if n == 0 { 0 } else { 1 + count(n - 1) } }}; h.count(3)", "3"},
+		{"let adder = fn(x) { fn(y) { x + y } }; let add2 = adder(2); [add2(1), adder(10)(1)]", "[3, 11]"},
+		{"fn apply(f, x) { f(x) }; apply(fn(x) { x * 3 }, 2)", "6"},
+		{"fn compose(f, g) { fn(x) { g(f(x)) } }; compose(fn(x) { x + 1 }, fn(x) { x * 2 })(3)", "8"},
+		{"let fs = []; for n in [1, 2] { fs = push(fs, fn get() { n }) }; [fs[0] == fs[1], fs[0]()]", "[false, 1]"},
+		{"@fn double() { @ * 2 }", "<none>"},
+		{"len", "builtin len"},
+		{"let len = fn(x) { 0 }; len([1, 2])", "0"},
+
+		// Collections
+		{"[1, 2 + 3, \"a\"]", `[1, 5, "a"]`},
+		{"[1, 2, 3][-1]", "3"},
+		{"[1, 2, 3, 4][1:3]", "[2, 3]"},
+		{"[1, 2, 3][:10]", "[1, 2, 3]"},
+		{`"hello"[1:]`, `"ello"`},
+		{`{a: 1, "b": 2}["b"]`, "2"},
+		{`{a: 1}["missing"]`, "null"},
+		{`{1: "one", true: "yes"}`, `{1: "one", true: "yes"}`},
+		{"keys({b: 1, a: 2})", `["b", "a"]`},
+		{"push([1], 2, 3)", "[1, 2, 3]"},
+		{"{a: {b: 2}}.a.b", "2"},
+		{"{a: 1}.missing", "null"},
+		{"let h = {f: fn(x) { x + 1 }}; h.f(1)", "2"},
+
+		// Pipelines
+		{"[1, 2, 3] -> sum", "6"},
+		{"[1, 2, 3] -> @fn() { @ * 2 }", "[2, 4, 6]"},
+		{"[1, 2, 3] -> @fn() { @ * 2 } -> sum", "12"},
+		{"[1, 2, 3] -> fn(xs) { len(xs) }", "3"},
+		{"5 -> @ * 2", "10"},
+		{"[1, 2] -> push(3)", "[1, 2, 3]"},
+		{"[1, 2] -> push(@, len(@))", "[1, 2, 2]"},
+		{"fn add(a, b) { a + b }; 1 -> add(2) -> add(@, 10)", "13"},
+		{"[[1, 2], [3]] -> @fn() { @ -> sum }", "[3, 3]"},
+		{"@fn scale(by) { @ * by }; [1, 2] -> scale(10)", "[10, 20]"},
+		{"@fn scale(by) { @ * by }; 3 -> scale(@ - 1)", "6"},
+		{"let f = @fn() { fn() { @ } }; 1 -> f -> @()", "1"},
+		{"fn pick(x) { @fn() { [x, @] } }; 5 -> pick(1)()", "[1, 5]"},
+		{"1 -> (2 -> @ + 1) + @", "4"},
+		{"let f = fn() { 3 -> fn(x) { @ + x } }; f()", "6"},
+		{"[1, 2, 3] -> fn sum(xs) { if len(xs) == 0 { 0 } else { xs[0] + sum(xs[1:]) } }", "6"},
+		{"fn twice(f) { fn(x) { f(f(x)) } }; 2 -> twice(fn(x) { x * 3 })", "18"},
+		{"fn scale(n) { @fn() { @ * n } }; [1, 2] -> scale(10) -> sum", "30"},
+		{"@fn each(f) { f(@) }; [1, 2] -> each(fn(x) { x + 1 })", "[2, 3]"},
+		{"fn add(a, b) { a + b }; let inc = fn(x) { add(x, 1) }; 1 -> inc -> add(5)", "7"},
+		{"[1, 2] -> @fn() { fn(y) { @ + y } } -> @fn() { @(10) }", "[11, 12]"},
+		{"[1, 2, 3, 4] -> parallel(2) -> @fn() { @ * 2 } -> @fn() { @ + 1 }", "[3, 5, 7, 9]"},
+		{"@fn scale(by) { @ * by }; [1, 2, 3] -> parallel(3) -> scale(len(@)) -> sum", "18"},
+		{"[1, 2] -> parallel(4)", "[1, 2]"},
+		{"5 -> parallel(2) -> @fn() { @ + 1 }", "6"},
+		{"fn f() { 5 }; 1 -> f()", "error 1:21: stage is a complete call; use @ or drop an argument"},
+		{"fn add(a, b) { a + b }; 5 -> add(1, 2)", "error 1:33: stage is a complete call; use @ or drop an argument"},
+		{"fn double(x) { x * 2 }; [1, 2] -> double(3)", "error 1:41: stage is a complete call; use @ or drop an argument"},
+		{"fn times(n) { fn(x) { x * n } }; 5 -> times(2)", "10"},
+		{"fn add(a, b) { a + b }; 5 -> add(1, @)", "6"},
+
+		// Streams
+		{"range(1, 5) -> @fn() { @ * 2 }", "[2, 4, 6, 8]"},
+		{"range(1, 100) -> @fn() { @ * @ } -> take(3)", "[1, 4, 9]"},
+		{"range(0, 10) -> filter(fn(x) { x > 6 }) -> map(fn(x) { x + 1 })", "[8, 9, 10]"},
+		{"range(1, 5) -> sum", "10"},
+		{"range(1, 6) -> skip(2) -> chunk(2)", "[[3, 4], [5]]"},
+		{"zip(range(0, 10), [\"a\", \"b\"]) -> @fn() { @[0] }", "[0, 1]"},
+		{"[1, 2, 3] -> window(2)", "[[1, 2], [2, 3]]"},
+		{"let s = 0; for x in range(1, 4) { s += x }; s", "6"},
+		{"range(1, 4) -> parallel(2) -> @fn() { @ * 10 }", "[10, 20, 30]"},
+
+		// Loops
+		{"let n = 0; let i = 5; while i > 0 { n = n + i; i = i - 1; }; n", "15"},
+		{"while false { 1 }", "<none>"},
+		{"let s = 0; for x in [1, 2, 3] { s = s + x; }; s", "6"},
+		{"let s = []; for i, x in [10, 20] { s = push(s, [i, x]); }; s", "[[0, 10], [1, 20]]"},
+		{`let s = ""; for k in ({a: 1, b: 2}) { s = s + k; }; s`, `"ab"`},
+		{`let s = 0; for k, v in ({a: 1, b: 2}) { s = s + v; }; s`, "3"},
+		{`let s = []; for i, c in "hé" { s = push(s, c + str(i)); }; s`, `["h0", "é1"]`},
+		{"for x in [1, 2] {}; x", "error 1:21: identifier not found: x"},
+		{"let s = 0; for x in [1, 2, 3, 4, 5] { if x == 2 { continue }; if x == 4 { break }; s = s + x; }; s", "4"},
+		{"let s = []; outer: for x in [1, 2, 3] { for y in [1, 2, 3] { if y == 2 { continue outer }; if x == 3 { break outer }; s = push(s, [x, y]); } }; s", "[[1, 1], [2, 1]]"},
+		{"let i = 0; while true { i = i + 1; if i >= 3 { break } }; i", "3"},
+		{"fn find(xs, v) { for i, x in xs { if x == v { return i } }; -1 }; [find([5, 6], 6), find([], 1)]", "[1, -1]"},
+		{"fn f() { for x in [1] { 5 } }; f()", "null"},
+		{"let fs = []; for x in [1, 2] { fs = push(fs, fn() { x }); }; fs[0]()", "1"},
+		{"for x in [[1, 2]] { for y in x { if y == 2 { break } } }; y", "error 1:59: identifier not found: y"},
+
+		// Assignments
+		{"let x = 1; x = 2; x", "2"},
+		{"let x = 1; x = x + 1", "2"},
+		{"let a = 0; let b = 0; a = b = 3; [a, b]", "[3, 3]"},
+		{"let x = 2; x += 3; x -= 1; x *= 5; x /= 2; x **= 2; x", "100"},
+		{`let s = "a"; s += "b"`, `"ab"`},
+		{"let xs = [1, 2, 3]; xs[0] = 5; xs[-1] += 10; xs", "[5, 2, 13]"},
+		{"let xs = [1]; let ys = xs; ys[0] = 2; xs", "[2]"},
+		{`let h = {}; h["a"] = 1; h.b = 2; h.a += 5; h`, `{"a": 6, "b": 2}`},
+		{"let m = [[1]]; m[0][0] *= 7; m", "[[7]]"},
+		{"let i = 0; while i < 3 { i += 1 }; i", "3"},
+
+		// Each iteration of the loop has its own scope
+		{"let x = 1; for x in [5, 6] {}; x", "1"},
+		{"let fs = []; for i in [1, 2, 3] { fs = push(fs, fn() { i }) }; [fs[0](), fs[1](), fs[2]()]", "[1, 2, 3]"},
+		{"let fs = []; let i = 0; while i < 2 { let j = i; fs = push(fs, fn() { j }); i += 1 }; [fs[0](), fs[1]()]", "[0, 1]"},
+		{"let y = 1; let i = 0; while i < 2 { let y = 5; i += 1 }; y", "1"},
+		{"fn f() { let r = 0; outer: for x in [1, 2] { for y in [3] { r = x + y; break outer } }; r }; f()", "4"},
+		{"fn f() { let r = []; for x in [1, 2, 3] { let d = x * 2; if x == 2 { continue }; r = push(r, fn() { d }) }; [r[0](), r[1](), len(r)] }; f()", "[2, 6, 2]"},
+		{"fn counter() { let n = 0; fn() { n += 1 } }; let c = counter(); c(); c()", "2"},
+		{"let n = 0; fn inc() { n += 1 }; inc(); inc(); n", "2"},
+		{"fn f(x) { x = x * 2; x }; f(4)", "8"},
+		{"const x = 1; x", "1"},
+
+		// Conditions and matching
+		{"let x = 5; if x < 3 { 1 } else if x < 10 { 2 } else { 3 }", "2"},
+		{"if false { 1 } else if false { 2 }", "null"},
+		{"match 2 { 1 | 2 => \"small\", _ => \"other\" }", `"small"`},
+		{"match 1.0 { 1 => \"one\" }", `"one"`},
+		{"match -1 { -1 => true, _ => false }", "true"},
+		{`match "a" { "b" => 1 }`, "null"},
+		{"match 3 { n if n > 5 => 1, n => n * 2 }", "6"},
+		{"match [1, 2, 3] { [] => 0, [x] => x, [head, ...rest] => [head, rest] }", "[1, [2, 3]]"},
+		{"match [1] { [_, ..._] => 1 }", "1"},
+		{"match [1, 2] { [a] => a, [a, b, c] => c }", "null"},
+		{`match ({name: "oi", age: 3}) { {name: n, missing} => 0, {name: n, age} => [n, age] }`, `["oi", 3]`},
+		{`match ({a: [1, {b: 2}]}) { {a: [1, {b}]} => b }`, "2"},
+		{"fn sum(xs) { match xs { [] => 0, [x, ...rest] => x + sum(rest) } }; sum([1, 2, 3])", "6"},
+		{"let r = match 1 { x => { let y = x + 1; y * 10 } }; r", "20"},
+		{"match 1 { x => { let y = x + 1; y * 10 } }; x", "error 1:45: identifier not found: x"},
+		{"let x = 0; match 1 { x => x }; x", "0"},
+		{"let s = 0; for x in [1, 2, 3] { s += match x { 2 => 20, v => v } }; s", "24"},
+		{"match [1] { [x] if x > 1 => x, _ => x }", "error 1:37: identifier not found: x"},
+		{"let x = 0; match [1] { [x] if x > 1 => x, _ => x }", "0"},
+
+		// Errors
+		{"1 + true", "error 1:3: type mismatch: INTEGER + BOOLEAN"},
+		{"-true", "error 1:1: unknown operator: -BOOLEAN"},
+		{"5 / 0", "error 1:3: division by zero"},
+		{"foo", "error 1:1: identifier not found: foo"},
+		{"if false { let y = 1; }; y", "error 1:26: identifier not found: y"},
+		{"fn f() { if false { let z = 1; }; z }; f()", "error 1:35: identifier not found: z"},
+		{"fn f(a) { a }; f(1, 2)", "error 1:17: wrong number of arguments: expected 1, got 2"},
+		{"1(2)", "error 1:2: not a function: INTEGER"},
+		{"len(1)", "error 1:4: argument to len is not supported: INTEGER"},
+		{"[1][5]", "error 1:4: index out of range: 5 with length 1"},
+		{"{[1]: 2}", "error 1:1: unusable as hash key: ARRAY"},
+		{"1[0:1]", "error 1:2: slice operator not supported: INTEGER"},
+		{"let x = 1; x.y", "error 1:13: member access not supported: INTEGER"},
+		{"[1, 2] -> @fn() { @ + true }", "error 1:21: type mismatch: INTEGER + BOOLEAN"},
+		{"@fn double() { @ * 2 }; double()", "error 1:31: @fn could be called only as pipeline stage"},
+		{"[] -> @fn(a) { a }", "error 1:7: wrong number of arguments: expected 1, got 0"},
+		{"[1, 2] -> parallel(0) -> @fn() { @ }", "error 1:19: amount of workers must be positive, got 0"},
+		{"[1, 2, 3] -> parallel(2) -> @fn() { 10 / (@ - 2) }", "error 1:40: division by zero"},
+		{"1 -> 2", "2"},
+		{"range(0, 5) -> @fn() { 10 / (@ - 2) }", "error 1:27: division by zero"},
+		{"range(0, 5) -> map(fn(x) { x + true })", "error 1:30: type mismatch: INTEGER + BOOLEAN"},
+		{"for x in range(0, 3) { x / 0 }", "error 1:26: division by zero"},
+		{"take(1, 2)", "error 1:5: argument to take must be ARRAY or STREAM, got INTEGER"},
+		{"1 -> @fn(a, b) { a }(1)", "error 1:21: wrong number of arguments: expected 2, got 1"},
+		{"for x in 5 { x }", "error 1:1: not iterable: INTEGER"},
+		{"while 1 + true { 1 }", "error 1:9: type mismatch: INTEGER + BOOLEAN"},
+		{"for x in [1] { x + true }", "error 1:18: type mismatch: INTEGER + BOOLEAN"},
+		{"match 1 { n if n + true => 1 }", "error 1:18: type mismatch: INTEGER + BOOLEAN"},
+		{"match 1 + true { _ => 1 }", "error 1:9: type mismatch: INTEGER + BOOLEAN"},
+		{"x = 1", "error 1:1: identifier not found: x"},
+		{"fn f() { y += 1 }; f()", "error 1:10: identifier not found: y"},
+		{"let x = 1; x += true", "error 1:14: type mismatch: INTEGER + BOOLEAN"},
+		{"let xs = [1]; xs[1] = 2", "error 1:17: index out of range: 1 with length 1"},
+		{`let s = "ab"; s[0] = "c"`, "error 1:16: index assignment not supported: STRING"},
+		{"let x = 1; x.y = 2", "error 1:13: member assignment not supported: INTEGER"},
+		{`let h = {}; h["a"] += 1`, "error 1:20: type mismatch: NULL + INTEGER"},
+	}
+
+	for _, test := range tests {
+		program, err := parser.New(lexer.New(test.input)).Parse()
+		if !assert.Nil(t, err, test.input) {
+			continue
+		}
+
+		for name, run := range engines {
+			assert.Equal(t, test.expected, describe(run(program, "", nil)), "%s: %s", name, test.input)
+		}
+	}
+}
+
+func TestCallDepth(t *testing.T) {
+	program, err := parser.New(lexer.New("let f = fn(n) { f(n + 1) }; f(0)")).Parse()
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	for name, run := range engines {
+		g := evaluator.NewGuard(context.Background(), evaluator.Limits{CallDepth: 100})
+		assert.Equal(t, "error 1:18: call depth limit of 100 exceeded", describe(run(program, "", g)), name)
+
+		// Default limits stop the recursion before it overflows the stack of the host
+		result := run(program, "", evaluator.NewGuard(context.Background(), evaluator.Limits{}))
+		if assert.IsType(t, &evaluator.Error{}, result, name) {
+			assert.Equal(t, 18, result.(*evaluator.Error).Token.Col+1, name)
+		}
+	}
+}
+
+func TestModules(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "lib.oi"), []byte("export fn double(x) { x * 2 }\nexport let name = \"lib\"\n"), 0o644))
+	main := filepath.Join(dir, "main.oi")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "./lib.oi" as lib; lib.double(21)`, "42"},
+		{`import "./lib.oi" as lib; [1, 2] -> @fn() { lib.double(@) }`, "[2, 4]"},
+		{`import "./lib.oi" as lib; lib.name + "!"`, `"lib!"`},
+		{`import "./lib.oi" as lib; lib.missing`, "error 1:30: module lib.oi has no export missing"},
+	}
+
+	for _, test := range tests {
+		program, err := parser.New(lexer.New(test.input)).Parse()
+		if !assert.Nil(t, err, test.input) {
+			continue
+		}
+
+		for name, run := range engines {
+			expected, ok := unsupported["import"][name]
+			if !ok {
+				expected = test.expected
+			}
+
+			assert.Equal(t, expected, describe(run(program, main, nil)), "%s: %s", name, test.input)
+		}
+	}
+}
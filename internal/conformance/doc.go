@@ -0,0 +1,3 @@
+// Package conformance holds tests that run the same programs on every execution engine
+// (tree-walking evaluator and bytecode VM) and check that they produce the same results and errors
+package conformance
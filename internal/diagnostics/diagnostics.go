@@ -0,0 +1,150 @@
+package diagnostics
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"oilang/internal/token"
+	"strings"
+	"unicode/utf8"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+)
+
+func (s Severity) String() string {
+	if s == Warning {
+		return "warning"
+	}
+
+	return "error"
+}
+
+// Diagnostic is a message about the problem in the source code that points to the token which caused it
+type Diagnostic struct {
+	Severity Severity
+	Message  string
+	Token    token.Token
+	// Additional hints that are shown after the source excerpt
+	Notes []string
+}
+
+// New Creates diagnostic for the token
+func New(severity Severity, tok token.Token, message string, notes ...string) Diagnostic {
+	return Diagnostic{Severity: severity, Message: message, Token: tok, Notes: notes}
+}
+
+// Renderer turns diagnostics into human-readable text with excerpt of the source, e.g.:
+//
+//	error: unexpected token
+//	 --> script.oi:1:9
+//	  |
+//	1 | let x = ]
+//	  |         ^
+//	  = help: ...
+type Renderer struct {
+	File  string
+	Color bool
+	lines []string
+}
+
+// NewRenderer Creates renderer for the diagnostics in the supplied source
+func NewRenderer(file, source string, useColor bool) *Renderer {
+	return &Renderer{File: file, Color: useColor, lines: strings.Split(source, "\n")}
+}
+
+// Render Formats single diagnostic. Result always ends with a newline
+func (r *Renderer) Render(d Diagnostic) string {
+	var out strings.Builder
+
+	severityColor := r.paint(color.FgRed, color.Bold)
+	if d.Severity == Warning {
+		severityColor = r.paint(color.FgYellow, color.Bold)
+	}
+	accent := r.paint(color.FgBlue, color.Bold)
+	bold := r.paint(color.Bold)
+
+	line, col := d.Token.Line, d.Token.Col
+	gutter := strings.Repeat(" ", len(fmt.Sprint(line+1)))
+
+	out.WriteString(severityColor(d.Severity.String()) + bold(": "+d.Message) + "\n")
+	out.WriteString(fmt.Sprintf("%s%s %s:%d:%d\n", gutter, accent("-->"), r.File, line+1, col+1))
+
+	if line >= 0 && line < len(r.lines) {
+		src := strings.TrimRight(r.lines[line], "\r")
+
+		out.WriteString(gutter + " " + accent("|") + "\n")
+		out.WriteString(accent(fmt.Sprint(line+1)+" |") + " " + expandTabs(src) + "\n")
+
+		offset, width := r.span(src, col, d.Token)
+		out.WriteString(gutter + " " + accent("|") + " " + strings.Repeat(" ", offset) + severityColor(strings.Repeat("^", width)) + "\n")
+	}
+
+	for _, note := range d.Notes {
+		out.WriteString(gutter + " " + accent("=") + bold(" help: ") + note + "\n")
+	}
+
+	return out.String()
+}
+
+// RenderAll Formats diagnostics one after another, separated by empty lines
+func (r *Renderer) RenderAll(ds []Diagnostic) string {
+	var parts []string
+	for _, d := range ds {
+		parts = append(parts, r.Render(d))
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+// Returns display offset of the token in the line and width of the underline
+func (r *Renderer) span(src string, col int, tok token.Token) (int, int) {
+	if col > len(src) {
+		col = len(src)
+	}
+	if col < 0 {
+		col = 0
+	}
+
+	offset := utf8.RuneCountInString(expandTabs(src[:col]))
+
+	width := utf8.RuneCountInString(tok.Literal)
+	switch tok.Type {
+	case token.STRING, token.TEMPLATE:
+		// Literal does not include quotes
+		width += 2
+	case token.NEWLINE, token.EOF:
+		width = 1
+	}
+
+	// Underline only part of the token on the current line
+	rest := utf8.RuneCountInString(expandTabs(src[col:]))
+	if width > rest {
+		width = rest
+	}
+	if width < 1 {
+		width = 1
+	}
+
+	return offset, width
+}
+
+// Returns function that colors the string, or leaves it unchanged if colors are disabled
+func (r *Renderer) paint(attrs ...color.Attribute) func(string) string {
+	if !r.Color {
+		return func(s string) string { return s }
+	}
+
+	c := color.New(attrs...)
+	c.EnableColor()
+
+	return func(s string) string { return c.Sprint(s) }
+}
+
+// Tabs are replaced with spaces, so underline is aligned with the source regardless of the terminal settings
+func expandTabs(s string) string {
+	return strings.ReplaceAll(s, "\t", "    ")
+}
@@ -0,0 +1,78 @@
+package diagnostics
+
+import (
+	"github.com/stretchr/testify/assert"
+	"oilang/internal/token"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	source := "let x = 1\nlet y = x + missing\n"
+	r := NewRenderer("script.oi", source, false)
+
+	d := New(Error, token.Token{Type: token.IDENT, Literal: "missing", Line: 1, Col: 12}, "identifier not found: missing", "declare it with let")
+
+	expected := strings.Join([]string{
+		"error: identifier not found: missing",
+		" --> script.oi:2:13",
+		"  |",
+		"2 | let y = x + missing",
+		"  |             ^^^^^^^",
+		"  = help: declare it with let",
+		"",
+	}, "\n")
+
+	assert.Equal(t, expected, r.Render(d))
+}
+
+func TestRenderSpans(t *testing.T) {
+	tests := []struct {
+		source   string
+		tok      token.Token
+		expected string
+	}{
+		// Tabs are expanded in both source and underline
+		{"\tx", token.Token{Type: token.IDENT, Literal: "x", Col: 1}, "1 |     x\n  |     ^"},
+		// Quotes are not part of the string literal
+		{`f("ab")`, token.Token{Type: token.STRING, Literal: "ab", Col: 2}, "1 | f(\"ab\")\n  |   ^^^^"},
+		// End of input is shown right after the last character
+		{"(1", token.Token{Type: token.EOF, Col: 2}, "1 | (1\n  |   ^"},
+		// Multiline token is underlined only on the first line
+		{"x `a\nb`", token.Token{Type: token.STRING, Literal: "a\nb", Col: 2}, "1 | x `a\n  |   ^^"},
+		// Width is counted in characters, not bytes
+		{"\"é\" x", token.Token{Type: token.IDENT, Literal: "x", Col: 5}, "1 | \"é\" x\n  |     ^"},
+	}
+
+	for _, test := range tests {
+		r := NewRenderer("f", test.source, false)
+		out := r.Render(New(Error, test.tok, "msg"))
+
+		assert.Contains(t, out, test.expected, test.source)
+	}
+}
+
+func TestRenderWarning(t *testing.T) {
+	r := NewRenderer("f", "x", false)
+	out := r.Render(New(Warning, token.Token{Type: token.IDENT, Literal: "x"}, "unused"))
+
+	assert.True(t, strings.HasPrefix(out, "warning: unused\n"))
+}
+
+func TestRenderWithColors(t *testing.T) {
+	r := NewRenderer("f", "x", true)
+	out := r.Render(New(Error, token.Token{Type: token.IDENT, Literal: "x"}, "msg"))
+
+	assert.Contains(t, out, "\x1b[")
+}
+
+func TestRenderAll(t *testing.T) {
+	r := NewRenderer("f", "a b", false)
+	out := r.RenderAll([]Diagnostic{
+		New(Error, token.Token{Type: token.IDENT, Literal: "a"}, "first"),
+		New(Error, token.Token{Type: token.IDENT, Literal: "b", Col: 2}, "second"),
+	})
+
+	assert.Equal(t, 2, strings.Count(out, "-->"))
+	assert.Contains(t, out, "^\n\nerror: second")
+}
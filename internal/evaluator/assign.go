@@ -0,0 +1,146 @@
+package evaluator
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/token"
+)
+
+// Target is evaluated before the value, and compound assignment reads the current value of the target before that,
+// so errors are reported in the order the parts of the expression appear
+func evalAssignExpression(exp *ast.AssignExpression, env *Environment) Object {
+	switch target := exp.Target.(type) {
+	case *ast.Identifier:
+		return evalAssignIdentifier(exp, target, env)
+	case *ast.IndexExpression:
+		return evalAssignIndex(exp, target, env)
+	case *ast.MemberExpression:
+		return evalAssignMember(exp, target, env)
+	}
+
+	return newError(exp.Token, "invalid assignment target")
+}
+
+func evalAssignIdentifier(exp *ast.AssignExpression, ident *ast.Identifier, env *Environment) Object {
+	val := evalAssignedValue(exp, func() Object { return evalIdentifier(ident, env) }, env)
+	if isError(val) {
+		return val
+	}
+
+	if env.Assign(ident.Value, val) {
+		return val
+	}
+
+	// Builtin functions are predefined globals, so assignment replaces them for the whole program
+	if _, ok := builtins[ident.Value]; ok {
+		return env.global().Set(ident.Value, val)
+	}
+
+	return newError(ident.Token, "identifier not found: %s", ident.Value)
+}
+
+func evalAssignIndex(exp *ast.AssignExpression, target *ast.IndexExpression, env *Environment) Object {
+	left := Eval(target.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	index := Eval(target.Index, env)
+	if isError(index) {
+		return index
+	}
+
+	val := evalAssignedValue(exp, func() Object { return evalIndex(target.Token, left, index) }, env)
+	if isError(val) {
+		return val
+	}
+
+	result, added := evalSetIndex(target.Token, left, index, val)
+	if isError(result) {
+		return result
+	}
+
+	return env.guard.allocateElements(target.Token, added, result)
+}
+
+func evalAssignMember(exp *ast.AssignExpression, target *ast.MemberExpression, env *Environment) Object {
+	object := Eval(target.Object, env)
+	if isError(object) {
+		return object
+	}
+
+	val := evalAssignedValue(exp, func() Object { return evalMember(target.Token, object, target.Property.Value) }, env)
+	if isError(val) {
+		return val
+	}
+
+	result, added := evalSetMember(target.Token, object, target.Property.Value, val)
+	if isError(result) {
+		return result
+	}
+
+	return env.guard.allocateElements(target.Token, added, result)
+}
+
+// Returns value that should be stored in the target. Compound assignment combines it with the current value of the target
+func evalAssignedValue(exp *ast.AssignExpression, current func() Object, env *Environment) Object {
+	op, compound := exp.Compound()
+	if !compound {
+		return Eval(exp.Value, env)
+	}
+
+	left := current()
+	if isError(left) {
+		return left
+	}
+
+	right := Eval(exp.Value, env)
+	if isError(right) {
+		return right
+	}
+
+	return env.guard.allocate(op, evalInfixOperator(op, left, right))
+}
+
+// evalSetIndex replaces element of the array or sets value of the hash key.
+// Returns the stored value and the amount of elements it has added to the collection
+func evalSetIndex(tok token.Token, left, index, val Object) (Object, int) {
+	switch left := left.(type) {
+	case *Array:
+		i, err := toIndex(tok, index, len(left.Elements))
+		if err != nil {
+			return err, 0
+		}
+		left.setElement(i, val)
+		return val, 0
+	case *Hash:
+		added, err := setHashPair(tok, left, index, val)
+		if err != nil {
+			return err, 0
+		}
+		return val, pairs(added)
+	}
+
+	return newError(tok, "index assignment not supported: %s", typeOf(left)), 0
+}
+
+// evalSetMember sets value of the hash stored under the name. Exports of the modules could not be changed.
+// Returns the stored value and the amount of elements it has added to the hash
+func evalSetMember(tok token.Token, object Object, name string, val Object) (Object, int) {
+	switch object := object.(type) {
+	case *Hash:
+		return val, pairs(object.Set(&String{Value: name}, val))
+	case *Module:
+		return newError(tok, "cannot assign to export %s of %s", name, object.Inspect()), 0
+	}
+
+	return newError(tok, "member assignment not supported: %s", typeOf(object)), 0
+}
+
+// Returns amount of the pairs added to the hash
+func pairs(added bool) int {
+	if added {
+		return 1
+	}
+
+	return 0
+}
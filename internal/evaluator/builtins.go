@@ -0,0 +1,294 @@
+package evaluator
+
+import (
+	"fmt"
+	"io"
+	"oilang/internal/token"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Functions that are available in any environment
+var builtins = map[string]*Builtin{
+	"print": {Name: "print", Fn: builtinPrint},
+	"len":   {Name: "len", Fn: builtinLen},
+	"type":  {Name: "type", Fn: builtinType, Allocates: true},
+	"str":   {Name: "str", Fn: builtinStr, Allocates: true},
+
+	"sum":    {Name: "sum", Guarded: builtinSum},
+	"push":   {Name: "push", Guarded: builtinPush, Allocates: true},
+	"first":  {Name: "first", Guarded: builtinFirst},
+	"last":   {Name: "last", Guarded: builtinLast},
+	"rest":   {Name: "rest", Guarded: builtinRest, Allocates: true},
+	"keys":   {Name: "keys", Fn: builtinKeys, Allocates: true},
+	"values": {Name: "values", Fn: builtinValues, Allocates: true},
+
+	"parallel": {Name: "parallel", Fn: builtinParallel},
+
+	"range":  {Name: "range", Fn: builtinRange},
+	"lines":  {Name: "lines", Fn: builtinLines},
+	"map":    {Name: "map", HigherOrder: builtinMap},
+	"filter": {Name: "filter", HigherOrder: builtinFilter},
+	"take":   {Name: "take", Guarded: builtinTake},
+	"skip":   {Name: "skip", Guarded: builtinSkip},
+	"chunk":  {Name: "chunk", Guarded: builtinChunk},
+	"window": {Name: "window", Guarded: builtinWindow},
+	"zip":    {Name: "zip", Guarded: builtinZip},
+}
+
+// Modules that are available in any environment
+var builtinModules = map[string]*Module{
+	"stdin": StdinFrom(os.Stdin),
+}
+
+// BuiltinNames Returns names of all builtin functions and modules in alphabetical order
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins)+len(builtinModules))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	for name := range builtinModules {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func checkArgsAmount(tok token.Token, args []Object, expected int) *Error {
+	if len(args) != expected {
+		return newError(tok, "wrong number of arguments: expected %d, got %d", expected, len(args))
+	}
+
+	return nil
+}
+
+// print writes all arguments separated by space to the standard output
+func builtinPrint(_ token.Token, args ...Object) Object {
+	return printTo(os.Stdout, args)
+}
+
+// PrintTo Returns print function that writes to the writer instead of the standard output
+func PrintTo(w io.Writer) *Builtin {
+	return &Builtin{Name: "print", Fn: func(_ token.Token, args ...Object) Object {
+		return printTo(w, args)
+	}}
+}
+
+func printTo(w io.Writer, args []Object) Object {
+	var parts []string
+	for _, a := range args {
+		parts = append(parts, toDisplayString(a))
+	}
+
+	_, _ = fmt.Fprintln(w, strings.Join(parts, " "))
+	return NULL
+}
+
+// len returns amount of characters in the string or elements in the collection
+func builtinLen(tok token.Token, args ...Object) Object {
+	if err := checkArgsAmount(tok, args, 1); err != nil {
+		return err
+	}
+
+	switch arg := args[0].(type) {
+	case *String:
+		return &Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+	case *Array:
+		return &Integer{Value: int64(len(arg.Elements))}
+	case *Hash:
+		return &Integer{Value: int64(arg.Len())}
+	}
+
+	return newError(tok, "argument to len is not supported: %s", args[0].Type())
+}
+
+// type returns name of the argument's type
+func builtinType(tok token.Token, args ...Object) Object {
+	if err := checkArgsAmount(tok, args, 1); err != nil {
+		return err
+	}
+
+	return &String{Value: strings.ToLower(string(args[0].Type()))}
+}
+
+// str converts the argument to string
+func builtinStr(tok token.Token, args ...Object) Object {
+	if err := checkArgsAmount(tok, args, 1); err != nil {
+		return err
+	}
+
+	return &String{Value: toDisplayString(args[0])}
+}
+
+// Checks that there's exactly one argument and it's an array. Stream is collected under the guard
+func arrayArgument(tok token.Token, g *Guard, name string, args []Object) (*Array, *Error) {
+	if err := checkArgsAmount(tok, args, 1); err != nil {
+		return nil, err
+	}
+
+	// Streams are collected, so functions of arrays could end pipelines of streams, e.g. "range(1, 5) -> sum"
+	if stream, ok := args[0].(*Stream); ok {
+		result := collectStream(tok, stream, g)
+		if isError(result) {
+			return nil, result.(*Error)
+		}
+
+		return result.(*Array), nil
+	}
+
+	array, ok := args[0].(*Array)
+	if !ok {
+		return nil, newError(tok, "argument to %s must be ARRAY, got %s", name, args[0].Type())
+	}
+
+	return array, nil
+}
+
+// sum adds all numbers in the array
+func builtinSum(tok token.Token, g *Guard, args ...Object) Object {
+	array, err := arrayArgument(tok, g, "sum", args)
+	if err != nil {
+		return err
+	}
+
+	var result Object = &Integer{Value: 0}
+	for _, e := range array.elements() {
+		if !isNumber(e) {
+			return newError(tok, "unable to sum %s", e.Type())
+		}
+
+		result = evalInfixOperator(token.Token{Type: token.PLUS, Literal: "+", Line: tok.Line, Col: tok.Col}, result, e)
+	}
+
+	return result
+}
+
+// push returns new array with elements appended to the end
+func builtinPush(tok token.Token, g *Guard, args ...Object) Object {
+	if len(args) < 1 {
+		return newError(tok, "wrong number of arguments: expected at least 1, got 0")
+	}
+
+	array, err := arrayArgument(tok, g, "push", args[:1])
+	if err != nil {
+		return err
+	}
+
+	return &Array{Elements: append(array.elements(), args[1:]...)}
+}
+
+// first returns the first element of the array or null if it's empty
+func builtinFirst(tok token.Token, g *Guard, args ...Object) Object {
+	array, err := arrayArgument(tok, g, "first", args)
+	if err != nil {
+		return err
+	}
+
+	if len(array.Elements) == 0 {
+		return NULL
+	}
+
+	return array.element(0)
+}
+
+// last returns the last element of the array or null if it's empty
+func builtinLast(tok token.Token, g *Guard, args ...Object) Object {
+	array, err := arrayArgument(tok, g, "last", args)
+	if err != nil {
+		return err
+	}
+
+	if len(array.Elements) == 0 {
+		return NULL
+	}
+
+	return array.element(len(array.Elements) - 1)
+}
+
+// rest returns new array with all elements except the first one
+func builtinRest(tok token.Token, g *Guard, args ...Object) Object {
+	array, err := arrayArgument(tok, g, "rest", args)
+	if err != nil {
+		return err
+	}
+
+	if len(array.Elements) == 0 {
+		return &Array{}
+	}
+
+	return &Array{Elements: array.slice(1, len(array.Elements))}
+}
+
+// Checks that there's exactly one argument and it's a hash
+func hashArgument(tok token.Token, name string, args []Object) (*Hash, *Error) {
+	if err := checkArgsAmount(tok, args, 1); err != nil {
+		return nil, err
+	}
+
+	hash, ok := args[0].(*Hash)
+	if !ok {
+		return nil, newError(tok, "argument to %s must be HASH, got %s", name, args[0].Type())
+	}
+
+	return hash, nil
+}
+
+// keys returns keys of the hash in the order of insertion
+func builtinKeys(tok token.Token, args ...Object) Object {
+	hash, err := hashArgument(tok, "keys", args)
+	if err != nil {
+		return err
+	}
+
+	elements := make([]Object, 0, hash.Len())
+	for _, pair := range hash.pairs() {
+		elements = append(elements, pair.Key)
+	}
+
+	return &Array{Elements: elements}
+}
+
+// values returns values of the hash in the order of insertion
+func builtinValues(tok token.Token, args ...Object) Object {
+	hash, err := hashArgument(tok, "values", args)
+	if err != nil {
+		return err
+	}
+
+	elements := make([]Object, 0, hash.Len())
+	for _, pair := range hash.pairs() {
+		elements = append(elements, pair.Value)
+	}
+
+	return &Array{Elements: elements}
+}
+
+// parallel returns the value unchanged. Inside of the pipeline it makes the next stage function run on n workers,
+// e.g. "urls -> parallel(8) -> @fn fetch() { ... }". Pipelines that do not run concurrently ignore it
+func builtinParallel(tok token.Token, args ...Object) Object {
+	if err := checkArgsAmount(tok, args, 2); err != nil {
+		return err
+	}
+
+	if _, err := workersArgument(tok, args[1]); err != nil {
+		return err
+	}
+
+	return args[0]
+}
+
+// Checks that amount of workers is a positive integer
+func workersArgument(tok token.Token, arg Object) (int, *Error) {
+	n, ok := arg.(*Integer)
+	if !ok {
+		return 0, newError(tok, "argument to parallel must be INTEGER, got %s", arg.Type())
+	}
+	if n.Value < 1 {
+		return 0, newError(tok, "amount of workers must be positive, got %d", n.Value)
+	}
+
+	return int(n.Value), nil
+}
@@ -0,0 +1,117 @@
+package evaluator
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/token"
+)
+
+func evalCallExpression(call *ast.CallExpression, env *Environment) Object {
+	fn := Eval(call.CalledExpression, env)
+	if isError(fn) {
+		return fn
+	}
+
+	args, err := evalExpressions(call.Arguments, env)
+	if err != nil {
+		return err
+	}
+
+	return applyFunction(call.Token, fn, args, env.guard)
+}
+
+// Evaluates expressions from left to right, stopping on the first error
+func evalExpressions(exps []ast.Expression, env *Environment) ([]Object, *Error) {
+	var result []Object
+
+	for _, e := range exps {
+		val := Eval(e, env)
+		if err, ok := val.(*Error); ok {
+			return nil, err
+		}
+
+		result = append(result, val)
+	}
+
+	return result, nil
+}
+
+// applyFunction calls the function object with supplied arguments under the guard of the caller. Token is used for error reporting
+func applyFunction(tok token.Token, fn Object, args []Object, g *Guard) Object {
+	switch fn := fn.(type) {
+	case *Function:
+		if fn.IsPipelineStage {
+			return newError(tok, "@fn could be called only as pipeline stage")
+		}
+
+		return callFunction(tok, fn, nil, args, g)
+	case *Builtin:
+		result := fn.Call(tok, caller(g), g, args...)
+		if fn.Allocates {
+			return g.allocate(tok, result)
+		}
+
+		return result
+	}
+
+	return newError(tok, "not a function: %s", typeOf(fn))
+}
+
+// Returns caller for builtin functions, which calls functions under the guard
+func caller(g *Guard) Caller {
+	return func(tok token.Token, fn Object, args ...Object) Object {
+		return applyFunction(tok, fn, args, g)
+	}
+}
+
+// Evaluates body of the function with arguments bound to its parameters.
+// Stage functions also get the value passed through pipeline as "@"
+func callFunction(tok token.Token, fn *Function, val Object, args []Object, g *Guard) Object {
+	if err := checkArguments(tok, fn, args); err != nil {
+		return err
+	}
+
+	if err := g.enter(tok); err != nil {
+		return err
+	}
+	defer g.leave()
+
+	env := NewEnclosedEnvironment(fn.Env)
+	env.guard = g
+	// Parameters with the same name hide the function
+	if fn.IsSelfBound {
+		env.Set(fn.Name.Value, fn)
+	}
+	if fn.IsPipelineStage {
+		env.Set(PIPELINE_CONTEXT, val)
+	}
+	for i, param := range fn.Parameters {
+		env.Set(param.Value, args[i])
+	}
+
+	result := evalBlockStatement(fn.Body, env)
+	if control, ok := result.(*LoopControl); ok {
+		return loopControlError(control)
+	}
+
+	return unwrapReturnValue(result)
+}
+
+func checkArguments(tok token.Token, fn *Function, args []Object) *Error {
+	if len(args) != len(fn.Parameters) {
+		return newError(tok, "wrong number of arguments: expected %d, got %d", len(fn.Parameters), len(args))
+	}
+
+	return nil
+}
+
+func unwrapReturnValue(obj Object) Object {
+	if obj == nil {
+		return NULL
+	}
+
+	if ret, ok := obj.(*ReturnValue); ok {
+		return ret.Value
+	}
+
+	return obj
+}
@@ -0,0 +1,192 @@
+package evaluator
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/token"
+	"unicode/utf8"
+)
+
+func evalArrayLiteral(array *ast.ArrayLiteral, env *Environment) Object {
+	elements, err := evalExpressions(array.Elements, env)
+	if err != nil {
+		return err
+	}
+
+	return env.guard.allocate(array.Token, &Array{Elements: elements})
+}
+
+func evalHashLiteral(hash *ast.HashLiteral, env *Environment) Object {
+	result := NewHash()
+
+	for _, pair := range hash.Pairs {
+		key := Eval(pair.Key, env)
+		if isError(key) {
+			return key
+		}
+
+		val := Eval(pair.Value, env)
+		if isError(val) {
+			return val
+		}
+
+		if _, err := setHashPair(hash.Token, result, key, val); err != nil {
+			return err
+		}
+	}
+
+	return env.guard.allocate(hash.Token, result)
+}
+
+// Adds the pair to the hash, checking that the key could be used for it. Returns true if the pair is added
+func setHashPair(tok token.Token, hash *Hash, key, val Object) (bool, *Error) {
+	hashable, ok := key.(Hashable)
+	if !ok {
+		return false, newError(tok, "unusable as hash key: %s", typeOf(key))
+	}
+
+	return hash.Set(hashable, val), nil
+}
+
+func evalIndexExpression(exp *ast.IndexExpression, env *Environment) Object {
+	left := Eval(exp.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	index := Eval(exp.Index, env)
+	if isError(index) {
+		return index
+	}
+
+	return evalIndex(exp.Token, left, index)
+}
+
+// evalIndex returns element of the collection. Negative indices of arrays and strings are counted from the end
+func evalIndex(tok token.Token, left, index Object) Object {
+	switch left := left.(type) {
+	case *Array:
+		i, err := toIndex(tok, index, len(left.Elements))
+		if err != nil {
+			return err
+		}
+		return left.element(i)
+	case *String:
+		runes := []rune(left.Value)
+		i, err := toIndex(tok, index, len(runes))
+		if err != nil {
+			return err
+		}
+		return &String{Value: string(runes[i])}
+	case *Hash:
+		key, ok := index.(Hashable)
+		if !ok {
+			return newError(tok, "unusable as hash key: %s", typeOf(index))
+		}
+		if val, ok := left.Get(key); ok {
+			return val
+		}
+		return NULL
+	}
+
+	return newError(tok, "index operator not supported: %s", typeOf(left))
+}
+
+// Converts index object to position in the collection of the supplied length
+func toIndex(tok token.Token, index Object, length int) (int, *Error) {
+	i, ok := index.(*Integer)
+	if !ok {
+		return 0, newError(tok, "index must be an integer, got %s", typeOf(index))
+	}
+
+	pos := i.Value
+	if pos < 0 {
+		pos += int64(length)
+	}
+
+	if pos < 0 || pos >= int64(length) {
+		return 0, newError(tok, "index out of range: %d with length %d", i.Value, length)
+	}
+
+	return int(pos), nil
+}
+
+func evalSliceExpression(exp *ast.SliceExpression, env *Environment) Object {
+	left := Eval(exp.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	var bounds [2]Object
+	for i, node := range []ast.Expression{exp.Low, exp.High} {
+		if node == nil {
+			continue
+		}
+
+		bounds[i] = Eval(node, env)
+		if isError(bounds[i]) {
+			return bounds[i]
+		}
+	}
+
+	return env.guard.allocate(exp.Token, evalSlice(exp.Token, left, bounds[0], bounds[1]))
+}
+
+// evalSlice takes part of the array or string between the bounds. Bounds are nil if they're omitted
+func evalSlice(tok token.Token, left, lowBound, highBound Object) Object {
+	var length int
+	switch left := left.(type) {
+	case *Array:
+		length = len(left.Elements)
+	case *String:
+		length = utf8.RuneCountInString(left.Value)
+	default:
+		return newError(tok, "slice operator not supported: %s", typeOf(left))
+	}
+
+	low, err := toSliceBound(tok, lowBound, 0, length)
+	if err != nil {
+		return err
+	}
+
+	high, err := toSliceBound(tok, highBound, length, length)
+	if err != nil {
+		return err
+	}
+
+	if high < low {
+		high = low
+	}
+
+	if array, ok := left.(*Array); ok {
+		return &Array{Elements: array.slice(low, high)}
+	}
+
+	return &String{Value: string([]rune(left.(*String).Value)[low:high])}
+}
+
+// Converts bound of the slice to position. Missing bound is replaced with default value,
+// negative bound is counted from the end and bound outside the collection is clamped to its length
+func toSliceBound(tok token.Token, val Object, def, length int) (int, *Error) {
+	if val == nil {
+		return def, nil
+	}
+
+	i, ok := val.(*Integer)
+	if !ok {
+		return 0, newError(tok, "slice bound must be an integer, got %s", typeOf(val))
+	}
+
+	bound := i.Value
+	if bound < 0 {
+		bound += int64(length)
+	}
+
+	if bound < 0 {
+		return 0, nil
+	}
+	if bound > int64(length) {
+		return length, nil
+	}
+
+	return int(bound), nil
+}
@@ -0,0 +1,359 @@
+package evaluator
+
+import (
+	"context"
+	"oilang/internal/ast"
+	"oilang/internal/token"
+	"sync"
+)
+
+// Amount of elements that could wait between two stages of concurrent pipeline.
+// Stage that gets ahead of the next one blocks once the buffer is full
+const STAGE_BUFFER = 16
+
+// Stage function the values are streamed through, resolved before the elements start to flow
+type streamStage struct {
+	tok  token.Token
+	fn   *Function
+	args []Object
+	// Amount of goroutines that call the function, set by parallel annotation
+	workers int
+}
+
+// Value on its way through the stages. Index keeps its place in the resulting array
+type streamItem struct {
+	index int
+	val   Object
+}
+
+// Stage of the pipeline that is resolved without evaluating anything but names and function literals
+type plannedStage struct {
+	tok  token.Token
+	call *ast.CallExpression
+	fn   Object
+	// Tells if the stage is a parallel annotation for the next one, rather than a stage function
+	annotation bool
+}
+
+// Finds stage functions at the start of the stages, which could stream the array or stream concurrently.
+// The first stage could use "@" in arguments, the next ones could not, because they get values before
+// the previous stage has finished all of them. Any other stage ends the run
+func planStream(stages []ast.Expression, tok token.Token, val Object, env *Environment) []plannedStage {
+	stageEnv := NewEnclosedEnvironment(env)
+	stageEnv.Set(PIPELINE_CONTEXT, val)
+
+	var planned []plannedStage
+	for _, stage := range stages {
+		p := plannedStage{tok: stageToken(stage, tok)}
+
+		switch stage := stage.(type) {
+		case *ast.FunctionLiteral:
+			p.fn = Eval(stage, stageEnv)
+		case *ast.Identifier:
+			p.fn = Eval(stage, stageEnv)
+		case *ast.CallExpression:
+			callee, ok := stage.CalledExpression.(*ast.Identifier)
+			if !ok || len(planned) > 0 && referencesContext(stage) {
+				return planned
+			}
+
+			p.call = stage
+			p.fn = Eval(callee, stageEnv)
+			p.annotation = p.fn == builtins["parallel"]
+		}
+
+		if !p.annotation && !isStageFunction(p.fn) {
+			return planned
+		}
+
+		planned = append(planned, p)
+	}
+
+	return planned
+}
+
+// Tells if the planned stages should run concurrently, which they do in concurrent mode or when any is annotated
+func runsConcurrently(planned []plannedStage, env *Environment) bool {
+	if env.concurrent {
+		return true
+	}
+
+	for _, p := range planned {
+		if p.annotation {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Evaluates arguments of the planned stages and attaches parallel annotations to the stages they precede
+func resolveStream(planned []plannedStage, val Object, env *Environment) ([]streamStage, Object) {
+	stageEnv := NewEnclosedEnvironment(env)
+	stageEnv.Set(PIPELINE_CONTEXT, val)
+
+	stages := make([]streamStage, 0, len(planned))
+	workers := 0
+	for _, p := range planned {
+		var args []Object
+		if p.call != nil {
+			var err *Error
+			if args, err = evalExpressions(p.call.Arguments, stageEnv); err != nil {
+				return nil, err
+			}
+		}
+
+		if p.annotation {
+			if result := applyFunction(p.tok, p.fn, append([]Object{val}, args...), env.guard); isError(result) {
+				return nil, result
+			}
+
+			workers, _ = workersArgument(p.tok, args[0])
+			continue
+		}
+
+		fn := p.fn.(*Function)
+		if err := checkArguments(p.tok, fn, args); err != nil {
+			return nil, err
+		}
+
+		stages = append(stages, streamStage{tok: p.tok, fn: fn, args: args, workers: workers})
+		workers = 0
+	}
+
+	return stages, nil
+}
+
+// Pipeline that streams values through stage functions running in their own goroutines.
+// The first error cancels the whole pipeline
+type concurrentPipeline struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	guard  *Guard
+	wg     sync.WaitGroup
+
+	once sync.Once
+	err  Object
+}
+
+// Streams elements of the array or values of the stream through the stages. Array gives the same array that
+// sequential pipeline returns. Stream gives a stream, so the stages run only as far ahead as their buffers allow
+func streamConcurrently(tok token.Token, stages []streamStage, source Object, env *Environment) Object {
+	ctx, cancel := context.WithCancel(env.guard.context())
+	p := &concurrentPipeline{ctx: ctx, cancel: cancel, guard: env.guard}
+
+	array, isArray := source.(*Array)
+	size := -1
+	if isArray {
+		size = len(array.Elements)
+	}
+
+	in := p.source(tok, source)
+	for _, stage := range stages {
+		in = p.runStage(stage, in, size)
+	}
+
+	if !isArray {
+		return &Stream{
+			next: func() Object {
+				if item, ok := <-in; ok {
+					return item.val
+				}
+
+				return p.wait(tok)
+			},
+			stop: func() {
+				cancel()
+				p.wg.Wait()
+			},
+		}
+	}
+
+	defer cancel()
+
+	elements := make([]Object, len(array.Elements))
+	for item := range in {
+		elements[item.index] = item.val
+	}
+	if err := p.wait(tok); err != nil {
+		return err
+	}
+
+	return env.guard.allocate(tok, &Array{Elements: elements})
+}
+
+// Starts goroutine that sends elements of the array or values of the stream to the first stage.
+// It's not waited for, because the stream could block it until its next value arrives, e.g. the next line of stdin
+func (p *concurrentPipeline) source(tok token.Token, source Object) <-chan streamItem {
+	out := make(chan streamItem, STAGE_BUFFER)
+
+	s, _ := toStream(tok, "", source)
+	go func() {
+		defer close(out)
+		defer s.Stop()
+
+		for i := 0; ; i++ {
+			val := s.Next()
+			if val == nil {
+				return
+			}
+			if isError(val) {
+				p.fail(val)
+				return
+			}
+
+			if !send(p.ctx, out, streamItem{index: i, val: val}) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Waits for the stages to finish and returns the error that has stopped the pipeline, nil if it has finished
+func (p *concurrentPipeline) wait(tok token.Token) Object {
+	p.wg.Wait()
+	if p.err != nil {
+		return p.err
+	}
+
+	// Stages could stop on cancellation without reporting it, e.g. when they have nothing to run
+	if err := p.guard.step(tok); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Starts goroutines of the stage and returns the channel of its results, which is closed when the stage is done.
+// Elements that parallel workers finish out of order are passed on in order
+func (p *concurrentPipeline) runStage(stage streamStage, in <-chan streamItem, size int) <-chan streamItem {
+	out := make(chan streamItem, STAGE_BUFFER)
+
+	workers := stage.workers
+	if size >= 0 && workers > size {
+		workers = size
+	}
+	if workers <= 1 {
+		p.spawn(func() {
+			defer close(out)
+			guard := p.guard.fork(p.ctx)
+			for {
+				item, ok := receive(p.ctx, in)
+				if !ok {
+					return
+				}
+
+				result, ok := p.call(stage, item, guard)
+				if !ok || !send(p.ctx, out, result) {
+					return
+				}
+			}
+		})
+
+		return out
+	}
+
+	type job struct {
+		item   streamItem
+		result chan streamItem
+	}
+
+	// Results are queued in the order of elements, so the queue also limits amount of elements in flight
+	queue := make(chan chan streamItem, STAGE_BUFFER)
+	jobs := make(chan job)
+	p.spawn(func() {
+		defer close(queue)
+		defer close(jobs)
+		for {
+			item, ok := receive(p.ctx, in)
+			if !ok {
+				return
+			}
+
+			j := job{item: item, result: make(chan streamItem, 1)}
+			if !send(p.ctx, queue, j.result) || !send(p.ctx, jobs, j) {
+				return
+			}
+		}
+	})
+
+	for i := 0; i < workers; i++ {
+		p.spawn(func() {
+			guard := p.guard.fork(p.ctx)
+			for j := range jobs {
+				result, ok := p.call(stage, j.item, guard)
+				if !ok {
+					return
+				}
+
+				j.result <- result
+			}
+		})
+	}
+
+	p.spawn(func() {
+		defer close(out)
+		for result := range queue {
+			select {
+			case item := <-result:
+				if !send(p.ctx, out, item) {
+					return
+				}
+			case <-p.ctx.Done():
+				return
+			}
+		}
+	})
+
+	return out
+}
+
+// Calls the stage function with the element. Returns false if the call has failed the pipeline
+func (p *concurrentPipeline) call(stage streamStage, item streamItem, guard *Guard) (streamItem, bool) {
+	result := callFunction(stage.tok, stage.fn, item.val, stage.args, guard)
+	if isError(result) {
+		p.fail(result)
+		return streamItem{}, false
+	}
+
+	return streamItem{index: item.index, val: result}, true
+}
+
+// Sends the value unless the pipeline is cancelled. Returns false if it is
+func send[T any](ctx context.Context, ch chan<- T, val T) bool {
+	select {
+	case ch <- val:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Receives the value unless the pipeline is cancelled. Returns false if it is or the channel is closed
+func receive[T any](ctx context.Context, ch <-chan T) (T, bool) {
+	select {
+	case val, ok := <-ch:
+		return val, ok
+	case <-ctx.Done():
+		var zero T
+		return zero, false
+	}
+}
+
+// Stops the pipeline with the error, unless it has already failed
+func (p *concurrentPipeline) fail(err Object) {
+	p.once.Do(func() {
+		p.err = err
+		p.cancel()
+	})
+}
+
+func (p *concurrentPipeline) spawn(fn func()) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		fn()
+	}()
+}
@@ -0,0 +1,157 @@
+package evaluator
+
+import (
+	"sort"
+	"sync"
+)
+
+// Environment stores values bound to the names in the current scope.
+// Stages of concurrent pipelines could share environments, so access to the names is synchronized
+type Environment struct {
+	mu    sync.RWMutex
+	store map[string]Object
+	outer *Environment
+
+	// Loads modules for import statements, nil if imports are not available
+	importer Importer
+	// File the code of the environment comes from, imports are resolved relative to it
+	file string
+	// Stops the code that runs in the environment, nil if it runs without limits
+	guard *Guard
+	// Tells if arrays are streamed through the stages of pipelines concurrently
+	concurrent bool
+	// Builtin functions and modules replaced by the host, e.g. print that writes to the buffer
+	builtins map[string]Object
+}
+
+// NewEnvironment Creates new top-level environment
+func NewEnvironment() *Environment {
+	return &Environment{store: make(map[string]Object)}
+}
+
+// NewEnclosedEnvironment Creates new environment that can access names from the outer one
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	env.importer = outer.importer
+	env.file = outer.file
+	env.guard = outer.guard
+	env.concurrent = outer.concurrent
+	env.builtins = outer.builtins
+
+	return env
+}
+
+// SetImporter Allows import statements in the environment. File is the path of the code that is evaluated,
+// empty for the code that does not come from a file (e.g. REPL input), so imports are resolved relative to the working directory
+func (e *Environment) SetImporter(importer Importer, file string) {
+	e.importer = importer
+	e.file = file
+}
+
+// SetGuard Makes the code that runs in the environment stop on cancellation of the guard's context or when it exceeds the limits
+func (e *Environment) SetGuard(guard *Guard) {
+	e.guard = guard
+}
+
+// SetConcurrent Makes pipelines run each stage function in its own goroutine when arrays are streamed through them.
+// Stages still see the elements in order. Each read and change of the collections they share is synchronized,
+// but compound assignment such as h[k] += 1 is not atomic
+func (e *Environment) SetConcurrent(concurrent bool) {
+	e.concurrent = concurrent
+}
+
+// SetBuiltin Replaces the builtin function or module for the code of the environment and the modules it imports,
+// e.g. to make print write to the buffer. It should be called before the environment is enclosed by the other ones
+func (e *Environment) SetBuiltin(name string, builtin Object) {
+	if e.builtins == nil {
+		e.builtins = make(map[string]Object)
+	}
+
+	e.builtins[name] = builtin
+}
+
+// File Returns path of the code of the environment, empty if it does not come from a file
+func (e *Environment) File() string {
+	return e.file
+}
+
+// NewModuleEnvironment Creates top-level environment for the module imported by the code of this one.
+// Module runs under the same guard, in the same mode of pipelines and with the same builtins as the code that imports it
+func (e *Environment) NewModuleEnvironment() *Environment {
+	env := NewEnvironment()
+	env.guard = e.guard
+	env.concurrent = e.concurrent
+	env.builtins = e.builtins
+
+	return env
+}
+
+// Get Looks up the name in the current scope and then in the outer ones
+func (e *Environment) Get(name string) (Object, bool) {
+	e.mu.RLock()
+	obj, ok := e.store[name]
+	e.mu.RUnlock()
+	if !ok && e.outer != nil {
+		return e.outer.Get(name)
+	}
+
+	return obj, ok
+}
+
+// Set Binds the value to the name in the current scope
+func (e *Environment) Set(name string, val Object) Object {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.store[name] = val
+	return val
+}
+
+// Assign Changes the value of the name in the scope it's bound in. Returns false if the name is not bound
+func (e *Environment) Assign(name string, val Object) bool {
+	for env := e; env != nil; env = env.outer {
+		if env.assign(name, val) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Changes the value of the name if it's bound in this scope
+func (e *Environment) assign(name string, val Object) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.store[name]; !ok {
+		return false
+	}
+
+	e.store[name] = val
+	return true
+}
+
+// Returns the top-level environment
+func (e *Environment) global() *Environment {
+	env := e
+	for env.outer != nil {
+		env = env.outer
+	}
+
+	return env
+}
+
+// Names Returns names bound in the current scope, without the outer ones
+func (e *Environment) Names() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	names := make([]string, 0, len(e.store))
+	for name := range e.store {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
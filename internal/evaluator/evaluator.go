@@ -0,0 +1,216 @@
+package evaluator
+
+import (
+	"fmt"
+	"oilang/internal/ast"
+	"oilang/internal/token"
+)
+
+// Values that exist in a single instance, so they could be compared by pointer
+var (
+	NULL  = &Null{}
+	TRUE  = &Boolean{Value: true}
+	FALSE = &Boolean{Value: false}
+)
+
+// Eval Evaluates the node in the supplied environment.
+//
+// Returns nil for the nodes that do not produce any value (e.g. let statement)
+func Eval(node ast.Node, env *Environment) Object {
+	switch node := node.(type) {
+	// Statements
+	case *ast.Program:
+		return evalProgram(node, env)
+	case *ast.BlockStatement:
+		return evalBlockStatement(node, env)
+	case *ast.ExpressionStatement:
+		return evalExpressionStatement(node, env)
+	case *ast.LetStatement:
+		return evalLetStatement(node, env)
+	case *ast.ReturnStatement:
+		return evalReturnStatement(node, env)
+	case *ast.ImportStatement:
+		return evalImportStatement(node, env)
+	case *ast.ExportStatement:
+		return Eval(node.Statement, env)
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env)
+	case *ast.ForStatement:
+		return evalForStatement(node, env)
+	case *ast.BreakStatement:
+		return evalBreakStatement(node)
+	case *ast.ContinueStatement:
+		return evalContinueStatement(node)
+
+	// Expressions
+	case *ast.IntegerLiteral:
+		return &Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &Float{Value: node.Value}
+	case *ast.StringLiteral:
+		return &String{Value: node.Value}
+	case *ast.TemplateLiteral:
+		return evalTemplateLiteral(node, env)
+	case *ast.ArrayLiteral:
+		return evalArrayLiteral(node, env)
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, env)
+	case *ast.IndexExpression:
+		return evalIndexExpression(node, env)
+	case *ast.SliceExpression:
+		return evalSliceExpression(node, env)
+	case *ast.MemberExpression:
+		return evalMemberExpression(node, env)
+	case *ast.BoolExpression:
+		return nativeBoolToObject(node.Value)
+	case *ast.Identifier:
+		return evalIdentifier(node, env)
+	case *ast.PrefixExpression:
+		return evalPrefixExpression(node, env)
+	case *ast.InfixExpression:
+		return evalInfixExpression(node, env)
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+	case *ast.IfExpression:
+		return evalIfExpression(node, env)
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
+	case *ast.FunctionLiteral:
+		return evalFunctionLiteral(node, env)
+	case *ast.CallExpression:
+		return evalCallExpression(node, env)
+	case *ast.PipelineExpression:
+		return evalPipelineExpression(node, env)
+	}
+
+	return newError(token.Token{}, "unable to evaluate %T", node)
+}
+
+func evalProgram(program *ast.Program, env *Environment) Object {
+	var result Object
+
+	for _, stmt := range program.Statements {
+		if err := env.guard.step(statementToken(stmt)); err != nil {
+			return err
+		}
+
+		result = Eval(stmt, env)
+
+		switch result := result.(type) {
+		case *ReturnValue:
+			return result.Value
+		case *LoopControl:
+			return loopControlError(result)
+		case *Error:
+			return result
+		}
+	}
+
+	return result
+}
+
+// Unlike the program, block does not unwrap return value, so it's propagated up to the function call.
+// Break and continue are propagated up to their loop the same way
+func evalBlockStatement(block *ast.BlockStatement, env *Environment) Object {
+	var result Object
+
+	for _, stmt := range block.Statements {
+		if err := env.guard.step(statementToken(stmt)); err != nil {
+			return err
+		}
+
+		result = Eval(stmt, env)
+
+		if result != nil && (result.Type() == RETURN_OBJ || result.Type() == ERROR_OBJ || result.Type() == LOOP_CONTROL_OBJ) {
+			return result
+		}
+	}
+
+	return result
+}
+
+func evalExpressionStatement(stmt *ast.ExpressionStatement, env *Environment) Object {
+	// Named function on its own is a declaration, so it's bound to its name
+	if fn, ok := stmt.Expression.(*ast.FunctionLiteral); ok && fn.Name != nil {
+		evalFunctionDeclaration(fn, env)
+		return nil
+	}
+
+	return Eval(stmt.Expression, env)
+}
+
+func evalLetStatement(stmt *ast.LetStatement, env *Environment) Object {
+	val := Eval(stmt.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	env.Set(stmt.Name.Value, val)
+	return nil
+}
+
+func evalReturnStatement(stmt *ast.ReturnStatement, env *Environment) Object {
+	if stmt.ReturnValue == nil {
+		return &ReturnValue{Value: NULL}
+	}
+
+	val := Eval(stmt.ReturnValue, env)
+	if isError(val) {
+		return val
+	}
+
+	return &ReturnValue{Value: val}
+}
+
+func evalIdentifier(ident *ast.Identifier, env *Environment) Object {
+	if val, ok := env.Get(ident.Value); ok {
+		return val
+	}
+
+	if builtin, ok := env.builtins[ident.Value]; ok {
+		return builtin
+	}
+	if builtin, ok := lookupBuiltin(ident.Value); ok {
+		return builtin
+	}
+
+	return newError(ident.Token, "identifier not found: %s", ident.Value)
+}
+
+// Returns builtin function or module with the name
+func lookupBuiltin(name string) (Object, bool) {
+	if builtin, ok := builtins[name]; ok {
+		return builtin, true
+	}
+	if module, ok := builtinModules[name]; ok {
+		return module, true
+	}
+
+	return nil, false
+}
+
+func newError(tok token.Token, format string, a ...any) *Error {
+	return &Error{Message: fmt.Sprintf(format, a...), Token: tok}
+}
+
+func isError(obj Object) bool {
+	return obj != nil && obj.Type() == ERROR_OBJ
+}
+
+func nativeBoolToObject(v bool) *Boolean {
+	if v {
+		return TRUE
+	}
+
+	return FALSE
+}
+
+// Only false and null are considered false, any other value is true
+func isTruthy(obj Object) bool {
+	switch obj {
+	case NULL, FALSE:
+		return false
+	}
+
+	return true
+}
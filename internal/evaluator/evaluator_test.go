@@ -0,0 +1,574 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"oilang/internal/lexer"
+	"oilang/internal/parser"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func testEval(t *testing.T, input string) Object {
+	program, err := parser.New(lexer.New(input)).Parse()
+	assert.Nil(t, err)
+	assert.NotNil(t, program)
+
+	return Eval(program, NewEnvironment())
+}
+
+func testInspect(t *testing.T, tests []struct {
+	input    string
+	expected string
+}) {
+	for _, test := range tests {
+		result := testEval(t, test.input)
+
+		assert.NotNil(t, result, test.input)
+		assert.Equal(t, test.expected, result.Inspect(), test.input)
+	}
+}
+
+func TestIntegerArithmetic(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"5", "5"},
+		{"-10", "-10"},
+		{"5 + 5 * 2", "15"},
+		{"(5 + 5) * 2", "20"},
+		{"2 ** 10", "1024"},
+		{"7 / 2", "3"},
+		{"2 ** -1", "0.5"},
+		{"-(1 - 4) * 3", "9"},
+	})
+}
+
+func TestFloatArithmetic(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"1.5", "1.5"},
+		{"5.0", "5.0"},
+		{"1.5 + 1", "2.5"},
+		{"7 / 2.0", "3.5"},
+		{"2 * 0.25", "0.5"},
+		{"-1.5", "-1.5"},
+	})
+}
+
+func TestBooleanExpressions(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"true", "true"},
+		{"not true", "false"},
+		{"not 5", "false"},
+		{"1 < 2", "true"},
+		{"1.5 >= 2", "false"},
+		{"2 == 2.0", "true"},
+		{"1 != 1", "false"},
+		{"true == false", "false"},
+		{"true and false", "false"},
+		{"false or 1", "true"},
+		{"1 > 2 or 2 > 1", "true"},
+		// Right side is not evaluated, so missing identifier is not an error
+		{"false and missing", "false"},
+		{"true or missing", "true"},
+	})
+}
+
+func TestIfExpressions(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"if true { 10 }", "10"},
+		{"if false { 10 }", "null"},
+		{"if 1 > 2 { 10 } else { 20 }", "20"},
+		{"if 1 < 2 { 10 } else { 20 }", "10"},
+		{"if 0 { 1 }", "1"},
+	})
+}
+
+func TestLetAndReturnStatements(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"let a = 5\na", "5"},
+		{"let a = 5\nlet b = a * 2\nb + a", "15"},
+		{"return 10\n20", "10"},
+		{"if true { if true { return 1 }\n return 2 }", "1"},
+	})
+}
+
+func TestFunctions(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"fn (x) { x * 2 }(4)", "8"},
+		{"let add = fn (x, y) { return x + y }\nadd(1, add(2, 3))", "6"},
+		{"fn double(x) { x * 2 }\ndouble(21)", "42"},
+		{"fn fact(n) { if n <= 1 { return 1 }\n n * fact(n - 1) }\nfact(5)", "120"},
+		{"fn () { }()", "null"},
+		{"fn (x, y) { x + y }", "fn (x, y)"},
+		{"@fn (x) { x }", "@fn (x)"},
+		{"let f = fn named() { 1 }\nf", "fn named()"},
+	})
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"missing", "identifier not found: missing"},
+		{"1 + true", "type mismatch: INTEGER + BOOLEAN"},
+		{"true + false", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"-true", "unknown operator: -BOOLEAN"},
+		{"1 / 0", "division by zero"},
+		{"5(1)", "not a function: INTEGER"},
+		{"fn (x) { x }(1, 2)", "wrong number of arguments: expected 1, got 2"},
+		{"let x = 1 + true\nx", "type mismatch: INTEGER + BOOLEAN"},
+		{"if true { 1 + true\n 10 }", "type mismatch: INTEGER + BOOLEAN"},
+		{`import "./a.oi" as a`, "imports are not available"},
+		{"break", "break outside of loop"},
+		{"fn f() { continue }; while true { f() }", "continue outside of loop"},
+		{"outer: while true { while true { break inner } }", "unknown label inner"},
+		{"let n = 1\nn.x", "member access not supported: INTEGER"},
+		{"undefined = 1", "identifier not found: undefined"},
+		{"let xs = [1]\nxs[true] = 1", "index must be an integer, got BOOLEAN"},
+		{"let h = {}\nh[[1]] = 1", "unusable as hash key: ARRAY"},
+	}
+
+	for _, test := range tests {
+		result := testEval(t, test.input)
+
+		err, ok := result.(*Error)
+		assert.Truef(t, ok, "expected error for %q, got %v", test.input, result)
+		if ok {
+			assert.Equal(t, test.message, err.Message)
+		}
+	}
+}
+
+func TestErrorPosition(t *testing.T) {
+	result := testEval(t, "let a = 1\na + missing")
+
+	err, ok := result.(*Error)
+	assert.True(t, ok)
+	assert.Equal(t, 1, err.Token.Line)
+	assert.Equal(t, 4, err.Token.Col)
+}
+
+func TestPipelines(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"2 -> @ * 10", "20"},
+		{"fn inc(x) { x + 1 }\n1 -> inc -> inc", "3"},
+		{"fn add(x, y) { x + y }\n1 -> add(10)", "11"},
+		{"fn add(x, y) { x + y }\n1 -> add(10, @)", "11"},
+		{"3 -> @fn double() { @ * 2 } -> @ + 1", "7"},
+		{"let x = 5\nx\n  -> @ * @\n  -> @ - x", "20"},
+		{"1 -> fn (x) { x -> @ + 1 }", "2"},
+	})
+}
+
+func TestPipelineErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"@", "identifier not found: @"},
+		{"1 -> missing(@)", "identifier not found: missing"},
+		{"1 -> @ + true -> @ * 2", "type mismatch: INTEGER + BOOLEAN"},
+		{"fn f() { 1 }\n1 -> f", "wrong number of arguments: expected 0, got 1"},
+		{"@fn f() { @ }\nf()", "@fn could be called only as pipeline stage"},
+		{"@fn f(x) { x }\n1 -> f", "wrong number of arguments: expected 1, got 0"},
+	}
+
+	for _, test := range tests {
+		result := testEval(t, test.input)
+
+		err, ok := result.(*Error)
+		assert.Truef(t, ok, "expected error for %q, got %v", test.input, result)
+		if ok {
+			assert.Equal(t, test.message, err.Message)
+		}
+	}
+}
+
+func TestStrings(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{`"hello"`, `"hello"`},
+		{`"foo" + "bar"`, `"foobar"`},
+		{`"a" < "b"`, "true"},
+		{`"a" == "a"`, "true"},
+		{`"a" != "a"`, "false"},
+		{`let name = "oi"` + "\n" + `"Hello, ${name}!"`, `"Hello, oi!"`},
+		{`"${1 + 2} ${true} ${"nested"}"`, `"3 true nested"`},
+		{`len("héllo")`, "5"},
+		{`type(1.5)`, `"float"`},
+		{`str(12) + "!"`, `"12!"`},
+		{`"oi" -> "${@}!"`, `"oi!"`},
+	})
+}
+
+func TestStringErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{`"a" - "b"`, "unknown operator: STRING - STRING"},
+		{`"a" + 1`, "type mismatch: STRING + INTEGER"},
+		{`"${missing}"`, "identifier not found: missing"},
+		{`len(1)`, "argument to len is not supported: INTEGER"},
+	}
+
+	for _, test := range tests {
+		result := testEval(t, test.input)
+
+		err, ok := result.(*Error)
+		assert.Truef(t, ok, "expected error for %q, got %v", test.input, result)
+		if ok {
+			assert.Equal(t, test.message, err.Message)
+		}
+	}
+}
+
+func TestCollections(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2 * 2, \"three\"]", `[1, 4, "three"]`},
+		{"[]", "[]"},
+		{"[1, 2, 3][1]", "2"},
+		{"[1, 2, 3][-1]", "3"},
+		{"let xs = [1, 2, 3, 4]\nxs[1:3]", "[2, 3]"},
+		{"[1, 2, 3][:-1]", "[1, 2]"},
+		{"[1, 2, 3][2:]", "[3]"},
+		{"[1, 2, 3][5:]", "[]"},
+		{`"hello"[1:-1]`, `"ell"`},
+		{`"héllo"[1]`, `"é"`},
+		{`{ name: "oi", 1: true, true: 2.5 }`, `{"name": "oi", 1: true, true: 2.5}`},
+		{`{ name: "oi" }["name"]`, `"oi"`},
+		{`{ name: "oi" }["missing"]`, "null"},
+		{`let key = "k"` + "\n" + `{ "${key}1": 1 }["k1"]`, "1"},
+		{`{ b: 1, a: 2, b: 3 }`, `{"b": 3, "a": 2}`},
+		{"len([1, 2])", "2"},
+		{"len({ a: 1 })", "1"},
+		{"sum([1, 2, 3.5])", "6.5"},
+		{"push([1], 2, 3)", "[1, 2, 3]"},
+		{"first([1, 2])", "1"},
+		{"last([1, 2])", "2"},
+		{"rest([1, 2, 3])", "[2, 3]"},
+		{"keys({ a: 1, b: 2 })", `["a", "b"]`},
+		{"values({ a: 1, b: 2 })", "[1, 2]"},
+	})
+}
+
+func TestHashKeys(t *testing.T) {
+	h := NewHash()
+	assert.True(t, h.Set(&String{Value: "a"}, &Integer{Value: 1}))
+	assert.True(t, h.Set(&String{Value: "b"}, &Integer{Value: 2}))
+	assert.True(t, h.Set(&Integer{Value: 1}, &Integer{Value: 3}))
+	assert.False(t, h.Set(&String{Value: "a"}, &Integer{Value: 4}))
+
+	// Keys are compared by their values, not by a digest of them
+	assert.Equal(t, HashKey{Type: STRING_OBJ, Text: "a"}, (&String{Value: "a"}).HashKey())
+	assert.NotEqual(t, (&String{Value: "a"}).HashKey(), (&String{Value: "b"}).HashKey())
+	val, ok := h.Get(&String{Value: "a"})
+	assert.True(t, ok)
+	assert.Equal(t, &Integer{Value: 4}, val)
+	_, ok = h.Get(&String{Value: "1"})
+	assert.False(t, ok)
+	assert.Equal(t, 3, h.Len())
+}
+
+func TestCollectionErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"[1, 2][2]", "index out of range: 2 with length 2"},
+		{"[1, 2][-3]", "index out of range: -3 with length 2"},
+		{`[1]["a"]`, "index must be an integer, got STRING"},
+		{"1[0]", "index operator not supported: INTEGER"},
+		{"1[0:1]", "slice operator not supported: INTEGER"},
+		{`{ a: 1 }[[1]]`, "unusable as hash key: ARRAY"},
+		{`{ [1]: 1 }`, "unusable as hash key: ARRAY"},
+		{`sum(["a"])`, "unable to sum STRING"},
+		{`sum(1)`, "argument to sum must be ARRAY, got INTEGER"},
+	}
+
+	for _, test := range tests {
+		result := testEval(t, test.input)
+
+		err, ok := result.(*Error)
+		assert.Truef(t, ok, "expected error for %q, got %v", test.input, result)
+		if ok {
+			assert.Equal(t, test.message, err.Message)
+		}
+	}
+}
+
+func TestPipelinesWithCollections(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3] -> @fn double() { @ * 2 } -> sum(@)", "12"},
+		{"[1, 2, 3] -> @fn () { @ * 2 } -> @fn () { @ + 1 }", "[3, 5, 7]"},
+		{"[1, 2, 3] -> sum", "6"},
+		{"[1, 2, 3] -> fn (xs) { len(xs) }", "3"},
+		{"[1, 2, 3] -> @[0]", "1"},
+		{"@fn scale(by) { @ * by }\n[1, 2] -> scale(10)", "[10, 20]"},
+		{"@fn scale(by) { @ * by }\n[1, 2] -> scale(len(@))", "[2, 4]"},
+	})
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		input   string
+		limits  Limits
+		message string
+		line    int
+		col     int
+	}{
+		{"let i = 0\nwhile true { i += 1 }", Limits{Steps: 100}, "step limit of 100 exceeded", 1, 0},
+		{"fn f(n) { f(n + 1) }\nf(0)", Limits{CallDepth: 50}, "call depth limit of 50 exceeded", 0, 11},
+		{"fn f(n) { f(n + 1) }\nf(0)", Limits{}, "call depth limit of 10000 exceeded", 0, 11},
+		{"let xs = [1, 2]\nxs = push(xs, 3)", Limits{Elements: 4}, "element limit of 4 exceeded", 1, 9},
+		{"let h = {a: 1}\nh.b = 2\nh[\"c\"] = 3", Limits{Elements: 2}, "element limit of 2 exceeded", 2, 1},
+		{"range(1, 100000000) -> map(fn(x) { x })", Limits{Elements: 100}, "element limit of 100 exceeded", 0, 20},
+		{"range(0, 1000000) -> sum", Limits{Steps: 1000}, "step limit of 1000 exceeded", 0, 21},
+		{"range(0, 1000000) -> last", Limits{Elements: 1000}, "element limit of 1000 exceeded", 0, 21},
+		{"range(0, 100000) -> chunk(100000)", Limits{Elements: 100}, "element limit of 100 exceeded", 0, 25},
+		{"[1, 2, 3] -> window(2) -> take(5)", Limits{Elements: 6}, "element limit of 6 exceeded", 0, 19},
+		{"zip([1, 2], [3, 4])", Limits{Elements: 5}, "element limit of 5 exceeded", 0, 3},
+		{"let s = \"ab\"\ns += s\ns + s", Limits{StringBytes: 8}, "string size limit of 8 bytes exceeded", 2, 2},
+		{"[1, 2, 3] -> @fn() { \"${@}\" }", Limits{StringBytes: 2}, "string size limit of 2 bytes exceeded", 0, 21},
+		{"while true {}", Limits{Time: time.Millisecond}, "time limit of 1ms exceeded", 0, 0},
+	}
+
+	for _, test := range tests {
+		program, parseErr := parser.New(lexer.New(test.input)).Parse()
+		assert.Nil(t, parseErr, test.input)
+
+		env := NewEnvironment()
+		env.SetGuard(NewGuard(context.Background(), test.limits))
+		result := Eval(program, env)
+
+		err, ok := result.(*Error)
+		if assert.Truef(t, ok, "expected error for %q, got %v", test.input, result) {
+			assert.Equal(t, test.message, err.Message, test.input)
+			assert.ErrorIs(t, err.Cause, ErrLimitExceeded, test.input)
+			assert.Equal(t, []int{test.line, test.col}, []int{err.Token.Line, err.Token.Col}, test.input)
+		}
+	}
+}
+
+func TestCancellation(t *testing.T) {
+	program, parseErr := parser.New(lexer.New("fn spin() { while true {} }\nspin()")).Parse()
+	assert.Nil(t, parseErr)
+
+	// Function created under one guard runs under the guard of its caller
+	env := NewEnvironment()
+	env.SetGuard(NewGuard(context.Background(), Limits{}))
+	spin := Eval(program.Statements[0], env)
+	assert.False(t, isError(spin))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	env.SetGuard(NewGuard(ctx, Limits{}))
+	result := Eval(program.Statements[1], env)
+
+	err, ok := result.(*Error)
+	if assert.True(t, ok) {
+		assert.Equal(t, "evaluation cancelled: context deadline exceeded", err.Message)
+		assert.ErrorIs(t, err.Cause, context.DeadlineExceeded)
+	}
+}
+
+func testEvalConcurrent(t *testing.T, input string, guard *Guard) Object {
+	program, err := parser.New(lexer.New(input)).Parse()
+	assert.Nil(t, err)
+
+	env := NewEnvironment()
+	env.SetConcurrent(true)
+	env.SetGuard(guard)
+
+	return Eval(program, env)
+}
+
+func TestConcurrentPipelines(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// Parallel workers could finish in any order, but the next stage sees elements in order
+		{"let seen = []\n[1, 2, 3, 4, 5, 6, 7, 8] -> parallel(4) -> @fn() { @ * 10 } -> @fn() { seen = push(seen, @) }\nseen", "[10, 20, 30, 40, 50, 60, 70, 80]"},
+		{"[[1, 2], [3]] -> @fn() { @ -> parallel(2) -> @fn() { @ + 1 } }", "[[2, 3], [4]]"},
+		{"@fn add(n) { @ + n }\n[1, 2] -> add(1) -> add(len(@))", "[4, 5]"},
+		{"[1, 2] -> @fn() { @ } -> sum -> @fn() { @ * 2 }", "6"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, testEvalConcurrent(t, test.input, nil).Inspect(), test.input)
+	}
+}
+
+// Workers change the collections they share, which should be run with -race to catch unsynchronized access
+func TestConcurrentSharedCollections(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let h = {}\nrange(0, 3000) -> parallel(8) -> @fn() { h[\"k${@}\"] = 1; @ } -> sum\n[len(h), len(keys(h)), h.k2999]", "[3000, 3000, 1]"},
+		{"let h = {}\n[1, 2, 3, 4] -> parallel(4) -> @fn() { h[\"n\"] = @; h.last = @; len(h) }\nkeys(h)", `["n", "last"]`},
+		{"let xs = [0, 0, 0, 0]\nlet r = range(0, 400) -> parallel(8) -> @fn() { xs[@ / 100] = xs[3 - @ / 100] + 1; for x in xs {}; \"${xs}\" }\n[len(r), len(xs)]", "[400, 4]"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, testEvalConcurrent(t, test.input, nil).Inspect(), test.input)
+	}
+
+	// Pairs added by the workers are counted once each, together with the elements of both arrays
+	result := testEvalConcurrent(t, "let h = {}\nlet r = range(0, 1000) -> parallel(8) -> @fn() { h[@ / 100] = @ }\n[len(r), len(h)]", NewGuard(context.Background(), Limits{Elements: 1012}))
+	assert.Equal(t, "[1000, 10]", result.Inspect())
+}
+
+func TestConcurrentPipelineErrors(t *testing.T) {
+	// Failed stage cancels the one that would never finish
+	result := testEvalConcurrent(t, "[1, 2] -> parallel(2) -> @fn() { if @ == 1 { while true {} }\n1 / 0 }", nil)
+	err, ok := result.(*Error)
+	if assert.True(t, ok, result.Inspect()) {
+		assert.Equal(t, "division by zero", err.Message)
+		assert.Equal(t, []int{1, 2}, []int{err.Token.Line, err.Token.Col})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	result = testEvalConcurrent(t, "[1, 2, 3] -> @fn() { while true {} } -> @fn() { @ }", NewGuard(ctx, Limits{}))
+	err, ok = result.(*Error)
+	if assert.True(t, ok, result.Inspect()) {
+		assert.ErrorIs(t, err.Cause, context.DeadlineExceeded)
+	}
+
+	// Stages count their steps together
+	result = testEvalConcurrent(t, "[1, 2, 3, 4] -> parallel(4) -> @fn() { let i = 0; while i < 30 { i += 1 } }", NewGuard(context.Background(), Limits{Steps: 100}))
+	err, ok = result.(*Error)
+	if assert.True(t, ok, result.Inspect()) {
+		assert.Equal(t, "step limit of 100 exceeded", err.Message)
+	}
+}
+
+func testEvalStdin(t *testing.T, input string, stdin io.Reader) Object {
+	program, err := parser.New(lexer.New(input)).Parse()
+	assert.Nil(t, err)
+
+	env := NewEnvironment()
+	env.Set("stdin", StdinFrom(stdin))
+
+	return Eval(program, env)
+}
+
+func TestStreams(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"range(0, 3)", "stream"},
+		{"range(2, 5) -> @fn() { @ }", "[2, 3, 4]"},
+		{"range(5, 2) -> @fn() { @ }", "[]"},
+		{"[1, 2, 3] -> take(2)", "[1, 2]"},
+		{"[1, 2, 3] -> skip(5)", "[]"},
+		{"[1, 2, 3, 4, 5] -> chunk(2)", "[[1, 2], [3, 4], [5]]"},
+		{"[1, 2] -> window(3)", "[]"},
+		{"range(0, 4) -> window(3)", "[[0, 1, 2], [1, 2, 3]]"},
+		{"zip([1, 2, 3], [\"a\", \"b\"])", `[[1, "a"], [2, "b"]]`},
+		{"zip(range(0, 100), [\"a\", \"b\"], [true, false, true])", "stream"},
+		{"map([1, 2], fn(x) { x * 2 })", "[2, 4]"},
+		{"filter(range(0, 6), fn(x) { x > 3 }) -> @fn() { @ }", "[4, 5]"},
+		{"sum(range(0, 5))", "10"},
+		{"first(range(7, 9))", "7"},
+		{"let xs = []; for i, x in range(3, 5) { xs = push(xs, [i, x]) }; xs", "[[0, 3], [1, 4]]"},
+	})
+}
+
+func TestStreamSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("a,1\r\nb,2\nc,3"), 0o644))
+
+	result := testEval(t, `lines("`+path+`") -> @fn() { @[2:] } -> skip(1)`)
+	assert.Equal(t, `["2", "3"]`, result.Inspect())
+
+	result = testEval(t, `lines("`+filepath.Join(filepath.Dir(path), "missing.txt")+`")`)
+	if err, ok := result.(*Error); assert.True(t, ok, result.Inspect()) {
+		assert.Contains(t, err.Message, "missing.txt: no such file or directory")
+	}
+
+	// Input after the taken lines is never read
+	stdin := io.MultiReader(strings.NewReader("one\ntwo\nthree\n"), iotest.ErrReader(errors.New("read too far")))
+	result = testEvalStdin(t, "stdin.lines() -> @fn() { len(@) } -> take(2)", stdin)
+	assert.Equal(t, "[3, 3]", result.Inspect())
+
+	stdin = io.MultiReader(strings.NewReader("one\n"), iotest.ErrReader(errors.New("read too far")))
+	result = testEvalStdin(t, "stdin.lines() -> @fn() { @ }", stdin)
+	if err, ok := result.(*Error); assert.True(t, ok, result.Inspect()) {
+		assert.Equal(t, "could not read stdin: read too far", err.Message)
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"range(1)", "wrong number of arguments: expected 2, got 1"},
+		{"range(1, 2.5)", "argument to range must be INTEGER, got FLOAT"},
+		{"take(1, 2)", "argument to take must be ARRAY or STREAM, got INTEGER"},
+		{"[1] -> take(-1)", "argument to take must be at least 0, got -1"},
+		{"[1] -> chunk(0)", "argument to chunk must be at least 1, got 0"},
+		{"zip([1])", "wrong number of arguments: expected at least 2, got 1"},
+		{"[1, 2] -> filter(fn(x) { x + true })", "type mismatch: INTEGER + BOOLEAN"},
+		{"range(0, 3) -> @fn() { 1 / @ }", "division by zero"},
+		{"lines(1)", "argument to lines must be STRING, got INTEGER"},
+	}
+
+	for _, test := range tests {
+		result := testEval(t, test.input)
+
+		err, ok := result.(*Error)
+		assert.Truef(t, ok, "expected error for %q, got %v", test.input, result)
+		if ok {
+			assert.Equal(t, test.message, err.Message)
+		}
+	}
+
+	// Endless stream is stopped by the step limit
+	program, err := parser.New(lexer.New("range(0, 9223372036854775807) -> @fn() { @ }")).Parse()
+	assert.Nil(t, err)
+	env := NewEnvironment()
+	env.SetGuard(NewGuard(context.Background(), Limits{Steps: 50}))
+	if err, ok := Eval(program, env).(*Error); assert.True(t, ok) {
+		assert.Equal(t, "step limit of 50 exceeded", err.Message)
+	}
+}
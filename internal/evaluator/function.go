@@ -0,0 +1,26 @@
+package evaluator
+
+import "oilang/internal/ast"
+
+// Named function literal binds its name to itself inside of the body, so it could call itself
+func evalFunctionLiteral(fn *ast.FunctionLiteral, env *Environment) Object {
+	f := newFunction(fn, env)
+	f.IsSelfBound = fn.Name != nil
+
+	return f
+}
+
+// Named function on its own is a declaration, so it's bound to its name in the enclosing scope the same way let binds the value
+func evalFunctionDeclaration(fn *ast.FunctionLiteral, env *Environment) {
+	env.Set(fn.Name.Value, newFunction(fn, env))
+}
+
+func newFunction(fn *ast.FunctionLiteral, env *Environment) *Function {
+	return &Function{
+		Name:            fn.Name,
+		Parameters:      fn.Parameters,
+		Body:            fn.Body,
+		Env:             env,
+		IsPipelineStage: fn.IsPipelineStage,
+	}
+}
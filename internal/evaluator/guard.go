@@ -0,0 +1,194 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"oilang/internal/ast"
+	"oilang/internal/token"
+	"sync/atomic"
+	"time"
+)
+
+// Limits bound resources the program could use, so untrusted code could be run safely. Zero limit means there is no limit
+type Limits struct {
+	// Amount of statements and loop iterations the program could run
+	Steps int64
+	// Depth of nested function calls. Deeper calls would overflow the Go stack, so zero means DEFAULT_CALL_DEPTH
+	CallDepth int
+	// Total amount of array elements and hash pairs the program could create
+	Elements int64
+	// Total size of the strings the program could create, in bytes
+	StringBytes int64
+	// Wall-clock time the program could run for
+	Time time.Duration
+}
+
+// Depth of nested calls the program could reach when its limits do not tell otherwise. It's far below the depth
+// that overflows the Go stack, which can not be recovered from
+const DEFAULT_CALL_DEPTH = 10000
+
+// ErrLimitExceeded is the cause of the errors that stop the program when it exceeds its limits
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// Guard tracks resources used by the program and stops it when its context is cancelled or it exceeds the limits.
+// Guard is passed from the caller to the called functions, so functions created by the previous programs
+// run under the guard of the current one. Nil guard does not stop the program
+type Guard struct {
+	ctx    context.Context
+	limits Limits
+	// Time when the time limit is exceeded, zero if there is no time limit
+	deadline time.Time
+	// Shared with the forks of the guard, so the resources used by concurrent stages are counted together
+	usage *usage
+	// Streams could call functions from the goroutine of the concurrent pipeline that pulls them
+	depth atomic.Int64
+}
+
+type usage struct {
+	steps       atomic.Int64
+	elements    atomic.Int64
+	stringBytes atomic.Int64
+}
+
+// NewGuard Creates guard that starts counting the time limit right away
+func NewGuard(ctx context.Context, limits Limits) *Guard {
+	if limits.CallDepth <= 0 {
+		limits.CallDepth = DEFAULT_CALL_DEPTH
+	}
+
+	g := &Guard{ctx: ctx, limits: limits, usage: &usage{}}
+	if limits.Time > 0 {
+		g.deadline = time.Now().Add(limits.Time)
+	}
+
+	return g
+}
+
+// Counts the statement or loop iteration and checks if the program should stop before running it
+func (g *Guard) step(tok token.Token) *Error {
+	if g == nil {
+		return nil
+	}
+
+	select {
+	case <-g.ctx.Done():
+		return &Error{Message: "evaluation cancelled: " + g.ctx.Err().Error(), Token: tok, Cause: g.ctx.Err()}
+	default:
+	}
+
+	if steps := g.usage.steps.Add(1); g.limits.Steps > 0 && steps > g.limits.Steps {
+		return limitError(tok, "step limit of %d", g.limits.Steps)
+	}
+	if !g.deadline.IsZero() && time.Now().After(g.deadline) {
+		return limitError(tok, "time limit of %s", g.limits.Time)
+	}
+
+	return nil
+}
+
+// Creates guard for the goroutine that runs a part of the program concurrently. Fork counts resources together
+// with the guard, starts from its call depth and stops when ctx is done. Nil guard is forked into one with default limits
+func (g *Guard) fork(ctx context.Context) *Guard {
+	if g == nil {
+		return NewGuard(ctx, Limits{})
+	}
+
+	fork := &Guard{ctx: ctx, limits: g.limits, deadline: g.deadline, usage: g.usage}
+	fork.depth.Store(g.depth.Load())
+
+	return fork
+}
+
+// Returns context the program runs with
+func (g *Guard) context() context.Context {
+	if g == nil {
+		return context.Background()
+	}
+
+	return g.ctx
+}
+
+// Counts the function call, which should be followed by leave once the function returns
+func (g *Guard) enter(tok token.Token) *Error {
+	if g == nil {
+		return nil
+	}
+
+	if depth := g.depth.Add(1); depth > int64(g.limits.CallDepth) {
+		g.depth.Add(-1)
+		return limitError(tok, "call depth limit of %d", g.limits.CallDepth)
+	}
+
+	return nil
+}
+
+func (g *Guard) leave() {
+	if g != nil {
+		g.depth.Add(-1)
+	}
+}
+
+// Counts the new collection or string the program has created. Returns the object, or the error if it exceeds the limits
+func (g *Guard) allocate(tok token.Token, obj Object) Object {
+	switch obj := obj.(type) {
+	case *Array:
+		return g.allocateElements(tok, len(obj.Elements), obj)
+	case *Hash:
+		return g.allocateElements(tok, obj.Len(), obj)
+	case *String:
+		if g == nil {
+			return obj
+		}
+
+		size := g.usage.stringBytes.Add(int64(len(obj.Value)))
+		if g.limits.StringBytes > 0 && size > g.limits.StringBytes {
+			return limitError(tok, "string size limit of %d bytes", g.limits.StringBytes)
+		}
+	}
+
+	return obj
+}
+
+// Counts elements added to the collection, e.g. a new pair of the hash. Returns the object or the error
+func (g *Guard) allocateElements(tok token.Token, amount int, obj Object) Object {
+	if g == nil {
+		return obj
+	}
+
+	if elements := g.usage.elements.Add(int64(amount)); g.limits.Elements > 0 && elements > g.limits.Elements {
+		return limitError(tok, "element limit of %d", g.limits.Elements)
+	}
+
+	return obj
+}
+
+func limitError(tok token.Token, format string, limit any) *Error {
+	return &Error{Message: fmt.Sprintf(format+" exceeded", limit), Token: tok, Cause: ErrLimitExceeded}
+}
+
+// Returns the first token of the statement, where the step that runs it is counted
+func statementToken(stmt ast.Statement) token.Token {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return stmt.Token
+	case *ast.ReturnStatement:
+		return stmt.Token
+	case *ast.ExpressionStatement:
+		return stmt.Token
+	case *ast.ImportStatement:
+		return stmt.Token
+	case *ast.ExportStatement:
+		return stmt.Token
+	case *ast.WhileStatement:
+		return stmt.Token
+	case *ast.ForStatement:
+		return stmt.Token
+	case *ast.BreakStatement:
+		return stmt.Token
+	case *ast.ContinueStatement:
+		return stmt.Token
+	}
+
+	return token.Token{}
+}
@@ -0,0 +1,29 @@
+package evaluator
+
+import "oilang/internal/ast"
+
+func evalIfExpression(exp *ast.IfExpression, env *Environment) Object {
+	cond := Eval(exp.Condition, env)
+	if isError(cond) {
+		return cond
+	}
+
+	if isTruthy(cond) {
+		return evalBranch(exp.Consequnce, env)
+	}
+
+	if exp.Alternative != nil {
+		return evalBranch(exp.Alternative, env)
+	}
+
+	return NULL
+}
+
+// Branch has its own scope, so its let statements do not change names of the enclosing one. Its value should always be defined
+func evalBranch(block *ast.BlockStatement, env *Environment) Object {
+	if result := evalBlockStatement(block, NewEnclosedEnvironment(env)); result != nil {
+		return result
+	}
+
+	return NULL
+}
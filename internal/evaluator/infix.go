@@ -0,0 +1,201 @@
+package evaluator
+
+import (
+	"math"
+	"oilang/internal/ast"
+	"oilang/internal/token"
+	"strings"
+)
+
+func evalInfixExpression(exp *ast.InfixExpression, env *Environment) Object {
+	left := Eval(exp.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	// Logical operators are short-circuited, so right side is evaluated only when needed
+	switch exp.Token.Type {
+	case token.AND:
+		if !isTruthy(left) {
+			return FALSE
+		}
+		return evalLogicalOperand(exp.Right, env)
+	case token.OR:
+		if isTruthy(left) {
+			return TRUE
+		}
+		return evalLogicalOperand(exp.Right, env)
+	}
+
+	right := Eval(exp.Right, env)
+	if isError(right) {
+		return right
+	}
+
+	return env.guard.allocate(exp.Token, evalInfixOperator(exp.Token, left, right))
+}
+
+func evalLogicalOperand(node ast.Expression, env *Environment) Object {
+	val := Eval(node, env)
+	if isError(val) {
+		return val
+	}
+
+	return nativeBoolToObject(isTruthy(val))
+}
+
+// evalInfixOperator applies binary operator to already evaluated operands
+func evalInfixOperator(tok token.Token, left, right Object) Object {
+	switch {
+	case left.Type() == INTEGER_OBJ && right.Type() == INTEGER_OBJ:
+		return evalIntegerInfix(tok, left.(*Integer).Value, right.(*Integer).Value)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfix(tok, toFloat(left), toFloat(right))
+	case left.Type() == STRING_OBJ && right.Type() == STRING_OBJ:
+		return evalStringInfix(tok, left.(*String).Value, right.(*String).Value)
+	case left.Type() == BOOLEAN_OBJ && right.Type() == BOOLEAN_OBJ:
+		return evalBooleanInfix(tok, left.(*Boolean).Value, right.(*Boolean).Value)
+	case tok.Type == token.EQ:
+		return nativeBoolToObject(left == right)
+	case tok.Type == token.NEQ:
+		return nativeBoolToObject(left != right)
+	case left.Type() != right.Type():
+		return newError(tok, "type mismatch: %s %s %s", typeOf(left), tok.Literal, typeOf(right))
+	}
+
+	return newError(tok, "unknown operator: %s %s %s", typeOf(left), tok.Literal, typeOf(right))
+}
+
+func evalIntegerInfix(tok token.Token, left, right int64) Object {
+	switch tok.Type {
+	case token.PLUS:
+		return &Integer{Value: left + right}
+	case token.MINUS:
+		return &Integer{Value: left - right}
+	case token.MULTIPLY:
+		return &Integer{Value: left * right}
+	case token.DIVIDE:
+		if right == 0 {
+			return newError(tok, "division by zero")
+		}
+		return &Integer{Value: left / right}
+	case token.POWER:
+		// Negative power can not be represented with integer
+		if right < 0 {
+			return &Float{Value: math.Pow(float64(left), float64(right))}
+		}
+		return &Integer{Value: intPow(left, right)}
+	}
+
+	return evalComparison(tok, compareNumbers(left, right))
+}
+
+func evalFloatInfix(tok token.Token, left, right float64) Object {
+	switch tok.Type {
+	case token.PLUS:
+		return &Float{Value: left + right}
+	case token.MINUS:
+		return &Float{Value: left - right}
+	case token.MULTIPLY:
+		return &Float{Value: left * right}
+	case token.DIVIDE:
+		if right == 0 {
+			return newError(tok, "division by zero")
+		}
+		return &Float{Value: left / right}
+	case token.POWER:
+		return &Float{Value: math.Pow(left, right)}
+	}
+
+	return evalComparison(tok, compareNumbers(left, right))
+}
+
+func evalStringInfix(tok token.Token, left, right string) Object {
+	if tok.Type == token.PLUS {
+		return &String{Value: left + right}
+	}
+
+	if _, ok := comparisonOperators[tok.Type]; ok {
+		return evalComparison(tok, strings.Compare(left, right))
+	}
+
+	return newError(tok, "unknown operator: %s %s %s", STRING_OBJ, tok.Literal, STRING_OBJ)
+}
+
+func evalBooleanInfix(tok token.Token, left, right bool) Object {
+	switch tok.Type {
+	case token.EQ:
+		return nativeBoolToObject(left == right)
+	case token.NEQ:
+		return nativeBoolToObject(left != right)
+	}
+
+	return newError(tok, "unknown operator: %s %s %s", BOOLEAN_OBJ, tok.Literal, BOOLEAN_OBJ)
+}
+
+// evalComparison turns result of comparing two operands into a boolean depending on comparison operator.
+// Comparison result should be negative if left is less than right, positive if greater and zero if they're equal
+func evalComparison(tok token.Token, cmp int) Object {
+	switch tok.Type {
+	case token.EQ:
+		return nativeBoolToObject(cmp == 0)
+	case token.NEQ:
+		return nativeBoolToObject(cmp != 0)
+	case token.LT:
+		return nativeBoolToObject(cmp < 0)
+	case token.GT:
+		return nativeBoolToObject(cmp > 0)
+	case token.LTE:
+		return nativeBoolToObject(cmp <= 0)
+	case token.GTE:
+		return nativeBoolToObject(cmp >= 0)
+	}
+
+	return newError(tok, "unknown operator: %s", tok.Literal)
+}
+
+var comparisonOperators = map[token.TokenType]struct{}{
+	token.EQ:  {},
+	token.NEQ: {},
+	token.LT:  {},
+	token.GT:  {},
+	token.LTE: {},
+	token.GTE: {},
+}
+
+func compareNumbers[T int64 | float64](left, right T) int {
+	switch {
+	case left < right:
+		return -1
+	case left > right:
+		return 1
+	}
+
+	return 0
+}
+
+func intPow(base, exp int64) int64 {
+	result := int64(1)
+	for exp > 0 {
+		if exp&1 == 1 {
+			result *= base
+		}
+		base *= base
+		exp >>= 1
+	}
+
+	return result
+}
+
+func isNumber(obj Object) bool {
+	return obj.Type() == INTEGER_OBJ || obj.Type() == FLOAT_OBJ
+}
+
+// Converts numeric object to float. Should be called only after checking with isNumber
+func toFloat(obj Object) float64 {
+	if i, ok := obj.(*Integer); ok {
+		return float64(i.Value)
+	}
+
+	return obj.(*Float).Value
+}
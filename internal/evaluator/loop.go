@@ -0,0 +1,197 @@
+package evaluator
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/token"
+)
+
+// Iterator goes through the elements of the collection that for loop runs over
+type Iterator struct {
+	next func() (key, value Object, ok bool)
+	// Ends the stream the loop has not pulled to the end, nil for collections
+	stop func()
+	// Hash is iterated over its keys, so the only name of the loop is bound to the key instead of the value
+	keyed bool
+}
+
+func (*Iterator) Type() ObjectType { return ITERATOR_OBJ }
+func (*Iterator) Inspect() string  { return "iterator" }
+
+// Next Returns the next element along with its key: index of the array element, string character or stream value,
+// or key of the hash pair. Returns false when there are no more elements. Value is *Error if the stream has failed
+func (it *Iterator) Next() (key, value Object, ok bool) {
+	return it.next()
+}
+
+// Stop Releases the stream the loop goes through when the loop ends before it
+func (it *Iterator) Stop() {
+	if it.stop != nil {
+		it.stop()
+	}
+}
+
+// Element Returns what the only name of the for loop is bound to
+func (it *Iterator) Element(key, value Object) Object {
+	if it.keyed {
+		return key
+	}
+
+	return value
+}
+
+// Elements that are added to the collection by the loop itself are not visited
+func newIterator(tok token.Token, iterable Object) (*Iterator, *Error) {
+	i := 0
+
+	switch iterable := iterable.(type) {
+	case *Array:
+		size := len(iterable.Elements)
+		return &Iterator{next: func() (Object, Object, bool) {
+			if i >= size {
+				return nil, nil, false
+			}
+
+			i++
+			return &Integer{Value: int64(i - 1)}, iterable.element(i - 1), true
+		}}, nil
+	case *String:
+		runes := []rune(iterable.Value)
+		return &Iterator{next: func() (Object, Object, bool) {
+			if i >= len(runes) {
+				return nil, nil, false
+			}
+
+			i++
+			return &Integer{Value: int64(i - 1)}, &String{Value: string(runes[i-1])}, true
+		}}, nil
+	case *Hash:
+		keys := iterable.keys()
+		return &Iterator{keyed: true, next: func() (Object, Object, bool) {
+			if i >= len(keys) {
+				return nil, nil, false
+			}
+
+			i++
+			pair := iterable.pair(keys[i-1])
+			return pair.Key, pair.Value, true
+		}}, nil
+	case *Stream:
+		return &Iterator{stop: iterable.Stop, next: func() (Object, Object, bool) {
+			val := iterable.Next()
+			if val == nil {
+				return nil, nil, false
+			}
+
+			i++
+			return &Integer{Value: int64(i - 1)}, val, true
+		}}, nil
+	}
+
+	return nil, newError(tok, "not iterable: %s", typeOf(iterable))
+}
+
+func evalWhileStatement(stmt *ast.WhileStatement, env *Environment) Object {
+	for {
+		if err := env.guard.step(stmt.Token); err != nil {
+			return err
+		}
+
+		cond := Eval(stmt.Condition, env)
+		if isError(cond) {
+			return cond
+		}
+
+		if !isTruthy(cond) {
+			return nil
+		}
+
+		if stop, result := controlLoop(stmt.Label, evalBlockStatement(stmt.Body, NewEnclosedEnvironment(env))); stop {
+			return result
+		}
+	}
+}
+
+// Each iteration has its own scope, where names of the loop are bound together with let statements of the body,
+// so functions created by the body keep values of the iteration they were created in
+func evalForStatement(stmt *ast.ForStatement, env *Environment) Object {
+	iterable := Eval(stmt.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	it, err := newIterator(stmt.Token, iterable)
+	if err != nil {
+		return err
+	}
+	defer it.Stop()
+
+	for {
+		if err := env.guard.step(stmt.Token); err != nil {
+			return err
+		}
+
+		key, value, ok := it.Next()
+		if !ok {
+			return nil
+		}
+		if isError(value) {
+			return value
+		}
+
+		iteration := NewEnclosedEnvironment(env)
+		if stmt.Key != nil {
+			iteration.Set(stmt.Key.Value, key)
+			iteration.Set(stmt.Value.Value, value)
+		} else {
+			iteration.Set(stmt.Value.Value, it.Element(key, value))
+		}
+
+		if stop, result := controlLoop(stmt.Label, evalBlockStatement(stmt.Body, iteration)); stop {
+			return result
+		}
+	}
+}
+
+// Decides if the loop should stop after its body has produced the result. Errors, return values and
+// break or continue of the outer loops stop the loop and are passed further
+func controlLoop(label *ast.Identifier, result Object) (bool, Object) {
+	control, ok := result.(*LoopControl)
+	if !ok {
+		if result != nil && (result.Type() == RETURN_OBJ || result.Type() == ERROR_OBJ) {
+			return true, result
+		}
+
+		return false, nil
+	}
+
+	if control.Label != "" && (label == nil || label.Value != control.Label) {
+		return true, control
+	}
+
+	return !control.Continue, nil
+}
+
+func evalBreakStatement(stmt *ast.BreakStatement) Object {
+	return &LoopControl{Token: stmt.Token, Label: labelName(stmt.Label)}
+}
+
+func evalContinueStatement(stmt *ast.ContinueStatement) Object {
+	return &LoopControl{Token: stmt.Token, Label: labelName(stmt.Label), Continue: true}
+}
+
+func labelName(label *ast.Identifier) string {
+	if label == nil {
+		return ""
+	}
+
+	return label.Value
+}
+
+// Break or continue that has left the function or the program did not find its loop
+func loopControlError(control *LoopControl) *Error {
+	if control.Label != "" {
+		return newError(control.Token, "unknown label %s", control.Label)
+	}
+
+	return newError(control.Token, "%s outside of loop", control.Token.Literal)
+}
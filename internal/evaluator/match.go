@@ -0,0 +1,110 @@
+package evaluator
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/token"
+)
+
+// Names bound by the arm are set in the current scope, the same way names of the loops are.
+// Value of the match is null if no arm has matched
+func evalMatchExpression(exp *ast.MatchExpression, env *Environment) Object {
+	subject := Eval(exp.Subject, env)
+	if isError(subject) {
+		return subject
+	}
+
+	for _, arm := range exp.Arms {
+		matched, bindings := matchArm(arm, subject)
+		if matched == nil {
+			continue
+		}
+
+		// Names bound by the pattern are visible only inside of the arm
+		armEnv := NewEnclosedEnvironment(env)
+		names := ast.PatternNames(matched)
+		for i, name := range names {
+			armEnv.Set(name.Value, bindings[i])
+		}
+
+		if arm.Guard != nil {
+			guard := Eval(arm.Guard, armEnv)
+			if isError(guard) {
+				return guard
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+
+		if arm.Body != nil {
+			return evalBranch(arm.Body, armEnv)
+		}
+
+		return Eval(arm.Value, armEnv)
+	}
+
+	return NULL
+}
+
+// Returns the first pattern of the arm that matches the value along with the values of its names
+func matchArm(arm *ast.MatchArm, val Object) (ast.Pattern, []Object) {
+	for _, pattern := range arm.Patterns {
+		if bindings, ok := matchPattern(pattern, val, nil); ok {
+			return pattern, bindings
+		}
+	}
+
+	return nil, nil
+}
+
+// matchPattern tells if the value matches the pattern and appends values of the names it binds,
+// in the same order as ast.PatternNames returns them
+func matchPattern(pattern ast.Pattern, val Object, bindings []Object) ([]Object, bool) {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return bindings, true
+	case *ast.BindingPattern:
+		return append(bindings, val), true
+	case *ast.LiteralPattern:
+		literal := Eval(pattern.Value, nil)
+		return bindings, isTruthy(evalInfixOperator(token.Token{Type: token.EQ, Literal: "=="}, literal, val))
+	case *ast.ArrayPattern:
+		array, ok := val.(*Array)
+		if !ok || len(array.Elements) < len(pattern.Elements) || (pattern.Rest == nil && len(array.Elements) != len(pattern.Elements)) {
+			return nil, false
+		}
+
+		for i, element := range pattern.Elements {
+			if bindings, ok = matchPattern(element, array.element(i), bindings); !ok {
+				return nil, false
+			}
+		}
+
+		if pattern.Rest != nil {
+			rest := array.slice(len(pattern.Elements), len(array.Elements))
+			return matchPattern(pattern.Rest, &Array{Elements: rest}, bindings)
+		}
+
+		return bindings, true
+	case *ast.HashPattern:
+		hash, ok := val.(*Hash)
+		if !ok {
+			return nil, false
+		}
+
+		for _, pair := range pattern.Pairs {
+			value, found := hash.Get(&String{Value: pair.Key.Value})
+			if !found {
+				return nil, false
+			}
+
+			if bindings, ok = matchPattern(pair.Value, value, bindings); !ok {
+				return nil, false
+			}
+		}
+
+		return bindings, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,99 @@
+package evaluator
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/token"
+	"path/filepath"
+)
+
+// Importer loads modules requested by import statements
+type Importer interface {
+	// Import Returns *Module with the path relative to the file of the importing environment, or *Error if it could not be loaded.
+	// Token of the import statement is used for error reporting
+	Import(tok token.Token, path string, from *Environment) Object
+}
+
+// Module is an evaluated file. Names it has exported are accessible as its members, e.g. text.trim
+type Module struct {
+	Path    string
+	Exports map[string]Object
+}
+
+func (*Module) Type() ObjectType  { return MODULE_OBJ }
+func (m *Module) Inspect() string { return "module " + filepath.Base(m.Path) }
+
+// NewModule Creates module from the program evaluated in the environment, collecting values of its exported names
+func NewModule(path string, program *ast.Program, env *Environment) *Module {
+	module := &Module{Path: path, Exports: make(map[string]Object)}
+
+	for _, stmt := range program.Statements {
+		export, ok := stmt.(*ast.ExportStatement)
+		if !ok {
+			continue
+		}
+
+		name := exportedName(export)
+		if val, ok := env.Get(name); ok {
+			module.Exports[name] = val
+		}
+	}
+
+	return module
+}
+
+// Returns name declared by the exported let statement or function
+func exportedName(export *ast.ExportStatement) string {
+	switch stmt := export.Statement.(type) {
+	case *ast.LetStatement:
+		return stmt.Name.Value
+	case *ast.ExpressionStatement:
+		if fn, ok := stmt.Expression.(*ast.FunctionLiteral); ok && fn.Name != nil {
+			return fn.Name.Value
+		}
+	}
+
+	return ""
+}
+
+func evalImportStatement(stmt *ast.ImportStatement, env *Environment) Object {
+	if env.importer == nil {
+		return newError(stmt.Token, "imports are not available")
+	}
+
+	module := env.importer.Import(stmt.Path.Token, stmt.Path.Value, env)
+	if isError(module) {
+		return module
+	}
+
+	env.Set(stmt.Alias.Value, module)
+	return nil
+}
+
+func evalMemberExpression(member *ast.MemberExpression, env *Environment) Object {
+	object := Eval(member.Object, env)
+	if isError(object) {
+		return object
+	}
+
+	return evalMember(member.Token, object, member.Property.Value)
+}
+
+// Returns export of the module or value of the hash stored under the name
+func evalMember(tok token.Token, object Object, name string) Object {
+	switch object := object.(type) {
+	case *Module:
+		if val, ok := object.Exports[name]; ok {
+			return val
+		}
+
+		return newError(tok, "module %s has no export %s", filepath.Base(object.Path), name)
+	case *Hash:
+		if val, ok := object.Get(&String{Value: name}); ok {
+			return val
+		}
+
+		return NULL
+	}
+
+	return newError(tok, "member access not supported: %s", typeOf(object))
+}
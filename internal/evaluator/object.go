@@ -0,0 +1,374 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"oilang/internal/ast"
+	"oilang/internal/diagnostics"
+	"oilang/internal/token"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type ObjectType string
+
+const (
+	INTEGER_OBJ  ObjectType = "INTEGER"
+	FLOAT_OBJ    ObjectType = "FLOAT"
+	BOOLEAN_OBJ  ObjectType = "BOOLEAN"
+	STRING_OBJ   ObjectType = "STRING"
+	ARRAY_OBJ    ObjectType = "ARRAY"
+	HASH_OBJ     ObjectType = "HASH"
+	NULL_OBJ     ObjectType = "NULL"
+	ERROR_OBJ    ObjectType = "ERROR"
+	RETURN_OBJ   ObjectType = "RETURN_VALUE"
+	FUNCTION_OBJ ObjectType = "FUNCTION"
+	BUILTIN_OBJ  ObjectType = "BUILTIN"
+	MODULE_OBJ   ObjectType = "MODULE"
+	ITERATOR_OBJ ObjectType = "ITERATOR"
+	STREAM_OBJ   ObjectType = "STREAM"
+
+	LOOP_CONTROL_OBJ ObjectType = "LOOP_CONTROL"
+	PATTERN_OBJ      ObjectType = "PATTERN"
+)
+
+// Object is a value that is produced by evaluating the AST
+type Object interface {
+	Type() ObjectType
+	// Inspect returns representation of the value that is shown to the user
+	Inspect() string
+}
+
+type Integer struct {
+	Value int64
+}
+
+func (*Integer) Type() ObjectType  { return INTEGER_OBJ }
+func (i *Integer) Inspect() string { return strconv.FormatInt(i.Value, 10) }
+
+type Float struct {
+	Value float64
+}
+
+func (*Float) Type() ObjectType { return FLOAT_OBJ }
+func (f *Float) Inspect() string {
+	out := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	// Make sure float is distinguishable from the integer
+	if !strings.ContainsAny(out, ".eIN") {
+		out += ".0"
+	}
+
+	return out
+}
+
+type Boolean struct {
+	Value bool
+}
+
+func (*Boolean) Type() ObjectType  { return BOOLEAN_OBJ }
+func (b *Boolean) Inspect() string { return strconv.FormatBool(b.Value) }
+
+type String struct {
+	Value string
+}
+
+func (*String) Type() ObjectType  { return STRING_OBJ }
+func (s *String) Inspect() string { return strconv.Quote(s.Value) }
+
+// Array could be shared by stages of concurrent pipelines, which replace its elements by index assignment,
+// so access to the elements is synchronized while the program runs
+type Array struct {
+	mu       sync.RWMutex
+	Elements []Object
+}
+
+func (*Array) Type() ObjectType { return ARRAY_OBJ }
+func (a *Array) Inspect() string {
+	var elements []string
+	for _, e := range a.elements() {
+		elements = append(elements, e.Inspect())
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// Returns the element, index should be in bounds
+func (a *Array) element(i int) Object {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.Elements[i]
+}
+
+// Replaces the element, index should be in bounds
+func (a *Array) setElement(i int, val Object) {
+	a.mu.Lock()
+	a.Elements[i] = val
+	a.mu.Unlock()
+}
+
+// Returns copy of the elements between the bounds, which could be read while the array changes
+func (a *Array) slice(low, high int) []Object {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	elements := make([]Object, high-low)
+	copy(elements, a.Elements[low:high])
+
+	return elements
+}
+
+// Returns copy of all elements, see slice
+func (a *Array) elements() []Object {
+	return a.slice(0, len(a.Elements))
+}
+
+// HashKey identifies value that is used as a key of the hash
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+	// Strings are keyed by their whole value, so different strings never share the key
+	Text string
+}
+
+// Hashable is implemented by objects that could be used as hash keys
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+func (i *Integer) HashKey() HashKey { return HashKey{Type: INTEGER_OBJ, Value: uint64(i.Value)} }
+func (f *Float) HashKey() HashKey   { return HashKey{Type: FLOAT_OBJ, Value: math.Float64bits(f.Value)} }
+func (s *String) HashKey() HashKey  { return HashKey{Type: STRING_OBJ, Text: s.Value} }
+func (b *Boolean) HashKey() HashKey {
+	if b.Value {
+		return HashKey{Type: BOOLEAN_OBJ, Value: 1}
+	}
+
+	return HashKey{Type: BOOLEAN_OBJ, Value: 0}
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// Hash is a map that remembers the order in which keys were inserted
+// Hash could be shared by stages of concurrent pipelines, which add pairs to it by assignment,
+// so access to the pairs is synchronized while the program runs
+type Hash struct {
+	mu    sync.RWMutex
+	Pairs map[HashKey]HashPair
+	Keys  []HashKey
+}
+
+func NewHash() *Hash {
+	return &Hash{Pairs: make(map[HashKey]HashPair)}
+}
+
+func (*Hash) Type() ObjectType { return HASH_OBJ }
+func (h *Hash) Inspect() string {
+	var pairs []string
+	for _, pair := range h.pairs() {
+		pairs = append(pairs, pair.Key.Inspect()+": "+pair.Value.Inspect())
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// Set Adds the pair to the hash or replaces value of the existing key. Returns true if the pair is added
+func (h *Hash) Set(key Hashable, val Object) bool {
+	k := key.HashKey()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, exists := h.Pairs[k]
+	if !exists {
+		h.Keys = append(h.Keys, k)
+	}
+
+	h.Pairs[k] = HashPair{Key: key, Value: val}
+	return !exists
+}
+
+// Get Returns value stored under the key
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	k := key.HashKey()
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	pair, ok := h.Pairs[k]
+	return pair.Value, ok
+}
+
+// Len Returns amount of the pairs
+func (h *Hash) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.Keys)
+}
+
+// Returns copy of the keys in the order they were added, which could be read while the hash changes
+func (h *Hash) keys() []HashKey {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return append([]HashKey(nil), h.Keys...)
+}
+
+// Returns the pair stored under the key
+func (h *Hash) pair(k HashKey) HashPair {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.Pairs[k]
+}
+
+// Returns copy of the pairs in the order their keys were added, see keys
+func (h *Hash) pairs() []HashPair {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	pairs := make([]HashPair, 0, len(h.Keys))
+	for _, k := range h.Keys {
+		pairs = append(pairs, h.Pairs[k])
+	}
+
+	return pairs
+}
+
+type Null struct{}
+
+func (*Null) Type() ObjectType { return NULL_OBJ }
+func (*Null) Inspect() string  { return "null" }
+
+// Error is a runtime error. It stops evaluation of the program and remembers the token that has caused it
+type Error struct {
+	Message string
+	Token   token.Token
+	// Go error behind the error, e.g. cancellation of the context, ErrLimitExceeded or the import cycle. Nil for other errors of the program
+	Cause error
+}
+
+func (*Error) Type() ObjectType  { return ERROR_OBJ }
+func (e *Error) Inspect() string { return "error: " + e.Message }
+
+// Diagnostic Converts the error to diagnostic that could be shown to the user
+func (e *Error) Diagnostic() diagnostics.Diagnostic {
+	return diagnostics.New(diagnostics.Error, e.Token, e.Message)
+}
+
+// ReturnValue wraps the value of return statement, so evaluation of the enclosing block stops on it
+type ReturnValue struct {
+	Value Object
+}
+
+func (*ReturnValue) Type() ObjectType   { return RETURN_OBJ }
+func (rv *ReturnValue) Inspect() string { return rv.Value.Inspect() }
+
+// LoopControl is produced by break and continue statements. Like return value, it stops evaluation of the blocks
+// until it reaches the loop with the label, or the innermost loop if there is no label
+type LoopControl struct {
+	Token    token.Token
+	Label    string
+	Continue bool
+}
+
+func (*LoopControl) Type() ObjectType   { return LOOP_CONTROL_OBJ }
+func (lc *LoopControl) Inspect() string { return lc.Token.Literal }
+
+type Function struct {
+	Name       *ast.Identifier
+	Parameters []*ast.Identifier
+	Body       *ast.BlockStatement
+	// Environment the function was defined in
+	Env *Environment
+	// Tells if the function should be run only in pipeline
+	IsPipelineStage bool
+	// Tells if the name is bound to the function itself inside of it, so the named function literal
+	// could call itself without being declared
+	IsSelfBound bool
+}
+
+func (*Function) Type() ObjectType { return FUNCTION_OBJ }
+func (f *Function) Inspect() string {
+	var params []string
+	for _, p := range f.Parameters {
+		params = append(params, p.String())
+	}
+
+	kind := "fn"
+	if f.IsPipelineStage {
+		kind = "@fn"
+	}
+
+	var name string
+	if f.Name != nil {
+		name = f.Name.String()
+	}
+
+	return fmt.Sprintf("%s %s(%s)", kind, name, strings.Join(params, ", "))
+}
+
+// BuiltinFunction is a function implemented by the interpreter itself.
+// Token is the token of the call expression and should be used for reporting errors
+type BuiltinFunction func(tok token.Token, args ...Object) Object
+
+// Caller calls the function with arguments the way the engine that runs the builtin function does.
+// Returns the result or *Error
+type Caller func(tok token.Token, fn Object, args ...Object) Object
+
+// GuardedFunction is a builtin function that pulls streams or builds collections element by element, e.g. sum.
+// It runs under the guard of the caller, so it stops when the program exceeds its limits or is cancelled
+type GuardedFunction func(tok token.Token, g *Guard, args ...Object) Object
+
+// HigherOrderFunction is a builtin function that calls the functions it gets, e.g. map. It runs under the guard of the caller
+type HigherOrderFunction func(tok token.Token, call Caller, g *Guard, args ...Object) Object
+
+// Builtin is a function implemented by the interpreter, either Fn, Guarded or HigherOrder
+type Builtin struct {
+	Name        string
+	Fn          BuiltinFunction
+	Guarded     GuardedFunction
+	HigherOrder HigherOrderFunction
+	// Tells if the function returns a new collection or string, so its result is counted towards the limits
+	Allocates bool
+}
+
+func (*Builtin) Type() ObjectType  { return BUILTIN_OBJ }
+func (b *Builtin) Inspect() string { return "builtin " + b.Name }
+
+// Call Calls the function with arguments under the guard, using the caller for the functions it gets
+func (b *Builtin) Call(tok token.Token, call Caller, g *Guard, args ...Object) Object {
+	if b.HigherOrder != nil {
+		return b.HigherOrder(tok, call, g, args...)
+	}
+	if b.Guarded != nil {
+		return b.Guarded(tok, g, args...)
+	}
+
+	return b.Fn(tok, args...)
+}
+
+// Returns type of the object for error messages, taking into account that object could be absent
+func typeOf(obj Object) ObjectType {
+	if obj == nil {
+		return NULL_OBJ
+	}
+
+	return obj.Type()
+}
+
+// Returns text representation of the object as it should appear inside other strings or output.
+// Unlike Inspect, strings are not quoted
+func toDisplayString(obj Object) string {
+	if s, ok := obj.(*String); ok {
+		return s.Value
+	}
+
+	return obj.Inspect()
+}
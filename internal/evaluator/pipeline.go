@@ -0,0 +1,183 @@
+package evaluator
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/token"
+)
+
+// Name under which the value passed through pipeline is available in the stage
+const PIPELINE_CONTEXT = "@"
+
+// evalPipelineExpression passes the value through the stages one by one. Array or stream that goes through consecutive
+// stage functions goes through them concurrently in concurrent mode, or when one of them is annotated with parallel.
+// Stream the pipeline ends with is collected into an array
+func evalPipelineExpression(pipeline *ast.PipelineExpression, env *Environment) Object {
+	val := Eval(pipeline.Source, env)
+
+	for i := 0; i < len(pipeline.Stages); i++ {
+		if isError(val) {
+			return val
+		}
+
+		switch val.(type) {
+		case *Array, *Stream:
+			planned := planStream(pipeline.Stages[i:], pipeline.Token, val, env)
+			if len(planned) > 0 && runsConcurrently(planned, env) {
+				stages, err := resolveStream(planned, val, env)
+				if err != nil {
+					return err
+				}
+
+				// Annotations alone pass the value on, the same way they do when they run sequentially
+				if len(stages) > 0 {
+					val = streamConcurrently(pipeline.Token, stages, val, env)
+				}
+				i += len(planned) - 1
+				continue
+			}
+		}
+
+		val = evalPipelineStage(pipeline.Stages[i], pipeline.Token, val, env)
+	}
+
+	if stream, ok := val.(*Stream); ok {
+		return collectStream(pipeline.Token, stream, env.guard)
+	}
+
+	return val
+}
+
+// evalPipelineStage passes the value to a single stage of the pipeline. Value is bound to "@" inside the stage, so:
+//   - Stage that evaluates to a function is called with the value, e.g. "x -> fn (v) { v * 2 }"
+//   - Call that does not reference "@" gets the value as the first argument, e.g. "x -> add(1)" is "add(x, 1)",
+//     unless it already has all parameters of the function. Then it's evaluated and the function it returns
+//     is called with the value, e.g. "x -> times(2)" is "times(2)(x)". Such call that returns anything else
+//     would drop the value, so it's an error
+//   - Call of the stage function (@fn) passes arguments to its parameters, e.g. "x -> scale(2)"
+//   - Any other expression is just evaluated, e.g. "x -> @ * 2"
+//
+// Stage functions that receive an array are applied to each element, so the array is streamed through them.
+// Stage functions that receive a stream return a stream, which calls them for the values as they are pulled
+func evalPipelineStage(stage ast.Expression, tok token.Token, val Object, env *Environment) Object {
+	stageEnv := NewEnclosedEnvironment(env)
+	stageEnv.Set(PIPELINE_CONTEXT, val)
+
+	var result Object
+	if call, ok := stage.(*ast.CallExpression); ok {
+		fn := Eval(call.CalledExpression, stageEnv)
+		if isError(fn) {
+			return fn
+		}
+
+		args, err := evalExpressions(call.Arguments, stageEnv)
+		if err != nil {
+			return err
+		}
+
+		if isStageFunction(fn) || !referencesContext(call) && !FillsParameters(fn, len(args)) {
+			return applyStage(call.Token, fn, val, args, env.guard)
+		}
+
+		result = applyFunction(call.Token, fn, args, env.guard)
+		if !isError(result) && !referencesContext(call) && !isFunction(result) {
+			return newError(call.Token, COMPLETE_STAGE_CALL)
+		}
+	} else {
+		result = Eval(stage, stageEnv)
+	}
+
+	switch result.(type) {
+	case *Function, *Builtin:
+		return applyStage(stageToken(stage, tok), result, val, nil, env.guard)
+	}
+
+	return result
+}
+
+// Error of the stage that is a complete call, which result could not get the value passed through pipeline
+const COMPLETE_STAGE_CALL = "stage is a complete call; use @ or drop an argument"
+
+// Calls the function with the value followed by additional arguments.
+// Stage function is called with the value bound to "@" and arguments passed to its parameters
+func applyStage(tok token.Token, fn Object, val Object, args []Object, g *Guard) Object {
+	f, ok := fn.(*Function)
+	if !ok || !f.IsPipelineStage {
+		return applyFunction(tok, fn, append([]Object{val}, args...), g)
+	}
+
+	// Arguments are checked before the array is streamed, so the error is reported even for an empty one
+	if err := checkArguments(tok, f, args); err != nil {
+		return err
+	}
+
+	if stream, ok := val.(*Stream); ok {
+		return mapStream(stream, func(e Object) Object { return callFunction(tok, f, e, args, g) })
+	}
+
+	array, isArray := val.(*Array)
+	if !isArray {
+		return callFunction(tok, f, val, args, g)
+	}
+
+	elements := make([]Object, 0, len(array.Elements))
+	for _, e := range array.elements() {
+		result := callFunction(tok, f, e, args, g)
+		if isError(result) {
+			return result
+		}
+
+		elements = append(elements, result)
+	}
+
+	return g.allocate(tok, &Array{Elements: elements})
+}
+
+// FillsParameters Tells if the amount of arguments is what the function expects, so the call is complete
+// without the value passed through pipeline. Builtin functions accept any amount, so their calls are never complete
+func FillsParameters(fn Object, args int) bool {
+	f, ok := fn.(*Function)
+	return ok && len(f.Parameters) == args
+}
+
+func isFunction(obj Object) bool {
+	switch obj.(type) {
+	case *Function, *Builtin:
+		return true
+	}
+
+	return false
+}
+
+func isStageFunction(obj Object) bool {
+	fn, ok := obj.(*Function)
+	return ok && fn.IsPipelineStage
+}
+
+// Checks if pipeline context is used anywhere inside the node
+func referencesContext(node ast.Node) bool {
+	found := false
+
+	ast.Walk(node, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Identifier); ok && ident.Value == PIPELINE_CONTEXT {
+			found = true
+		}
+
+		return !found
+	})
+
+	return found
+}
+
+// Returns token that represents the stage for error reporting, or the fallback if stage has no single token
+func stageToken(stage ast.Expression, fallback token.Token) token.Token {
+	switch stage := stage.(type) {
+	case *ast.Identifier:
+		return stage.Token
+	case *ast.FunctionLiteral:
+		return stage.Token
+	case *ast.CallExpression:
+		return stage.Token
+	}
+
+	return fallback
+}
@@ -0,0 +1,32 @@
+package evaluator
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/token"
+)
+
+func evalPrefixExpression(exp *ast.PrefixExpression, env *Environment) Object {
+	operand := Eval(exp.Operand, env)
+	if isError(operand) {
+		return operand
+	}
+
+	return evalPrefixOperator(exp.Token, operand)
+}
+
+// evalPrefixOperator applies unary operator to already evaluated operand
+func evalPrefixOperator(tok token.Token, operand Object) Object {
+	switch tok.Type {
+	case token.NOT:
+		return nativeBoolToObject(!isTruthy(operand))
+	case token.MINUS:
+		switch operand := operand.(type) {
+		case *Integer:
+			return &Integer{Value: -operand.Value}
+		case *Float:
+			return &Float{Value: -operand.Value}
+		}
+	}
+
+	return newError(tok, "unknown operator: %s%s", tok.Literal, typeOf(operand))
+}
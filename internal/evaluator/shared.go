@@ -0,0 +1,135 @@
+package evaluator
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/token"
+)
+
+// #####################
+// Operations on already evaluated values. They are exported for other execution engines (e.g. bytecode VM),
+// so values behave the same way regardless of how the program is run
+// #####################
+
+// Infix Applies binary operator of the token to the operands
+func Infix(tok token.Token, left, right Object) Object {
+	return evalInfixOperator(tok, left, right)
+}
+
+// Prefix Applies unary operator of the token to the operand
+func Prefix(tok token.Token, operand Object) Object {
+	return evalPrefixOperator(tok, operand)
+}
+
+// Index Returns element of the collection
+func Index(tok token.Token, left, index Object) Object {
+	return evalIndex(tok, left, index)
+}
+
+// Slice Takes part of the collection between bounds, nil bounds are omitted
+func Slice(tok token.Token, left, low, high Object) Object {
+	return evalSlice(tok, left, low, high)
+}
+
+// Member Returns named member of the value, e.g. export of the module
+func Member(tok token.Token, object Object, name string) Object {
+	return evalMember(tok, object, name)
+}
+
+// SetIndex Replaces element of the collection, returns the stored value
+func SetIndex(tok token.Token, left, index, val Object) Object {
+	result, _ := evalSetIndex(tok, left, index, val)
+	return result
+}
+
+// SetMember Changes named member of the value, returns the stored value
+func SetMember(tok token.Token, object Object, name string, val Object) Object {
+	result, _ := evalSetMember(tok, object, name, val)
+	return result
+}
+
+// Iterate Returns *Iterator over the elements of the collection, or *Error if it could not be iterated
+func Iterate(tok token.Token, iterable Object) Object {
+	it, err := newIterator(tok, iterable)
+	if err != nil {
+		return err
+	}
+
+	return it
+}
+
+// Match Tells if the value matches the pattern and returns values of the names bound by it, in order of ast.PatternNames
+func Match(pattern ast.Pattern, val Object) ([]Object, bool) {
+	return matchPattern(pattern, val, nil)
+}
+
+// SetHashPair Adds the pair to the hash, returns error if the key could not be used in hashes
+func SetHashPair(tok token.Token, hash *Hash, key, val Object) *Error {
+	_, err := setHashPair(tok, hash, key, val)
+	return err
+}
+
+// LookupBuiltin Returns builtin function or module with the name
+func LookupBuiltin(name string) (Object, bool) {
+	return lookupBuiltin(name)
+}
+
+// IsTruthy Tells if the value is considered true in conditions
+func IsTruthy(obj Object) bool {
+	return isTruthy(obj)
+}
+
+// NativeBool Returns boolean object for the value
+func NativeBool(v bool) *Boolean {
+	return nativeBoolToObject(v)
+}
+
+// DisplayString Returns text of the value as it appears in string interpolation
+func DisplayString(obj Object) string {
+	return toDisplayString(obj)
+}
+
+// TypeOf Returns type of the value for error messages
+func TypeOf(obj Object) ObjectType {
+	return typeOf(obj)
+}
+
+// NewError Creates runtime error caused by the token
+func NewError(tok token.Token, format string, a ...any) *Error {
+	return newError(tok, format, a...)
+}
+
+// EnterCall Counts the function call under the guard, returns error if the calls are nested deeper than its limit.
+// Call should be followed by LeaveCall once the function returns
+func EnterCall(tok token.Token, g *Guard) *Error {
+	return g.enter(tok)
+}
+
+// LeaveCall Counts return from the function call, see EnterCall
+func LeaveCall(g *Guard) {
+	g.leave()
+}
+
+// Collect Returns array of the values of the stream pulled under the guard, other objects are returned as they are.
+// Pipelines collect the streams they end with
+func Collect(tok token.Token, obj Object, g *Guard) Object {
+	if stream, ok := obj.(*Stream); ok {
+		return collectStream(tok, stream, g)
+	}
+
+	return obj
+}
+
+// MapStream Returns stream of the values fn returns for the values of the stream, see Stream
+func MapStream(s *Stream, fn func(val Object) Object) *Stream {
+	return mapStream(s, fn)
+}
+
+// ReferencesContext Tells if the pipeline context is used anywhere inside the node
+func ReferencesContext(node ast.Node) bool {
+	return referencesContext(node)
+}
+
+// StageToken Returns token that represents the pipeline stage in errors
+func StageToken(stage ast.Expression, fallback token.Token) token.Token {
+	return stageToken(stage, fallback)
+}
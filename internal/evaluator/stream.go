@@ -0,0 +1,441 @@
+package evaluator
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"oilang/internal/token"
+	"os"
+	"strings"
+)
+
+// Stream is a lazy sequence of values, which are produced one by one when they are pulled, e.g. lines of the file.
+// Stream could be pulled only once. Pipeline collects the stream it ends with into an array, so values flow
+// through its stages one by one and the source is read only as far as the stages need
+type Stream struct {
+	next func() Object
+	// Releases resources of the stream, nil if it has none
+	stop func()
+	over bool
+}
+
+func (*Stream) Type() ObjectType { return STREAM_OBJ }
+func (*Stream) Inspect() string  { return "stream" }
+
+// Next Returns the next value, nil when the stream is over, or *Error if it has failed. Stream is over after the error
+func (s *Stream) Next() Object {
+	if s.over {
+		return nil
+	}
+
+	val := s.next()
+	if val == nil || isError(val) {
+		s.Stop()
+	}
+
+	return val
+}
+
+// Stop Ends the stream before it's over, e.g. when the rest of its values is not needed
+func (s *Stream) Stop() {
+	s.over = true
+	if s.stop != nil {
+		s.stop()
+		s.stop = nil
+	}
+}
+
+// Returns the stream or the stream over elements of the array. Other values could not be streamed
+func toStream(tok token.Token, name string, obj Object) (*Stream, *Error) {
+	switch obj := obj.(type) {
+	case *Stream:
+		return obj, nil
+	case *Array:
+		size := len(obj.Elements)
+		i := 0
+		return &Stream{next: func() Object {
+			if i >= size {
+				return nil
+			}
+
+			i++
+			return obj.element(i - 1)
+		}}, nil
+	}
+
+	return nil, newError(tok, "argument to %s must be ARRAY or STREAM, got %s", name, typeOf(obj))
+}
+
+// Pulls the rest of the stream into an array. Each value is counted as a step and as an element, so endless stream could be stopped
+func collectStream(tok token.Token, s *Stream, g *Guard) Object {
+	elements := make([]Object, 0)
+	for {
+		if err := g.step(tok); err != nil {
+			s.Stop()
+			return err
+		}
+
+		val := s.Next()
+		if val == nil {
+			break
+		}
+		if isError(val) {
+			s.Stop()
+			return val
+		}
+
+		// Elements are counted as they come, so collecting the endless stream stops at the limit
+		if err := g.allocateElements(tok, 1, val); isError(err) {
+			s.Stop()
+			return err
+		}
+		elements = append(elements, val)
+	}
+
+	return &Array{Elements: elements}
+}
+
+// Returns stream of the values fn returns for the values of the stream
+func mapStream(s *Stream, fn func(val Object) Object) *Stream {
+	return &Stream{stop: s.Stop, next: func() Object {
+		val := s.Next()
+		if val == nil || isError(val) {
+			return val
+		}
+
+		return fn(val)
+	}}
+}
+
+// Applies the combinator to the stream of the source. Combinators are lazy only for streams,
+// arrays are combined right away into arrays under the guard
+func combine(tok token.Token, g *Guard, name string, source Object, combinator func(s *Stream) *Stream) Object {
+	s, err := toStream(tok, name, source)
+	if err != nil {
+		return err
+	}
+
+	result := combinator(s)
+	if _, ok := source.(*Array); ok {
+		return collectStream(tok, result, g)
+	}
+
+	return result
+}
+
+// Checks that the argument is an integer that is not less than min
+func countArgument(tok token.Token, name string, arg Object, min int64) (int64, *Error) {
+	n, ok := arg.(*Integer)
+	if !ok {
+		return 0, newError(tok, "argument to %s must be INTEGER, got %s", name, typeOf(arg))
+	}
+	if n.Value < min {
+		return 0, newError(tok, "argument to %s must be at least %d, got %d", name, min, n.Value)
+	}
+
+	return n.Value, nil
+}
+
+// range returns stream of integers from a up to b, not including b
+func builtinRange(tok token.Token, args ...Object) Object {
+	if err := checkArgsAmount(tok, args, 2); err != nil {
+		return err
+	}
+
+	bounds := make([]int64, 0, 2)
+	for _, arg := range args {
+		n, ok := arg.(*Integer)
+		if !ok {
+			return newError(tok, "argument to range must be INTEGER, got %s", typeOf(arg))
+		}
+		bounds = append(bounds, n.Value)
+	}
+
+	i := bounds[0]
+	return &Stream{next: func() Object {
+		if i >= bounds[1] {
+			return nil
+		}
+
+		i++
+		return &Integer{Value: i - 1}
+	}}
+}
+
+// lines returns stream of lines of the file. File is read as the stream is pulled and closed once it's over
+func builtinLines(tok token.Token, args ...Object) Object {
+	return readLines(tok, args, func(name string) (io.ReadCloser, error) { return os.Open(name) })
+}
+
+// LinesFrom Returns lines function that reads files of the file system instead of the host ones,
+// so the program could not read files outside of it
+func LinesFrom(files fs.FS) *Builtin {
+	return &Builtin{Name: "lines", Fn: func(tok token.Token, args ...Object) Object {
+		return readLines(tok, args, func(name string) (io.ReadCloser, error) { return files.Open(name) })
+	}}
+}
+
+func readLines(tok token.Token, args []Object, open func(name string) (io.ReadCloser, error)) Object {
+	if err := checkArgsAmount(tok, args, 1); err != nil {
+		return err
+	}
+
+	path, ok := args[0].(*String)
+	if !ok {
+		return newError(tok, "argument to lines must be STRING, got %s", typeOf(args[0]))
+	}
+
+	file, err := open(path.Value)
+	if err != nil {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
+		}
+
+		return newError(tok, "could not read %s: %v", path.Value, err)
+	}
+
+	reader := bufio.NewReader(file)
+	return &Stream{
+		next: func() Object { return readLine(tok, path.Value, reader) },
+		stop: func() { _ = file.Close() },
+	}
+}
+
+// StdinFrom Creates stdin module that reads the input from the reader. Its lines function streams lines of the input
+func StdinFrom(r io.Reader) *Module {
+	reader := bufio.NewReader(r)
+
+	return &Module{Path: "stdin", Exports: map[string]Object{
+		"lines": &Builtin{Name: "lines", Fn: func(tok token.Token, args ...Object) Object {
+			if err := checkArgsAmount(tok, args, 0); err != nil {
+				return err
+			}
+
+			return &Stream{next: func() Object { return readLine(tok, "stdin", reader) }}
+		}},
+	}}
+}
+
+// Returns the next line without its line ending, nil at the end of the input
+func readLine(tok token.Token, name string, reader *bufio.Reader) Object {
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return newError(tok, "could not read %s: %v", name, err)
+	}
+	if err == io.EOF && line == "" {
+		return nil
+	}
+
+	return &String{Value: strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")}
+}
+
+// map returns what the function returns for each element of the array or stream
+func builtinMap(tok token.Token, call Caller, g *Guard, args ...Object) Object {
+	if err := checkArgsAmount(tok, args, 2); err != nil {
+		return err
+	}
+
+	fn := args[1]
+	return combine(tok, g, "map", args[0], func(s *Stream) *Stream {
+		return mapStream(s, func(val Object) Object { return call(tok, fn, val) })
+	})
+}
+
+// filter returns elements of the array or stream for which the function returns a truthy value
+func builtinFilter(tok token.Token, call Caller, g *Guard, args ...Object) Object {
+	if err := checkArgsAmount(tok, args, 2); err != nil {
+		return err
+	}
+
+	fn := args[1]
+	return combine(tok, g, "filter", args[0], func(s *Stream) *Stream {
+		return &Stream{stop: s.Stop, next: func() Object {
+			for {
+				val := s.Next()
+				if val == nil || isError(val) {
+					return val
+				}
+
+				keep := call(tok, fn, val)
+				if isError(keep) {
+					return keep
+				}
+				if isTruthy(keep) {
+					return val
+				}
+			}
+		}}
+	})
+}
+
+// take returns the first n elements of the array or stream. Stream is not pulled further
+func builtinTake(tok token.Token, g *Guard, args ...Object) Object {
+	if err := checkArgsAmount(tok, args, 2); err != nil {
+		return err
+	}
+
+	n, err := countArgument(tok, "take", args[1], 0)
+	if err != nil {
+		return err
+	}
+
+	return combine(tok, g, "take", args[0], func(s *Stream) *Stream {
+		taken := int64(0)
+		return &Stream{stop: s.Stop, next: func() Object {
+			if taken >= n {
+				return nil
+			}
+
+			taken++
+			return s.Next()
+		}}
+	})
+}
+
+// skip returns elements of the array or stream after the first n
+func builtinSkip(tok token.Token, g *Guard, args ...Object) Object {
+	if err := checkArgsAmount(tok, args, 2); err != nil {
+		return err
+	}
+
+	n, err := countArgument(tok, "skip", args[1], 0)
+	if err != nil {
+		return err
+	}
+
+	return combine(tok, g, "skip", args[0], func(s *Stream) *Stream {
+		skipped := int64(0)
+		return &Stream{stop: s.Stop, next: func() Object {
+			for ; skipped < n; skipped++ {
+				if val := s.Next(); val == nil || isError(val) {
+					return val
+				}
+			}
+
+			return s.Next()
+		}}
+	})
+}
+
+// chunk splits elements of the array or stream into arrays of n elements. The last one could be shorter
+func builtinChunk(tok token.Token, g *Guard, args ...Object) Object {
+	if err := checkArgsAmount(tok, args, 2); err != nil {
+		return err
+	}
+
+	n, err := countArgument(tok, "chunk", args[1], 1)
+	if err != nil {
+		return err
+	}
+
+	return combine(tok, g, "chunk", args[0], func(s *Stream) *Stream {
+		return &Stream{stop: s.Stop, next: func() Object {
+			elements := make([]Object, 0, n)
+			for int64(len(elements)) < n {
+				val := s.Next()
+				if isError(val) {
+					return val
+				}
+				if val == nil {
+					break
+				}
+
+				// Elements are counted as they come, so the chunk stops growing at the limit
+				if err := g.allocateElements(tok, 1, val); isError(err) {
+					return err
+				}
+				elements = append(elements, val)
+			}
+
+			if len(elements) == 0 {
+				return nil
+			}
+
+			return &Array{Elements: elements}
+		}}
+	})
+}
+
+// window returns arrays of n consecutive elements of the array or stream, moving by one element
+func builtinWindow(tok token.Token, g *Guard, args ...Object) Object {
+	if err := checkArgsAmount(tok, args, 2); err != nil {
+		return err
+	}
+
+	n, err := countArgument(tok, "window", args[1], 1)
+	if err != nil {
+		return err
+	}
+
+	return combine(tok, g, "window", args[0], func(s *Stream) *Stream {
+		var window []Object
+		return &Stream{stop: s.Stop, next: func() Object {
+			if len(window) > 0 {
+				window = window[1:]
+			}
+
+			for int64(len(window)) < n {
+				val := s.Next()
+				if val == nil || isError(val) {
+					return val
+				}
+
+				window = append(window, val)
+			}
+
+			elements := make([]Object, n)
+			copy(elements, window)
+			return g.allocate(tok, &Array{Elements: elements})
+		}}
+	})
+}
+
+// zip returns arrays of elements at the same positions of the arrays or streams, until the shortest of them is over.
+// Result is a stream if any of them is a stream
+func builtinZip(tok token.Token, g *Guard, args ...Object) Object {
+	if len(args) < 2 {
+		return newError(tok, "wrong number of arguments: expected at least 2, got %d", len(args))
+	}
+
+	streams := make([]*Stream, 0, len(args))
+	lazy := false
+	for _, arg := range args {
+		s, err := toStream(tok, "zip", arg)
+		if err != nil {
+			return err
+		}
+
+		_, isStream := arg.(*Stream)
+		lazy = lazy || isStream
+		streams = append(streams, s)
+	}
+
+	zipped := &Stream{
+		stop: func() {
+			for _, s := range streams {
+				s.Stop()
+			}
+		},
+		next: func() Object {
+			elements := make([]Object, 0, len(streams))
+			for _, s := range streams {
+				val := s.Next()
+				if val == nil || isError(val) {
+					return val
+				}
+
+				elements = append(elements, val)
+			}
+
+			return g.allocate(tok, &Array{Elements: elements})
+		},
+	}
+
+	if !lazy {
+		return collectStream(tok, zipped, g)
+	}
+
+	return zipped
+}
@@ -0,0 +1,22 @@
+package evaluator
+
+import (
+	"oilang/internal/ast"
+	"strings"
+)
+
+// Interpolated values are converted to strings and concatenated with the text parts
+func evalTemplateLiteral(tmpl *ast.TemplateLiteral, env *Environment) Object {
+	var out strings.Builder
+
+	for _, part := range tmpl.Parts {
+		val := Eval(part, env)
+		if isError(val) {
+			return val
+		}
+
+		out.WriteString(toDisplayString(val))
+	}
+
+	return env.guard.allocate(tmpl.Token, &String{Value: out.String()})
+}
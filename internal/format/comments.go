@@ -0,0 +1,233 @@
+package format
+
+import (
+	"oilang/internal/lexer"
+	"oilang/internal/token"
+	"sort"
+	"strings"
+)
+
+// position is a place in the source that could be compared with other positions
+type position struct {
+	line int
+	col  int
+}
+
+func positionOf(tok token.Token) position {
+	return position{tok.Line, tok.Col}
+}
+
+func commentPosition(c token.Comment) position {
+	return position{c.Line, c.Col}
+}
+
+func (p position) before(other position) bool {
+	return p.line < other.line || p.line == other.line && p.col < other.col
+}
+
+// source holds tokens and comments of the formatted program.
+// Parser does not keep comments in the AST, so they are taken from the tokens by their positions. Comments between
+// elements of lists, parameters and pipeline stages are placed next to them, others are placed between the statements
+type source struct {
+	tokens []token.Token
+	// Comments that are not printed yet, in order of their appearance
+	comments []token.Comment
+	// Last line of the source that is printed
+	lastLine int
+	// Comments that could not be kept at their places inside of the statement, which are placed before it
+	deferred []token.Comment
+}
+
+func newSource(src string) *source {
+	s := &source{}
+
+	l := lexer.New(src)
+	for {
+		tok := l.NextToken()
+		s.tokens = append(s.tokens, tok)
+		s.comments = append(s.comments, tok.Comments()...)
+
+		if tok.Type == token.EOF {
+			return s
+		}
+	}
+}
+
+// Takes all comments placed before the position
+func (s *source) takeBefore(pos position) []token.Comment {
+	return s.take(func(c token.Comment) bool { return commentPosition(c).before(pos) })
+}
+
+// Takes comments of the statement that ends before the limit. Comments on the last line of the statement are trailing,
+// others are placed inside of the statement (e.g. between elements of the array), which formatter could not preserve at their places
+func (s *source) takeStatementComments(limit position, lastLine int) (inner, trailing []token.Comment) {
+	inner, s.deferred = s.deferred, nil
+	for _, c := range s.take(func(c token.Comment) bool {
+		return c.Line <= lastLine && commentPosition(c).before(limit)
+	}) {
+		if c.Line == lastLine {
+			trailing = append(trailing, c)
+		} else {
+			inner = append(inner, c)
+		}
+	}
+
+	return inner, trailing
+}
+
+// gap holds comments between two parts of the expression, e.g. elements of the array,
+// split by the separator that precedes the next part, e.g. "," or "->"
+type gap struct {
+	// Comments before the separator
+	before []token.Comment
+	// Comments after the separator on the same line with it
+	head []token.Comment
+	// Comments on their own lines after the separator
+	leading []token.Comment
+}
+
+// Takes comments placed before the position, which is the start of the next part of the expression or the closing bracket
+func (s *source) takeGap(limit position) gap {
+	var g gap
+
+	sep := s.tokens[s.separator(limit)]
+	for _, c := range s.takeBefore(limit) {
+		switch {
+		case commentPosition(c).before(positionOf(sep)):
+			g.before = append(g.before, c)
+		case c.Line == sep.Line:
+			g.head = append(g.head, c)
+		default:
+			g.leading = append(g.leading, c)
+		}
+	}
+
+	return g
+}
+
+// Returns index of the last significant token before the position, e.g. "," before the element of the array
+func (s *source) separator(limit position) int {
+	i := s.index(limit) - 1
+	for i > 0 && s.tokens[i].Type == token.NEWLINE {
+		i--
+	}
+
+	return i
+}
+
+// Returns index of the first token that is not before the position
+func (s *source) index(pos position) int {
+	return sort.Search(len(s.tokens), func(i int) bool { return !positionOf(s.tokens[i]).before(pos) })
+}
+
+// Returns position of the first token of the type that is not before the position, e.g. the opening bracket
+func (s *source) find(from position, typ token.TokenType) position {
+	i := s.index(from)
+	for i < len(s.tokens)-1 && s.tokens[i].Type != typ {
+		i++
+	}
+
+	return positionOf(s.tokens[i])
+}
+
+// Returns position of the bracket that closes the one at the position
+func (s *source) closing(open position) position {
+	depth := 0
+	for i := s.index(open); i < len(s.tokens); i++ {
+		switch s.tokens[i].Type {
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			depth--
+		}
+
+		if depth == 0 {
+			return positionOf(s.tokens[i])
+		}
+	}
+
+	return positionOf(s.tokens[len(s.tokens)-1])
+}
+
+// Tells if the comment follows another token on the same line, so it stays at the end of that line
+func (s *source) trails(c token.Comment) bool {
+	i := s.separator(commentPosition(c))
+	return i >= 0 && s.tokens[i].Line == c.Line && positionOf(s.tokens[i]).before(commentPosition(c))
+}
+
+// Defers comments placed before the position, so they are placed before the statement that is being printed
+func (s *source) deferBefore(pos position) {
+	s.deferred = append(s.deferred, s.takeBefore(pos)...)
+}
+
+func (s *source) take(matches func(c token.Comment) bool) []token.Comment {
+	n := 0
+	for n < len(s.comments) && matches(s.comments[n]) {
+		n++
+	}
+
+	taken := s.comments[:n]
+	s.comments = s.comments[n:]
+
+	return taken
+}
+
+// Returns the line where the statement that ends before the limit finishes. It's the line of the token that follows
+// the last significant token of the statement, so statements that end with multi-line strings are handled as well
+func (s *source) statementEnd(limit position) int {
+	i := s.index(limit)
+
+	for i--; i > 0; i-- {
+		switch s.tokens[i].Type {
+		case token.NEWLINE, token.SEMICOLON, token.EOF:
+			continue
+		}
+		break
+	}
+
+	return s.tokens[i+1].Line
+}
+
+// Returns the last line the comment occupies
+func commentEnd(c token.Comment) int {
+	return c.Line + strings.Count(c.Text, "\n")
+}
+
+// Checks if the next comment is placed before the position
+func (s *source) startsBefore(pos position) bool {
+	return len(s.comments) > 0 && commentPosition(s.comments[0]).before(pos)
+}
+
+// Tells if the comment has to be followed by the line break
+func isLineComment(c token.Comment) bool {
+	return strings.HasPrefix(c.Text, "//")
+}
+
+// Joins the lists of comments into a new one
+func concat(lists ...[]token.Comment) []token.Comment {
+	var comments []token.Comment
+	for _, list := range lists {
+		comments = append(comments, list...)
+	}
+
+	return comments
+}
+
+func hasLineComment(comments []token.Comment) bool {
+	for _, c := range comments {
+		if isLineComment(c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func commentTexts(comments []token.Comment) []string {
+	texts := make([]string, 0, len(comments))
+	for _, c := range comments {
+		texts = append(texts, c.Text)
+	}
+
+	return texts
+}
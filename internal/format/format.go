@@ -0,0 +1,681 @@
+package format
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/lexer"
+	"oilang/internal/parser"
+	"oilang/internal/token"
+	"strings"
+)
+
+// Indentation of a single nesting level
+const INDENT = "    "
+
+// Collections that are longer than this are split one element per line
+const MAX_WIDTH = 80
+
+// Source Parses the program and renders it in the canonical form.
+// Single blank lines between statements are kept, so the code could still be grouped into paragraphs.
+// Comments between elements of lists, parameters and pipeline stages are kept next to them, others are kept
+// before the statements they precede or at the end of the line they follow
+func Source(src string) (string, parser.ParsingErrors) {
+	program, err := parser.New(lexer.New(src)).Parse()
+	if err != nil {
+		return "", err
+	}
+
+	p := &printer{src: newSource(src)}
+	// Lexer skips the shebang, so it's not a part of any token
+	if strings.HasPrefix(src, "#!") {
+		shebang, _, _ := strings.Cut(src, "\n")
+		p.write(strings.TrimRight(shebang, " \t\r"), "\n")
+	}
+
+	eof := p.src.tokens[len(p.src.tokens)-1]
+	p.statements(program.Statements, positionOf(eof))
+
+	if p.out.Len() > 0 && !strings.HasSuffix(p.String(), "\n") {
+		p.write("\n")
+	}
+
+	return p.String(), nil
+}
+
+// Node Renders a single node in the canonical form
+func Node(node ast.Node) string {
+	p := &printer{}
+	p.node(node)
+
+	return strings.TrimSuffix(p.String(), "\n")
+}
+
+// printer writes nodes into the buffer keeping track of the current indentation
+type printer struct {
+	out    strings.Builder
+	indent int
+	// Original source, used to keep comments and blank lines between statements. Nodes created without the source don't have them
+	src *source
+	// Set while printing if condition, where "{" is the start of the block, so hash literals have to be wrapped in parentheses
+	noHashLiterals bool
+}
+
+func (p *printer) String() string {
+	return p.out.String()
+}
+
+func (p *printer) write(s ...string) {
+	for _, part := range s {
+		p.out.WriteString(part)
+	}
+}
+
+// Starts the new line at the current indentation
+func (p *printer) newline() {
+	p.write("\n", strings.Repeat(INDENT, p.indent))
+}
+
+func (p *printer) node(node ast.Node) {
+	switch node := node.(type) {
+	case *ast.Program:
+		p.statements(node.Statements, position{})
+	case ast.Statement:
+		p.statement(node)
+	case ast.Expression:
+		p.expression(node)
+	}
+}
+
+// Prints each statement and comment on its own line. Statements are followed by comments that end at the limit, e.g. closing brace of the block
+func (p *printer) statements(statements []ast.Statement, end position) {
+	first := true
+	if p.src != nil {
+		// Comments deferred by the statement that holds the block are placed before it, not before the statements of the block
+		outer := p.src.deferred
+		p.src.deferred = nil
+		defer func() { p.src.deferred = append(outer, p.src.deferred...) }()
+	}
+
+	for i, s := range statements {
+		start := positionOf(statementToken(s))
+		limit := end
+		if i+1 < len(statements) {
+			limit = positionOf(statementToken(statements[i+1]))
+		}
+
+		if p.src == nil {
+			p.startLine(&first, start.line)
+			p.statement(s)
+			continue
+		}
+
+		p.comments(&first, p.src.takeBefore(start))
+		p.startLine(&first, start.line)
+
+		// Nested blocks take comments inside of them while the statement is rendered
+		rendered := p.render(func(p *printer) { p.statement(s) })
+		lastLine := p.src.statementEnd(limit)
+		inner, trailing := p.src.takeStatementComments(limit, lastLine)
+
+		for _, c := range inner {
+			p.write(c.Text)
+			p.newline()
+		}
+		p.write(p.reindent(rendered))
+		for _, c := range trailing {
+			p.write(" ", c.Text)
+		}
+
+		p.src.lastLine = lastLine
+	}
+
+	if p.src != nil {
+		p.comments(&first, p.src.takeBefore(end))
+	}
+}
+
+// Prints comments that are placed on their own lines
+func (p *printer) comments(first *bool, comments []token.Comment) {
+	for _, c := range comments {
+		p.startLine(first, c.Line)
+		p.write(c.Text)
+		p.src.lastLine = commentEnd(c)
+	}
+}
+
+// Starts the line for the next statement or comment, unless it's the first one. Single blank line is kept if it was in the source
+func (p *printer) startLine(first *bool, line int) {
+	if *first {
+		*first = false
+		return
+	}
+
+	if p.src != nil && line-p.src.lastLine > 1 {
+		p.write("\n")
+	}
+	p.newline()
+}
+
+func (p *printer) statement(s ast.Statement) {
+	switch s := s.(type) {
+	case *ast.LetStatement:
+		p.write(s.Token.Literal, " ", s.Name.Value)
+		if s.Value != nil {
+			p.write(" = ")
+			p.topLevelExpression(s.Value)
+		}
+	case *ast.ReturnStatement:
+		p.write(s.Token.Literal)
+		if s.ReturnValue != nil {
+			p.write(" ")
+			p.topLevelExpression(s.ReturnValue)
+		}
+	case *ast.ExpressionStatement:
+		p.topLevelExpression(s.Expression)
+	case *ast.ImportStatement:
+		p.write(s.Token.Literal, " ", quote(s.Path.Value), " as ", s.Alias.Value)
+	case *ast.ExportStatement:
+		p.write(s.Token.Literal, " ")
+		p.statement(s.Statement)
+	case *ast.WhileStatement:
+		p.label(s.Label)
+		p.write(s.Token.Literal, " ")
+		p.condition(s.Condition)
+		p.write(" ")
+		p.block(s.Body)
+	case *ast.ForStatement:
+		p.label(s.Label)
+		p.write(s.Token.Literal, " ")
+		if s.Key != nil {
+			p.write(s.Key.Value, ", ")
+		}
+		p.write(s.Value.Value, " in ")
+		p.condition(s.Iterable)
+		p.write(" ")
+		p.block(s.Body)
+	case *ast.BreakStatement:
+		p.write(s.Token.Literal)
+		p.jumpLabel(s.Label)
+	case *ast.ContinueStatement:
+		p.write(s.Token.Literal)
+		p.jumpLabel(s.Label)
+	}
+}
+
+func (p *printer) label(label *ast.Identifier) {
+	if label != nil {
+		p.write(label.Value, ": ")
+	}
+}
+
+func (p *printer) jumpLabel(label *ast.Identifier) {
+	if label != nil {
+		p.write(" ", label.Value)
+	}
+}
+
+// Expression that is the whole value of the statement. Only there pipelines are split one stage per line,
+// because nested pipelines could not be continued on the next line. Value of the assignment is split the same way as value of let
+func (p *printer) topLevelExpression(e ast.Expression) {
+	if assign, ok := e.(*ast.AssignExpression); ok {
+		p.operand(assign.Target, parser.ASSIGN)
+		p.write(" ", assign.Operator(), " ")
+		p.topLevelExpression(assign.Value)
+		return
+	}
+
+	pipeline, ok := e.(*ast.PipelineExpression)
+	if !ok || len(pipeline.Stages) < 2 {
+		p.expression(e)
+		return
+	}
+
+	p.operand(pipeline.Source, parser.PIPE)
+
+	p.indent++
+	for _, stage := range pipeline.Stages {
+		var head []token.Comment
+		if p.src != nil {
+			// Comments at the end of the previous stage stay there, others are placed on their own lines before the stage
+			g := p.src.takeGap(positionOf(expressionToken(stage)))
+			for _, c := range append(g.before, g.leading...) {
+				if p.src.trails(c) {
+					p.write(" ", c.Text)
+					continue
+				}
+
+				p.newline()
+				p.write(c.Text)
+			}
+			for _, c := range g.head {
+				if isLineComment(c) {
+					p.newline()
+					p.write(c.Text)
+				} else {
+					head = append(head, c)
+				}
+			}
+		}
+
+		p.newline()
+		p.write(pipeline.Token.Literal, " ")
+		for _, c := range head {
+			p.write(c.Text, " ")
+		}
+		p.operand(stage, parser.PIPE)
+	}
+	p.indent--
+}
+
+func (p *printer) expression(e ast.Expression) {
+	switch e := e.(type) {
+	case *ast.Identifier:
+		p.write(e.Value)
+	case *ast.IntegerLiteral:
+		p.write(e.Token.Literal)
+	case *ast.FloatLiteral:
+		p.write(e.Token.Literal)
+	case *ast.BoolExpression:
+		p.write(e.Token.Literal)
+	case *ast.StringLiteral:
+		p.write(quote(e.Value))
+	case *ast.TemplateLiteral:
+		p.template(e)
+	case *ast.PrefixExpression:
+		p.prefix(e)
+	case *ast.InfixExpression:
+		precedence := parser.Precedence(e.Token.Type)
+		// Operators are left associative, so the right operand of the same precedence needs parentheses
+		p.operand(e.Left, precedence-1)
+		p.write(" ", e.Operator(), " ")
+		p.operand(e.Right, precedence)
+	case *ast.AssignExpression:
+		// Assignment is right associative, so the value of the same precedence does not need parentheses
+		p.operand(e.Target, parser.ASSIGN)
+		p.write(" ", e.Operator(), " ")
+		p.operand(e.Value, parser.ASSIGN-1)
+	case *ast.PipelineExpression:
+		p.operand(e.Source, parser.PIPE)
+		for _, stage := range e.Stages {
+			p.write(" ", e.Token.Literal, " ")
+			p.operand(stage, parser.PIPE)
+		}
+	case *ast.CallExpression:
+		p.operand(e.CalledExpression, parser.CALL-1)
+		p.list(e.Arguments, e.Token, token.LPAREN, false)
+	case *ast.IndexExpression:
+		p.operand(e.Left, parser.INDEX-1)
+		p.write("[")
+		p.nested(func() { p.expression(e.Index) })
+		p.write("]")
+	case *ast.MemberExpression:
+		p.operand(e.Object, parser.INDEX-1)
+		p.write(".", e.Property.Value)
+	case *ast.SliceExpression:
+		p.operand(e.Left, parser.INDEX-1)
+		p.write("[")
+		p.nested(func() {
+			if e.Low != nil {
+				p.expression(e.Low)
+			}
+			p.write(":")
+			if e.High != nil {
+				p.expression(e.High)
+			}
+		})
+		p.write("]")
+	case *ast.ArrayLiteral:
+		p.list(e.Elements, e.Token, token.LBRACKET, true)
+	case *ast.HashLiteral:
+		p.hash(e)
+	case *ast.IfExpression:
+		p.ifExpression(e)
+	case *ast.MatchExpression:
+		p.match(e)
+	case *ast.FunctionLiteral:
+		p.function(e)
+	}
+}
+
+// Prints the operand wrapping it in parentheses if it binds weaker than the operator it belongs to,
+// i.e. its precedence is not higher than the precedence operand is parsed with
+func (p *printer) operand(e ast.Expression, precedence int) {
+	if expressionPrecedence(e) > precedence {
+		p.expression(e)
+		return
+	}
+
+	p.write("(")
+	p.nested(func() { p.expression(e) })
+	p.write(")")
+}
+
+func (p *printer) prefix(e *ast.PrefixExpression) {
+	p.write(e.Operator())
+	if isWord(e.Operator()) {
+		p.write(" ")
+	}
+
+	// Two minuses in a row would look like a single operator
+	if operand, ok := e.Operand.(*ast.PrefixExpression); ok && operand.Operator() == e.Operator() && !isWord(e.Operator()) {
+		p.write("(")
+		p.expression(operand)
+		p.write(")")
+		return
+	}
+
+	p.operand(e.Operand, parser.PrefixPrecedence(e.Token.Type))
+}
+
+// Prints comma separated expressions inside the brackets. Brackets are the first ones of their type after the token
+func (p *printer) list(items []ast.Expression, from token.Token, bracket token.TokenType, breakLong bool) {
+	open, end := "(", ")"
+	if bracket == token.LBRACKET {
+		open, end = "[", "]"
+	}
+
+	p.items(from, bracket, len(items), func(i int) position {
+		return positionOf(expressionToken(items[i]))
+	}, func(p *printer, i int) {
+		p.expression(items[i])
+	}, open, end, "", breakLong)
+}
+
+// Prints n comma separated items inside the brackets, separating them from the brackets with padding.
+// Items are rendered one by one, and the comments between them are kept next to them, if start of the items is known.
+// If breakLong is set and they don't fit into the line, or a line comment is placed between them,
+// each one is placed on its own line with the trailing comma
+func (p *printer) items(from token.Token, bracket token.TokenType, n int, start func(i int) position, render func(p *printer, i int), open, end, padding string, breakLong bool) {
+	rendered := make([]string, 0, n)
+	// Gap before each item and the one before the closing bracket
+	gaps := make([]gap, n+1)
+	comments := p.src != nil && start != nil
+	var openPos position
+	if comments {
+		openPos = p.src.find(positionOf(from), bracket)
+		p.src.deferBefore(openPos)
+	}
+	for i := 0; i < n; i++ {
+		if comments {
+			gaps[i] = p.src.takeGap(start(i))
+		}
+		rendered = append(rendered, p.render(func(p *printer) { render(p, i) }))
+	}
+	if comments {
+		gaps[n] = p.src.takeGap(p.src.closing(openPos))
+	}
+
+	// Comments before the first item follow the opening bracket, comments that precede the separator or follow it
+	// on the same line follow the item, and comments on their own lines precede the next item or the closing bracket
+	opening := concat(gaps[0].before, gaps[0].head, gaps[0].leading)
+	var closing []token.Comment
+	if n > 0 {
+		opening = concat(gaps[0].before, gaps[0].head)
+		closing = gaps[n].leading
+	}
+
+	// Returns comments that follow the item
+	trailing := func(i int) []token.Comment {
+		if i == n-1 {
+			return concat(gaps[n].before, gaps[n].head)
+		}
+
+		return concat(gaps[i+1].before, gaps[i+1].head)
+	}
+
+	parts := make([]string, 0, n)
+	forced := hasLineComment(opening) || hasLineComment(closing)
+	for i, item := range rendered {
+		leading := gaps[i].leading
+		if i > 0 {
+			// Comments after the separator are printed after ", " anyway
+			leading = concat(gaps[i].head, gaps[i].leading)
+		}
+		after := gaps[i+1].before
+		if i == n-1 {
+			after = trailing(i)
+		}
+
+		parts = append(parts, strings.Join(append(append(commentTexts(leading), item), commentTexts(after)...), " "))
+		forced = forced || hasLineComment(leading) || hasLineComment(trailing(i))
+	}
+
+	words := commentTexts(opening)
+	if n > 0 {
+		words = append(words, strings.Join(parts, ", "))
+	}
+	inline := strings.Join(append(words, commentTexts(closing)...), " ")
+
+	if !forced && (n == 0 || !breakLong || (len(inline) <= MAX_WIDTH && !strings.Contains(inline, "\n"))) {
+		if inline != "" {
+			inline = padding + inline + padding
+		}
+		p.write(open, p.reindent(inline), end)
+		return
+	}
+
+	p.write(open)
+	for _, c := range opening {
+		p.write(" ", c.Text)
+	}
+
+	p.indent++
+	for i, item := range rendered {
+		p.newline()
+		for _, c := range gaps[i].leading {
+			p.write(c.Text)
+			p.newline()
+		}
+		p.write(p.reindent(item), ",")
+		for _, c := range trailing(i) {
+			p.write(" ", c.Text)
+		}
+	}
+	for _, c := range closing {
+		p.newline()
+		p.write(c.Text)
+	}
+	p.indent--
+
+	p.newline()
+	p.write(end)
+}
+
+// Identifier keys are written without quotes, as they are shorthands for the string
+func (p *printer) hash(e *ast.HashLiteral) {
+	wrap := p.noHashLiterals
+	if wrap {
+		p.write("(")
+	}
+
+	p.items(e.Token, token.LBRACE, len(e.Pairs), func(i int) position {
+		return positionOf(expressionToken(e.Pairs[i].Key))
+	}, func(p *printer, i int) {
+		pair := e.Pairs[i]
+		if key, ok := pair.Key.(*ast.StringLiteral); ok && lexer.IsIdentifier(key.Value) {
+			p.write(key.Value)
+		} else {
+			p.expression(pair.Key)
+		}
+		p.write(": ")
+		p.expression(pair.Value)
+	}, "{", "}", " ", true)
+
+	if wrap {
+		p.write(")")
+	}
+}
+
+func (p *printer) ifExpression(e *ast.IfExpression) {
+	p.write(e.Token.Literal, " ")
+	p.condition(e.Condition)
+	p.write(" ")
+	p.block(e.Consequnce)
+
+	if next := e.ElseIf(); next != nil {
+		p.write(" else ")
+		p.ifExpression(next)
+	} else if e.Alternative != nil {
+		p.write(" else ")
+		p.block(e.Alternative)
+	}
+}
+
+// Each arm is printed on its own line
+func (p *printer) match(e *ast.MatchExpression) {
+	p.write(e.Token.Literal, " ")
+	p.condition(e.Subject)
+
+	if len(e.Arms) == 0 {
+		p.write(" {}")
+		return
+	}
+
+	p.write(" {")
+	p.nested(func() {
+		p.indent++
+		for _, arm := range e.Arms {
+			p.newline()
+			p.matchArm(arm)
+		}
+		p.indent--
+	})
+	p.newline()
+	p.write("}")
+}
+
+func (p *printer) matchArm(arm *ast.MatchArm) {
+	for i, pattern := range arm.Patterns {
+		if i > 0 {
+			p.write(" | ")
+		}
+		p.pattern(pattern)
+	}
+
+	if arm.Guard != nil {
+		p.write(" if ")
+		p.expression(arm.Guard)
+	}
+
+	p.write(" => ")
+	if arm.Body != nil {
+		p.block(arm.Body)
+	} else {
+		// "{" after the arrow starts the block, so hash literal has to be wrapped
+		p.condition(arm.Value)
+	}
+}
+
+// Key of the hash pattern that binds the value to the same name is written without the pattern: { name }
+func (p *printer) pattern(pattern ast.Pattern) {
+	switch pattern := pattern.(type) {
+	case *ast.LiteralPattern:
+		p.expression(pattern.Value)
+	case *ast.ArrayPattern:
+		elements := len(pattern.Elements)
+		if pattern.Rest != nil {
+			elements++
+		}
+
+		// Comments inside of patterns are kept before the match arm
+		p.items(pattern.Token, token.LBRACKET, elements, nil, func(p *printer, i int) {
+			if i == len(pattern.Elements) {
+				p.write("...", pattern.Rest.String())
+				return
+			}
+			p.pattern(pattern.Elements[i])
+		}, "[", "]", "", false)
+	case *ast.HashPattern:
+		p.items(pattern.Token, token.LBRACE, len(pattern.Pairs), nil, func(p *printer, i int) {
+			pair := pattern.Pairs[i]
+			if !lexer.IsIdentifier(pair.Key.Value) {
+				p.write(quote(pair.Key.Value), ": ")
+			} else if pair.Value.String() != pair.Key.Value || !isNamePattern(pair.Value) {
+				p.write(pair.Key.Value, ": ")
+			}
+			p.pattern(pair.Value)
+		}, "{", "}", " ", false)
+	default:
+		p.write(pattern.String())
+	}
+}
+
+// Prints expression that is followed by the block, e.g. if condition or iterable of the loop
+func (p *printer) condition(e ast.Expression) {
+	restore := p.noHashLiterals
+	p.noHashLiterals = true
+	p.expression(e)
+	p.noHashLiterals = restore
+}
+
+func (p *printer) function(e *ast.FunctionLiteral) {
+	p.write(e.Token.Literal)
+	if e.Name != nil {
+		p.write(" ", e.Name.Value)
+	}
+
+	p.items(e.Token, token.LPAREN, len(e.Parameters), func(i int) position {
+		return positionOf(e.Parameters[i].Token)
+	}, func(p *printer, i int) {
+		p.write(e.Parameters[i].Value)
+	}, "(", ")", "", false)
+	p.write(" ")
+	p.block(e.Body)
+}
+
+func (p *printer) block(b *ast.BlockStatement) {
+	end := positionOf(b.Rbrace)
+	if p.src != nil {
+		// Comments before the block belong to the statement it's part of, not to the first statement of the block
+		p.src.deferBefore(positionOf(b.Token))
+	}
+	if len(b.Statements) == 0 && (p.src == nil || !p.src.startsBefore(end)) {
+		p.write("{}")
+		return
+	}
+
+	p.write("{")
+	p.nested(func() {
+		p.indent++
+		p.newline()
+		p.statements(b.Statements, end)
+		p.indent--
+	})
+	p.newline()
+	p.write("}")
+}
+
+func (p *printer) template(e *ast.TemplateLiteral) {
+	p.write("\"")
+	for _, part := range e.Parts {
+		if s, ok := part.(*ast.StringLiteral); ok {
+			p.write(escape(s.Value))
+			continue
+		}
+
+		p.write("${")
+		p.nested(func() { p.expression(part) })
+		p.write("}")
+	}
+	p.write("\"")
+}
+
+// Runs the function outside of the if condition, so hash literals could be written as is, e.g. inside brackets
+func (p *printer) nested(fn func()) {
+	restore := p.noHashLiterals
+	p.noHashLiterals = false
+	fn()
+	p.noHashLiterals = restore
+}
+
+// Renders the node into a separate buffer at zero indentation, so its length could be measured before printing
+func (p *printer) render(fn func(p *printer)) string {
+	sub := &printer{src: p.src}
+	fn(sub)
+
+	return sub.String()
+}
+
+// Shifts the rendered lines to the current indentation
+func (p *printer) reindent(s string) string {
+	return strings.ReplaceAll(s, "\n", "\n"+strings.Repeat(INDENT, p.indent))
+}
@@ -0,0 +1,131 @@
+package format
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x=1;let y = x", "let x = 1\nlet y = x\n"},
+		{"let x = 1\n\n\n\nlet y = 2", "let x = 1\n\nlet y = 2\n"},
+		{"(1 + 2) * 3", "(1 + 2) * 3\n"},
+		{"1 + (2 * 3)", "1 + 2 * 3\n"},
+		{"(1 - 2) - 3", "1 - 2 - 3\n"},
+		{"1 - (2 - 3)", "1 - (2 - 3)\n"},
+		{"(-x) ** 2", "(-x) ** 2\n"},
+		{"-(x ** 2)", "-x ** 2\n"},
+		{"-(-x)", "-(-x)\n"},
+		{"not (a and b) or c", "not (a and b) or c\n"},
+		{"(a -> b) + 1", "(a -> b) + 1\n"},
+		{"f(x)[0][1:]", "f(x)[0][1:]\n"},
+		{"(f)(1)", "f(1)\n"},
+		{`"a\"b\n\${x}" + "${x + 1}!"`, `"a\"b\n\${x}" + "${x + 1}!"` + "\n"},
+		{`{name: 1, "with space": 2, "if": 3}`, `{ name: 1, "with space": 2, "if": 3 }` + "\n"},
+		{`if ({a: 1})["a"] { 1 }`, "if ({ a: 1 })[\"a\"] {\n    1\n}\n"},
+		{"[]; {}", "[]\n{}\n"},
+		{
+			"fn add(a,b){ return a+b }",
+			"fn add(a, b) {\n    return a + b\n}\n",
+		},
+		{
+			"if x > 1 { if y { 1 } } else { 2 }",
+			"if x > 1 {\n    if y {\n        1\n    }\n} else {\n    2\n}\n",
+		},
+		{
+			"let fs = [fn(x) { x }, 2]",
+			"let fs = [\n    fn(x) {\n        x\n    },\n    2,\n]\n",
+		},
+		{
+			"let xs = [1111111111, 2222222222, 3333333333, 4444444444, 5555555555, 6666666666, 7777777777]",
+			"let xs = [\n    1111111111,\n    2222222222,\n    3333333333,\n    4444444444,\n    5555555555,\n    6666666666,\n    7777777777,\n]\n",
+		},
+		{"xs -> sum", "xs -> sum\n"},
+		{
+			"let s = [1, 2] -> map(@fn() { @ * 2 }) -> sum",
+			"let s = [1, 2]\n    -> map(@fn() {\n        @ * 2\n    })\n    -> sum\n",
+		},
+		{"print(xs -> a -> b)", "print(xs -> a -> b)\n"},
+		{`import "./lib/text.oi"as text;text.trim(s)`, "import \"./lib/text.oi\" as text\ntext.trim(s)\n"},
+		{"export let x=1;export fn f(){ x }", "export let x = 1\nexport fn f() {\n    x\n}\n"},
+		{"(a + b).c; a.b.c(1).d", "(a + b).c\na.b.c(1).d\n"},
+		{"while x>0 { x }", "while x > 0 {\n    x\n}\n"},
+		{"outer:\nfor i,x in ({a: 1}) { if x { break outer }; continue }", "outer: for i, x in ({ a: 1 }) {\n    if x {\n        break outer\n    }\n    continue\n}\n"},
+		{"for x in xs {}", "for x in xs {}\n"},
+		{"x=1;xs[i]+=2;h.a**=(b = 3)", "x = 1\nxs[i] += 2\nh.a **= b = 3\n"},
+		{"(x = 1) + 2; f(x -= 1)", "(x = 1) + 2\nf(x -= 1)\n"},
+		{"const c=1;export const d=c", "const c = 1\nexport const d = c\n"},
+		{"if a { 1 } else if b { 2 } else { 3 }", "if a {\n    1\n} else if b {\n    2\n} else {\n    3\n}\n"},
+		{"if a { 1 } else { if b { 2 } }", "if a {\n    1\n} else {\n    if b {\n        2\n    }\n}\n"},
+		{
+			`let r = match x { 1|2=>"a", -1 => ({}), [h,...t] if h>1 => h, {name:name, "a b":[_, ..._], k: v} => { v }, _ => 0 }`,
+			"let r = match x {\n    1 | 2 => \"a\"\n    -1 => ({})\n    [h, ...t] if h > 1 => h\n    { name, \"a b\": [_, ..._], k: v } => {\n        v\n    }\n    _ => 0\n}\n",
+		},
+		{"match ({a: 1}) {}", "match ({ a: 1 }) {}\n"},
+		{"s = [1, 2] -> map(double) -> sum", "s = [1, 2]\n    -> map(double)\n    -> sum\n"},
+	}
+
+	for _, test := range tests {
+		result, err := Source(test.input)
+		assert.Nil(t, err, test.input)
+		assert.Equal(t, test.expected, result, test.input)
+
+		// Formatting is stable
+		again, err := Source(result)
+		assert.Nil(t, err, result)
+		assert.Equal(t, result, again, result)
+	}
+}
+
+func TestFormatInvalidSource(t *testing.T) {
+	_, err := Source("let = 1")
+	assert.Len(t, err, 1)
+}
+
+func TestFormatComments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"// only comment", "// only comment\n"},
+		{"#!/usr/bin/env oi\nlet x = 1", "#!/usr/bin/env oi\nlet x = 1\n"},
+		{"// header\n\n\nlet x=1 // one\n/* two */ let y=2", "// header\n\nlet x = 1 // one\n/* two */\nlet y = 2\n"},
+		{
+			"fn f(a) { // opens\n// inside\nreturn a // ret\n\n// before end\n}",
+			"fn f(a) {\n    // opens\n    // inside\n    return a // ret\n\n    // before end\n}\n",
+		},
+		{"fn f() {\n// nothing\n}", "fn f() {\n    // nothing\n}\n"},
+		{"if x { 1 } // done\n// end", "if x {\n    1\n} // done\n// end\n"},
+		{"let xs = [\n1, // first\n2\n] // after", "let xs = [\n    1, // first\n    2,\n] // after\n"},
+		{"/* nested /* block */ comment */", "/* nested /* block */ comment */\n"},
+		{"xs\n// stage\n-> a\n-> b", "xs\n    // stage\n    -> a\n    -> b\n"},
+
+		// Comments inside of expressions are kept next to the nodes they follow or precede
+		{"fn f(a /* first */, b) { a }", "fn f(a /* first */, b) {\n    a\n}\n"},
+		{"let xs = [\n 1, // one\n 2,\n]", "let xs = [\n    1, // one\n    2,\n]\n"},
+		{"let xs = [ // items\n1,\n// two\n2\n// end\n]", "let xs = [ // items\n    1,\n    // two\n    2,\n    // end\n]\n"},
+		{"f(1, /* two */ 2 /* after */)", "f(1, /* two */ 2 /* after */)\n"},
+		{"f(1, // one\n2)", "f(\n    1, // one\n    2,\n)\n"},
+		{"let h = {a: 1, /* b */ b: 2}", "let h = { a: 1, /* b */ b: 2 }\n"},
+		{"[/* none */]", "[/* none */]\n"},
+		{
+			"let r = xs\n-> a // after a\n// before stage\n-> @fn() { @ }",
+			"let r = xs\n    -> a // after a\n    // before stage\n    -> @fn() {\n        @\n    }\n",
+		},
+		{"xs -> /* stage */ a -> b", "xs\n    -> /* stage */ a\n    -> b\n"},
+		{"let x = 1 + /* one */ 1 + f(fn() { 2 })", "/* one */\nlet x = 1 + 1 + f(fn() {\n    2\n})\n"},
+	}
+
+	for _, test := range tests {
+		result, err := Source(test.input)
+		assert.Nil(t, err, test.input)
+		assert.Equal(t, test.expected, result, test.input)
+
+		again, err := Source(result)
+		assert.Nil(t, err, result)
+		assert.Equal(t, result, again, result)
+	}
+}
@@ -0,0 +1,160 @@
+package format
+
+import (
+	"fmt"
+	"oilang/internal/ast"
+	"oilang/internal/lexer"
+	"oilang/internal/parser"
+	"oilang/internal/token"
+	"strings"
+)
+
+// Precedence of expressions that are never split by other operators, e.g. literals and calls
+const primary = parser.INDEX + 1
+
+// Returns precedence of the expression as an operand, i.e. how strong it's bound together
+func expressionPrecedence(e ast.Expression) int {
+	switch e := e.(type) {
+	case *ast.InfixExpression:
+		return parser.Precedence(e.Token.Type)
+	case *ast.PrefixExpression:
+		return parser.PrefixPrecedence(e.Token.Type)
+	case *ast.PipelineExpression:
+		return parser.PIPE
+	case *ast.AssignExpression:
+		return parser.ASSIGN
+	}
+
+	return primary
+}
+
+// Returns the first token of the statement
+func statementToken(s ast.Statement) token.Token {
+	switch s := s.(type) {
+	case *ast.LetStatement:
+		return s.Token
+	case *ast.ReturnStatement:
+		return s.Token
+	case *ast.ExpressionStatement:
+		return s.Token
+	case *ast.ImportStatement:
+		return s.Token
+	case *ast.ExportStatement:
+		return s.Token
+	case *ast.WhileStatement:
+		return loopToken(s.Token, s.Label)
+	case *ast.ForStatement:
+		return loopToken(s.Token, s.Label)
+	case *ast.BreakStatement:
+		return s.Token
+	case *ast.ContinueStatement:
+		return s.Token
+	}
+
+	return token.Token{Line: -1}
+}
+
+// Returns the first token of the expression, where comments that precede it end.
+// Parentheses are not kept in the AST, so expression in parentheses starts after them
+func expressionToken(e ast.Expression) token.Token {
+	switch e := e.(type) {
+	case *ast.InfixExpression:
+		return expressionToken(e.Left)
+	case *ast.AssignExpression:
+		return expressionToken(e.Target)
+	case *ast.PipelineExpression:
+		return expressionToken(e.Source)
+	case *ast.CallExpression:
+		return expressionToken(e.CalledExpression)
+	case *ast.IndexExpression:
+		return expressionToken(e.Left)
+	case *ast.SliceExpression:
+		return expressionToken(e.Left)
+	case *ast.MemberExpression:
+		return expressionToken(e.Object)
+	case *ast.Identifier:
+		return e.Token
+	case *ast.IntegerLiteral:
+		return e.Token
+	case *ast.FloatLiteral:
+		return e.Token
+	case *ast.BoolExpression:
+		return e.Token
+	case *ast.StringLiteral:
+		return e.Token
+	case *ast.TemplateLiteral:
+		return e.Token
+	case *ast.PrefixExpression:
+		return e.Token
+	case *ast.ArrayLiteral:
+		return e.Token
+	case *ast.HashLiteral:
+		return e.Token
+	case *ast.IfExpression:
+		return e.Token
+	case *ast.MatchExpression:
+		return e.Token
+	case *ast.FunctionLiteral:
+		return e.Token
+	}
+
+	return token.Token{Line: -1}
+}
+
+// Labeled loop starts with its label
+func loopToken(tok token.Token, label *ast.Identifier) token.Token {
+	if label != nil {
+		return label.Token
+	}
+
+	return tok
+}
+
+// Tells if the pattern is a name or "_"
+func isNamePattern(pattern ast.Pattern) bool {
+	switch pattern.(type) {
+	case *ast.BindingPattern, *ast.WildcardPattern:
+		return true
+	}
+
+	return false
+}
+
+// Operators that are words have to be separated from the operand, e.g. "not x"
+func isWord(operator string) bool {
+	return lexer.IsIdentifier(operator) || token.LookupTokenType(operator) != token.IDENT
+}
+
+func quote(s string) string {
+	return "\"" + escape(s) + "\""
+}
+
+// Escapes the string so it's read back as the same value. Interpolation is escaped as well, so text is not turned into template
+func escape(s string) string {
+	var out strings.Builder
+
+	for i, ch := range s {
+		switch {
+		case ch == '\\':
+			out.WriteString(`\\`)
+		case ch == '"':
+			out.WriteString(`\"`)
+		case ch == '\n':
+			out.WriteString(`\n`)
+		case ch == '\t':
+			out.WriteString(`\t`)
+		case ch == '\r':
+			out.WriteString(`\r`)
+		case ch == 0:
+			out.WriteString(`\0`)
+		case ch == '$' && strings.HasPrefix(s[i+1:], "{"):
+			out.WriteString(`\$`)
+		case ch < ' ' || ch == 0x7f:
+			out.WriteString(fmt.Sprintf(`\u{%x}`, ch))
+		default:
+			out.WriteRune(ch)
+		}
+	}
+
+	return out.String()
+}
@@ -0,0 +1,102 @@
+package lexer
+
+import (
+	"oilang/internal/token"
+	"strings"
+)
+
+// Checks if the comment starts at the current character
+func (l *Lexer) atComment() bool {
+	return l.ch == '/' && (l.peekNext() == '/' || l.peekNext() == '*')
+}
+
+// readComment reads line comment up to the end of line or block comment up to its closing delimiter.
+// Block comments could be nested, e.g. "/* outer /* inner */ still comment */". Returns false if block comment is not closed
+func (l *Lexer) readComment() (token.Comment, bool) {
+	c := token.Comment{Line: l.curLine, Col: l.pos - l.lastNewlinePos}
+	start := l.pos
+
+	if l.peekNext() == '/' {
+		for l.ch != '\n' && l.ch != 0 {
+			l.readNext()
+		}
+
+		c.Text = strings.TrimRight(l.input[start:l.pos], "\r")
+		return c, true
+	}
+
+	depth := 0
+	for l.ch != 0 {
+		switch {
+		case l.ch == '/' && l.peekNext() == '*':
+			depth += 1
+			l.readNext()
+		case l.ch == '*' && l.peekNext() == '/':
+			depth -= 1
+			l.readNext()
+		case l.ch == '\n':
+			l.curLine += 1
+			l.lastNewlinePos = l.pos + 1
+		}
+
+		l.readNext()
+
+		if depth == 0 {
+			c.Text = l.input[start:l.pos]
+			return c, true
+		}
+	}
+
+	return c, false
+}
+
+// Reads comments before the next token. Lines that contain only comments are skipped entirely, including their newline
+func (l *Lexer) readLeadingComments() ([]token.Comment, *token.Token) {
+	var comments []token.Comment
+
+	for l.skipToNonWhiteSpace(); l.atComment(); l.skipToNonWhiteSpace() {
+		start := l.pos
+		tok := l.createToken(token.ILLEGAL, "")
+
+		c, ok := l.readComment()
+		if !ok {
+			tok.Literal = l.input[start:]
+			tok.Issue = "unterminated comment"
+			return nil, &tok
+		}
+		comments = append(comments, c)
+
+		l.skipToNonWhiteSpace()
+		if l.ch == '\n' {
+			l.lastNewlinePos = l.pos + 1
+			l.curLine += 1
+			l.readNext()
+		}
+	}
+
+	return comments, nil
+}
+
+// Reads comments after the token up to the end of the line.
+// Unterminated comment is left for the next token, so it's reported as illegal
+func (l *Lexer) readTrailingComments() []token.Comment {
+	var comments []token.Comment
+
+	for {
+		// Lexer holds only its position, so it could be restored from the copy
+		saved := *l
+
+		l.skipToNonWhiteSpace()
+		if !l.atComment() {
+			*l = saved
+			return comments
+		}
+
+		c, ok := l.readComment()
+		if !ok {
+			*l = saved
+			return comments
+		}
+		comments = append(comments, c)
+	}
+}
@@ -24,39 +24,81 @@ func New(input string) *Lexer {
 	l := &Lexer{input: input}
 	// Init lexer with the first character in the input
 	l.readNext()
+	l.skipShebang()
+
+	return l
+}
+
+// NewAt Creates new lexer for the input that is a part of the bigger source, so positions of the tokens match the original source
+func NewAt(input string, line, col int) *Lexer {
+	l := &Lexer{input: input, curLine: line, lastNewlinePos: -col}
+	l.readNext()
 
 	return l
 }
 
 // Tokens that appear as single character
 var singleTokens = map[byte]token.TokenType{
-	'+': token.PLUS,
-	'/': token.DIVIDE,
 	',': token.COMMA,
 	'{': token.LBRACE,
 	'}': token.RBRACE,
+	'[': token.LBRACKET,
+	']': token.RBRACKET,
+	':': token.COLON,
 	'(': token.LPAREN,
 	')': token.RPAREN,
-	'.': token.DOT,
+	'|': token.BAR,
 	';': token.SEMICOLON,
 }
 
-// Tokens that change their type if appeared next to another character
+// Tokens that change their type if followed by other characters
 var doubleTokens = map[byte]struct {
-	next   byte
 	single token.TokenType
-	double token.TokenType
+	// Continuations are tried in order, so the longer ones should go first
+	next []continuation
 }{
-	'=': {'=', token.ASSIGN, token.EQ},
-	'!': {'=', token.NOT, token.NEQ},
-	'>': {'=', token.GT, token.GTE},
-	'<': {'=', token.LT, token.LTE},
-	'*': {'*', token.MULTIPLY, token.POWER},
-	'-': {'>', token.MINUS, token.PIPE_OP},
+	'=': {token.ASSIGN, []continuation{{"=", token.EQ}, {">", token.FAT_ARROW}}},
+	'.': {token.DOT, []continuation{{"..", token.ELLIPSIS}}},
+	'!': {token.NOT, []continuation{{"=", token.NEQ}}},
+	'>': {token.GT, []continuation{{"=", token.GTE}}},
+	'<': {token.LT, []continuation{{"=", token.LTE}}},
+	'+': {token.PLUS, []continuation{{"=", token.PLUS_ASSIGN}}},
+	'/': {token.DIVIDE, []continuation{{"=", token.DIVIDE_ASSIGN}}},
+	'*': {token.MULTIPLY, []continuation{{"*=", token.POWER_ASSIGN}, {"*", token.POWER}, {"=", token.MULTIPLY_ASSIGN}}},
+	'-': {token.MINUS, []continuation{{">", token.PIPE_OP}, {"=", token.MINUS_ASSIGN}}},
+}
+
+// Characters that turn the first character of double token into another token
+type continuation struct {
+	chars string
+	tok   token.TokenType
 }
 
-// NextToken Parses next token in the input string
+// NextToken Parses next significant token in the input string. Comments are attached to the token as trivia:
+// comments before the token are leading and comments after it up to the end of the line are trailing
 func (l *Lexer) NextToken() token.Token {
+	leading, illegal := l.readLeadingComments()
+	if illegal != nil {
+		return *illegal
+	}
+
+	tok := l.readToken()
+
+	var trailing []token.Comment
+	switch tok.Type {
+	case token.NEWLINE, token.EOF, token.ILLEGAL:
+	default:
+		trailing = l.readTrailingComments()
+	}
+
+	if len(leading) > 0 || len(trailing) > 0 {
+		tok.Trivia = &token.Trivia{Leading: leading, Trailing: trailing}
+	}
+
+	return tok
+}
+
+func (l *Lexer) readToken() token.Token {
 	var tok token.Token
 
 	l.skipToNonWhiteSpace()
@@ -73,15 +115,21 @@ func (l *Lexer) NextToken() token.Token {
 		// Check if next token is a fn keyword
 		if l.peekNext() == 'f' {
 			l.readNext()
+			// Identifier is read until the next char, so it's not skipped at the end, e.g. "(" in "@fn(x)"
 			if l.readIdentifier() == "fn" {
 				tok.Type = token.STAGE_FN
 				tok.Literal = "@fn"
-				break
+				return tok
 			}
 
 			l.pos = lastPos
 			l.readPos = lastPos + 1
+			l.ch = '@'
 		}
+	case '"':
+		tok = l.readString()
+	case '`':
+		tok = l.readRawString()
 	case 0:
 		tok = l.createToken(token.EOF, "")
 	default:
@@ -98,11 +146,14 @@ func (l *Lexer) NextToken() token.Token {
 		}
 
 		if v, ok := doubleTokens[l.ch]; ok {
-			if l.peekNext() == v.next {
-				l.skipChars(1)
-				tok = l.createToken(v.double, string(l.ch)+string(v.next))
-			} else {
-				tok = l.createToken(v.single, string(l.ch))
+			tok = l.createToken(v.single, string(l.ch))
+			for _, c := range v.next {
+				if strings.HasPrefix(l.input[l.readPos:], c.chars) {
+					tok.Type = c.tok
+					tok.Literal += c.chars
+					l.skipChars(len(c.chars))
+					break
+				}
 			}
 			break
 		}
@@ -154,6 +205,17 @@ func (l *Lexer) peekAt(pos int) byte {
 	return l.input[pos]
 }
 
+// Skips "#!" line at the start of the input, so scripts could be executed directly. Newline is kept to preserve line numbers
+func (l *Lexer) skipShebang() {
+	if !strings.HasPrefix(l.input, "#!") {
+		return
+	}
+
+	for l.ch != '\n' && l.ch != 0 {
+		l.readNext()
+	}
+}
+
 // Reads until finds any non-whitespace character
 func (l *Lexer) skipToNonWhiteSpace() {
 	for l.ch == ' ' || l.ch == '\t' || l.ch == '\r' {
@@ -7,14 +7,17 @@ import (
 )
 
 func TestLexer(t *testing.T) {
-	l := New(`let fn true false return if else @fn @fnot
+	l := New(`let fn true false return if else @fn @fnot @fn(
 hello hello_123 _name_ a.b
 123 123.01 1_000 10_000.12
+"some string with new\nlines"
 == != <= >= < >
 ! and or;
-=+-*/**
-(){}
-->
+=+-*/ **
++= -= *= /= **= ***=
+(){}[]:
+-> const
+match | => ... .
 `)
 	tests := []struct {
 		Type    token.TokenType
@@ -30,6 +33,8 @@ hello hello_123 _name_ a.b
 		{token.STAGE_FN, "@fn"},
 		{token.PIPE_CTX, "@"},
 		{token.IDENT, "fnot"},
+		{token.STAGE_FN, "@fn"},
+		{token.LPAREN, "("},
 		{token.NEWLINE, "\n"},
 
 		{token.IDENT, "hello"},
@@ -46,8 +51,8 @@ hello hello_123 _name_ a.b
 		{token.FLOAT, "10000.12"},
 		{token.NEWLINE, "\n"},
 
-		// "some string with new\nlines"
-		//{token.STRING, "some string with new\n lines"},
+		{token.STRING, "some string with new\nlines"},
+		{token.NEWLINE, "\n"},
 
 		{token.EQ, "=="},
 		{token.NEQ, "!="},
@@ -71,13 +76,33 @@ hello hello_123 _name_ a.b
 		{token.POWER, "**"},
 		{token.NEWLINE, "\n"},
 
+		{token.PLUS_ASSIGN, "+="},
+		{token.MINUS_ASSIGN, "-="},
+		{token.MULTIPLY_ASSIGN, "*="},
+		{token.DIVIDE_ASSIGN, "/="},
+		{token.POWER_ASSIGN, "**="},
+		{token.POWER, "**"},
+		{token.MULTIPLY_ASSIGN, "*="},
+		{token.NEWLINE, "\n"},
+
 		{token.LPAREN, "("},
 		{token.RPAREN, ")"},
 		{token.LBRACE, "{"},
 		{token.RBRACE, "}"},
+		{token.LBRACKET, "["},
+		{token.RBRACKET, "]"},
+		{token.COLON, ":"},
 		{token.NEWLINE, "\n"},
 
 		{token.PIPE_OP, "->"},
+		{token.CONST, "const"},
+		{token.NEWLINE, "\n"},
+
+		{token.MATCH, "match"},
+		{token.BAR, "|"},
+		{token.FAT_ARROW, "=>"},
+		{token.ELLIPSIS, "..."},
+		{token.DOT, "."},
 	}
 
 	for _, expected := range tests {
@@ -90,20 +115,20 @@ hello hello_123 _name_ a.b
 
 func TestInvalid(t *testing.T) {
 	l := New(`10
-%|
+%~
 1__10 10..1`)
 
 	tests := []token.Token{
-		{token.INT, "10", 0, 0, ""},
-		{token.NEWLINE, "\n", 0, 2, ""},
+		{Type: token.INT, Literal: "10", Line: 0, Col: 0, Issue: ""},
+		{Type: token.NEWLINE, Literal: "\n", Line: 0, Col: 2, Issue: ""},
 
-		{token.ILLEGAL, "%", 1, 0, "unexpected character"},
-		{token.ILLEGAL, "|", 1, 1, "unexpected character"},
-		{token.NEWLINE, "\n", 1, 2, ""},
+		{Type: token.ILLEGAL, Literal: "%", Line: 1, Col: 0, Issue: "unexpected character"},
+		{Type: token.ILLEGAL, Literal: "~", Line: 1, Col: 1, Issue: "unexpected character"},
+		{Type: token.NEWLINE, Literal: "\n", Line: 1, Col: 2, Issue: ""},
 
-		{token.INT, "1", 2, 0, ""},
-		{token.IDENT, "__10", 2, 1, ""},
-		{token.ILLEGAL, ".", 2, 9, "unexpected fraction delimiter"},
+		{Type: token.INT, Literal: "1", Line: 2, Col: 0, Issue: ""},
+		{Type: token.IDENT, Literal: "__10", Line: 2, Col: 1, Issue: ""},
+		{Type: token.ILLEGAL, Literal: ".", Line: 2, Col: 9, Issue: "unexpected fraction delimiter"},
 	}
 
 	for _, expected := range tests {
@@ -112,3 +137,127 @@ func TestInvalid(t *testing.T) {
 		assert.Equalf(t, expected, tok, "Tokens did not match. Expected %q, got %q", expected, tok)
 	}
 }
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		Type     token.TokenType
+		expected string
+	}{
+		{`"hello"`, token.STRING, "hello"},
+		{`""`, token.STRING, ""},
+		{`"tab\tquote\"slash\\"`, token.STRING, "tab\tquote\"slash\\"},
+		{`"\u{48}\u{1F600}"`, token.STRING, "H\U0001F600"},
+		{`"not \${interpolated}"`, token.STRING, "not ${interpolated}"},
+		{"`raw \\n ${x}`", token.STRING, "raw \\n ${x}"},
+		{"`multi\nline`", token.STRING, "multi\nline"},
+		{`"Hello, ${name}!"`, token.TEMPLATE, "Hello, ${name}!"},
+		{`"${ {"a}": 1}["a}"] }"`, token.TEMPLATE, `${ {"a}": 1}["a}"] }`},
+
+		{`"unterminated`, token.ILLEGAL, `"unterminated`},
+		{"`unterminated", token.ILLEGAL, "`unterminated"},
+		{`"bad \q"`, token.ILLEGAL, `bad \q`},
+		{`"bad \u{zz}"`, token.ILLEGAL, `bad \u{zz}`},
+		{`"bad \u{D800}"`, token.ILLEGAL, `bad \u{D800}`},
+		{`"bad \u{110000}"`, token.ILLEGAL, `bad \u{110000}`},
+		{`"${x"`, token.ILLEGAL, `"${x"`},
+	}
+
+	for _, test := range tests {
+		tok := New(test.input).NextToken()
+
+		assert.Equal(t, test.Type, tok.Type, test.input)
+		assert.Equal(t, test.expected, tok.Literal, test.input)
+		if tok.Type == token.ILLEGAL {
+			assert.NotEmpty(t, tok.Issue)
+		}
+	}
+
+	tok := New(`"\u{DFFF}"`).NextToken()
+	assert.Equal(t, `invalid unicode code point "DFFF"`, tok.Issue)
+}
+
+func TestStringPositions(t *testing.T) {
+	l := New("\"a\" `b\nc` d\n\"e\nf")
+
+	tests := []token.Token{
+		{Type: token.STRING, Literal: "a", Line: 0, Col: 0},
+		{Type: token.STRING, Literal: "b\nc", Line: 0, Col: 4},
+		{Type: token.IDENT, Literal: "d", Line: 1, Col: 3},
+		{Type: token.NEWLINE, Literal: "\n", Line: 1, Col: 4},
+		{Type: token.ILLEGAL, Literal: "\"e", Line: 2, Col: 0, Issue: "unterminated string"},
+		{Type: token.NEWLINE, Literal: "\n", Line: 2, Col: 2},
+		{Type: token.IDENT, Literal: "f", Line: 3, Col: 0},
+	}
+
+	for _, expected := range tests {
+		tok := l.NextToken()
+
+		assert.Equalf(t, expected, tok, "Tokens did not match. Expected %q, got %q", expected, tok)
+	}
+}
+
+func TestShebang(t *testing.T) {
+	l := New("#!/usr/bin/env oi\nx")
+
+	assert.Equal(t, token.Token{Type: token.NEWLINE, Literal: "\n", Line: 0, Col: 17}, l.NextToken())
+	assert.Equal(t, token.Token{Type: token.IDENT, Literal: "x", Line: 1, Col: 0}, l.NextToken())
+
+	// Shebang is allowed only at the start of the input
+	assert.Equal(t, token.ILLEGAL, New(" #!").NextToken().Type)
+}
+
+func TestComments(t *testing.T) {
+	l := New(`// leading
+let x = 1 // trailing
+/* block /* nested */ */ x /* inline */ / 2
+/* multi
+line */
+y`)
+
+	tests := []struct {
+		Type     token.TokenType
+		Line     int
+		Leading  []string
+		Trailing []string
+	}{
+		{Type: token.LET, Line: 1, Leading: []string{"// leading"}},
+		{Type: token.IDENT, Line: 1},
+		{Type: token.ASSIGN, Line: 1},
+		{Type: token.INT, Line: 1, Trailing: []string{"// trailing"}},
+		{Type: token.NEWLINE, Line: 1},
+		{Type: token.IDENT, Line: 2, Leading: []string{"/* block /* nested */ */"}, Trailing: []string{"/* inline */"}},
+		{Type: token.DIVIDE, Line: 2},
+		{Type: token.INT, Line: 2},
+		{Type: token.NEWLINE, Line: 2},
+		{Type: token.IDENT, Line: 5, Leading: []string{"/* multi\nline */"}},
+		{Type: token.EOF, Line: 5},
+	}
+
+	for _, expected := range tests {
+		tok := l.NextToken()
+		assert.Equal(t, expected.Type, tok.Type, tok)
+		assert.Equal(t, expected.Line, tok.Line, tok)
+
+		var leading, trailing []string
+		if tok.Trivia != nil {
+			for _, c := range tok.Trivia.Leading {
+				leading = append(leading, c.Text)
+			}
+			for _, c := range tok.Trivia.Trailing {
+				trailing = append(trailing, c.Text)
+			}
+		}
+
+		assert.Equal(t, expected.Leading, leading, tok)
+		assert.Equal(t, expected.Trailing, trailing, tok)
+	}
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	l := New("x /* outer /* inner */")
+
+	assert.Equal(t, token.Token{Type: token.IDENT, Literal: "x", Line: 0, Col: 0}, l.NextToken())
+	assert.Equal(t, token.Token{Type: token.ILLEGAL, Literal: "/* outer /* inner */", Line: 0, Col: 2, Issue: "unterminated comment"}, l.NextToken())
+	assert.Equal(t, token.EOF, l.NextToken().Type)
+}
@@ -0,0 +1,251 @@
+package lexer
+
+import (
+	"fmt"
+	"oilang/internal/token"
+	"strconv"
+	"strings"
+)
+
+// TemplatePart is a piece of the string literal. It's either a text with escapes already decoded or a source of interpolated expression
+type TemplatePart struct {
+	Value  string
+	IsExpr bool
+	Offset int // Position of the part relative to the start of the string contents
+}
+
+// Reads double-quoted string starting from the current position. When the function returns, lexer is at the closing quote.
+//
+// Strings without interpolation produce token.STRING with decoded value,
+// strings with interpolation produce token.TEMPLATE with raw contents that should be split by SplitTemplate
+func (l *Lexer) readString() token.Token {
+	tok := l.createToken(token.STRING, "")
+	start := l.pos + 1
+	isTemplate := false
+
+	for {
+		l.readNext()
+
+		switch l.ch {
+		case '\\':
+			l.readNext()
+			if l.ch == 0 || l.ch == '\n' {
+				return l.unterminatedString(tok, start)
+			}
+		case '$':
+			if l.peekNext() == '{' {
+				isTemplate = true
+				if !l.skipInterpolation() {
+					return l.unterminatedString(tok, start)
+				}
+			}
+		case 0, '\n':
+			return l.unterminatedString(tok, start)
+		case '"':
+			raw := l.input[start:l.pos]
+
+			parts, err := SplitTemplate(raw)
+			if err != nil {
+				tok.Type = token.ILLEGAL
+				tok.Literal = raw
+				tok.Issue = err.Error()
+				return tok
+			}
+
+			if isTemplate {
+				tok.Type = token.TEMPLATE
+				tok.Literal = raw
+			} else if len(parts) > 0 {
+				tok.Literal = parts[0].Value
+			}
+
+			return tok
+		}
+	}
+}
+
+// Creates an error for the string that does not have closing quote on the same line.
+// Lexer is left on the last character of the line, so the newline is still produced as a separate token
+func (l *Lexer) unterminatedString(tok token.Token, start int) token.Token {
+	if l.ch == '\n' || l.ch == 0 {
+		l.pos -= 1
+		l.readPos -= 1
+	}
+
+	tok.Type = token.ILLEGAL
+	tok.Literal = l.input[start-1 : l.pos+1]
+	tok.Issue = "unterminated string"
+
+	return tok
+}
+
+// Skips interpolated expression, so lexer is left on the closing brace.
+// Returns false if the expression is not closed
+func (l *Lexer) skipInterpolation() bool {
+	// Skip "${"
+	l.readNext()
+	depth := 1
+
+	for depth > 0 {
+		l.readNext()
+
+		switch l.ch {
+		case 0, '\n':
+			return false
+		case '{':
+			depth += 1
+		case '}':
+			depth -= 1
+		case '"':
+			// Nested string may contain braces that should not be counted
+			for l.readNext(); l.ch != '"'; l.readNext() {
+				if l.ch == 0 || l.ch == '\n' {
+					return false
+				}
+				if l.ch == '\\' {
+					l.readNext()
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+// Reads raw string enclosed in backticks. Raw strings could span multiple lines and do not support escapes
+func (l *Lexer) readRawString() token.Token {
+	tok := l.createToken(token.STRING, "")
+	start := l.pos + 1
+
+	for l.readNext(); l.ch != '`'; l.readNext() {
+		if l.ch == 0 {
+			tok.Type = token.ILLEGAL
+			tok.Literal = l.input[start-1 : l.pos]
+			tok.Issue = "unterminated raw string"
+			return tok
+		}
+
+		if l.ch == '\n' {
+			l.lastNewlinePos = l.pos + 1
+			l.curLine += 1
+		}
+	}
+
+	tok.Literal = l.input[start:l.pos]
+	return tok
+}
+
+// SplitTemplate splits contents of double-quoted string into text and interpolated expressions.
+// Escape sequences in the text parts are decoded
+func SplitTemplate(raw string) ([]TemplatePart, error) {
+	var parts []TemplatePart
+	var text strings.Builder
+	textStart := 0
+
+	flushText := func() {
+		if text.Len() > 0 {
+			parts = append(parts, TemplatePart{Value: text.String(), Offset: textStart})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\':
+			decoded, size, err := decodeEscape(raw[i:])
+			if err != nil {
+				return nil, err
+			}
+			text.WriteString(decoded)
+			i += size - 1
+		case raw[i] == '$' && i+1 < len(raw) && raw[i+1] == '{':
+			flushText()
+
+			end := findInterpolationEnd(raw, i+2)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated interpolation")
+			}
+			if strings.TrimSpace(raw[i+2:end]) == "" {
+				return nil, fmt.Errorf("empty interpolation")
+			}
+
+			parts = append(parts, TemplatePart{Value: raw[i+2 : end], IsExpr: true, Offset: i + 2})
+			i = end
+			textStart = end + 1
+		default:
+			text.WriteByte(raw[i])
+		}
+	}
+
+	flushText()
+	// String without any parts is still a valid empty string
+	if len(parts) == 0 {
+		parts = append(parts, TemplatePart{})
+	}
+
+	return parts, nil
+}
+
+// Returns position of the brace that closes interpolation started at the supplied position, or -1 if it is not closed
+func findInterpolationEnd(raw string, start int) int {
+	depth := 1
+
+	for i := start; i < len(raw); i++ {
+		switch raw[i] {
+		case '{':
+			depth += 1
+		case '}':
+			depth -= 1
+			if depth == 0 {
+				return i
+			}
+		case '"':
+			for i += 1; i < len(raw) && raw[i] != '"'; i++ {
+				if raw[i] == '\\' {
+					i += 1
+				}
+			}
+		}
+	}
+
+	return -1
+}
+
+var simpleEscapes = map[byte]string{
+	'n':  "\n",
+	't':  "\t",
+	'r':  "\r",
+	'0':  "\x00",
+	'"':  "\"",
+	'\\': "\\",
+	'$':  "$",
+}
+
+// Decodes escape sequence at the start of the string. Returns decoded value and amount of bytes consumed
+func decodeEscape(s string) (string, int, error) {
+	if len(s) < 2 {
+		return "", 0, fmt.Errorf("unfinished escape sequence")
+	}
+
+	if v, ok := simpleEscapes[s[1]]; ok {
+		return v, 2, nil
+	}
+
+	if s[1] != 'u' {
+		return "", 0, fmt.Errorf("unknown escape sequence \\%c", s[1])
+	}
+
+	// Unicode escape in form of \u{1F600}
+	end := strings.IndexByte(s, '}')
+	if len(s) < 3 || s[2] != '{' || end < 0 {
+		return "", 0, fmt.Errorf("expected unicode escape in form of \\u{XXXX}")
+	}
+
+	code, err := strconv.ParseUint(s[3:end], 16, 32)
+	// Surrogate halves are not characters on their own, so they are rejected as well
+	if err != nil || end-3 > 6 || code > 0x10FFFF || code >= 0xD800 && code <= 0xDFFF {
+		return "", 0, fmt.Errorf("invalid unicode code point %q", s[3:end])
+	}
+
+	return string(rune(code)), end + 1, nil
+}
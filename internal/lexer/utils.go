@@ -1,5 +1,7 @@
 package lexer
 
+import "oilang/internal/token"
+
 // Checks if byte can be used for starting identifier / keyword
 func isStartingIdentChar(ch byte) bool {
 	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
@@ -14,3 +16,18 @@ func isGeneralIdentChar(ch byte) bool {
 func isDigit(ch byte) bool {
 	return '0' <= ch && ch <= '9'
 }
+
+// IsIdentifier Checks if the string would be read as a single identifier, so it's not empty, not a keyword and contains only identifier chars
+func IsIdentifier(s string) bool {
+	if s == "" || !isStartingIdentChar(s[0]) || token.LookupTokenType(s) != token.IDENT {
+		return false
+	}
+
+	for i := 1; i < len(s); i++ {
+		if !isGeneralIdentChar(s[i]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,117 @@
+package lsp
+
+import (
+	"oilang/internal/analysis"
+	"oilang/internal/ast"
+	"oilang/internal/diagnostics"
+	"oilang/internal/lexer"
+	"oilang/internal/parser"
+	"oilang/internal/token"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// document is an opened file together with results of its analysis
+type document struct {
+	uri     string
+	version int
+	text    string
+	lines   []string
+
+	// Program holds the statements parsed without errors, so they could still be navigated while others have errors
+	program     *ast.Program
+	info        *analysis.Info
+	diagnostics []diagnostics.Diagnostic
+}
+
+func newDocument(uri string, version int, text string) *document {
+	d := &document{uri: uri, version: version, text: text, lines: strings.Split(text, "\n")}
+
+	program, err := parser.New(lexer.New(text)).Parse()
+	d.program = program
+	d.info = analysis.Inspect(program)
+
+	// Analysis of the program with syntax errors would report names from the broken statements as undefined
+	if err != nil {
+		d.diagnostics = err.Diagnostics()
+	} else {
+		d.diagnostics = d.info.Diagnostics
+	}
+
+	return d
+}
+
+func (d *document) line(n int) string {
+	if n < 0 || n >= len(d.lines) {
+		return ""
+	}
+
+	return strings.TrimRight(d.lines[n], "\r")
+}
+
+// Converts byte column of the line to the position in UTF-16 code units
+func (d *document) position(line, col int) Position {
+	text := d.line(line)
+	if col > len(text) {
+		col = len(text)
+	}
+
+	return Position{Line: line, Character: len(utf16.Encode([]rune(text[:col])))}
+}
+
+// Converts position from the client to byte column of the line
+func (d *document) column(p Position) analysis.Position {
+	text := d.line(p.Line)
+
+	col, units := 0, 0
+	for col < len(text) && units < p.Character {
+		r, size := utf8.DecodeRuneInString(text[col:])
+		units += utf16.RuneLen(r)
+		col += size
+	}
+
+	return analysis.Position{Line: p.Line, Col: col}
+}
+
+// Returns range the token takes in the source. Tokens that span multiple lines are limited to the first one
+func (d *document) tokenRange(tok token.Token) Range {
+	width := len(tok.Literal)
+	switch tok.Type {
+	case token.STRING, token.TEMPLATE:
+		// Literal does not include quotes
+		width += 2
+	case token.NEWLINE, token.EOF:
+		width = 1
+	}
+
+	return Range{Start: d.position(tok.Line, tok.Col), End: d.position(tok.Line, tok.Col+width)}
+}
+
+// Returns range from the start of the first token to the end of the last one
+func (d *document) span(first, last token.Token) Range {
+	return Range{Start: d.tokenRange(first).Start, End: d.tokenRange(last).End}
+}
+
+// Returns range of the whole text
+func (d *document) fullRange() Range {
+	last := len(d.lines) - 1
+	return Range{End: d.position(last, len(d.lines[last]))}
+}
+
+// Returns identifier under the position, or nil if there is none
+func (d *document) identifierAt(p Position) *ast.Identifier {
+	pos := d.column(p)
+
+	var found *ast.Identifier
+	ast.Walk(d.program, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Identifier)
+		if ok && ident.Token.Line == pos.Line && ident.Token.Col <= pos.Col && pos.Col <= ident.Token.Col+len(ident.Value) {
+			found = ident
+		}
+
+		return found == nil
+	})
+
+	return found
+}
@@ -0,0 +1,299 @@
+package lsp
+
+import (
+	"fmt"
+	"oilang/internal/ast"
+	"oilang/internal/diagnostics"
+	"oilang/internal/evaluator"
+	"oilang/internal/format"
+	"oilang/internal/token"
+	"strings"
+)
+
+func (d *document) publishedDiagnostics() []Diagnostic {
+	result := make([]Diagnostic, 0, len(d.diagnostics))
+	for _, diagnostic := range d.diagnostics {
+		severity := SEVERITY_ERROR
+		if diagnostic.Severity == diagnostics.Warning {
+			severity = SEVERITY_WARNING
+		}
+
+		message := diagnostic.Message
+		for _, note := range diagnostic.Notes {
+			message += "\nhelp: " + note
+		}
+
+		result = append(result, Diagnostic{
+			Range:    d.tokenRange(diagnostic.Token),
+			Severity: severity,
+			Source:   "oi",
+			Message:  message,
+		})
+	}
+
+	return result
+}
+
+// Shows how the name under the position is declared, e.g. parameters of the function
+func (d *document) hover(p Position) *Hover {
+	ident := d.identifierAt(p)
+	if ident == nil {
+		return nil
+	}
+
+	var text string
+	if def, ok := d.info.Definitions[ident]; ok {
+		text = d.declaration(def).String()
+	} else if _, ok := evaluator.LookupBuiltin(ident.Value); ok {
+		text = "builtin " + ident.Value
+	}
+
+	if text == "" {
+		return nil
+	}
+
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```oi\n" + text + "\n```"},
+		Range:    d.tokenRange(ident.Token),
+	}
+}
+
+// declaration is what the identifier declares
+type declaration struct {
+	ident *ast.Identifier
+	// Let or const statement that declares the name
+	let *ast.LetStatement
+	// Function the name is bound to, nil if the value is not a function literal
+	fn *ast.FunctionLiteral
+	// Function the name is a parameter of
+	owner *ast.FunctionLiteral
+	// Import the name is an alias of
+	module *ast.ImportStatement
+	// Loop the name is a variable of
+	loop *ast.ForStatement
+	// Pattern of the match arm that binds the name
+	pattern *ast.BindingPattern
+}
+
+// Finds the statement or function that declares the identifier
+func (d *document) declaration(def *ast.Identifier) declaration {
+	decl := declaration{ident: def}
+
+	ast.Walk(d.program, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.LetStatement:
+			if n.Name == def {
+				decl.let = n
+				decl.fn, _ = n.Value.(*ast.FunctionLiteral)
+				return false
+			}
+		case *ast.ImportStatement:
+			if n.Alias == def {
+				decl.module = n
+				return false
+			}
+		case *ast.ForStatement:
+			if n.Key == def || n.Value == def {
+				decl.loop = n
+				return false
+			}
+		case *ast.BindingPattern:
+			if n.Name == def {
+				decl.pattern = n
+				return false
+			}
+		case *ast.FunctionLiteral:
+			if n.Name == def {
+				decl.fn = n
+				return false
+			}
+			for _, param := range n.Parameters {
+				if param == def {
+					decl.owner = n
+					return false
+				}
+			}
+		}
+
+		return decl.fn == nil && decl.owner == nil && decl.module == nil && decl.loop == nil && decl.let == nil && decl.pattern == nil
+	})
+
+	return decl
+}
+
+// Returns signature of the declaration, e.g. "let double = fn(x)"
+func (decl declaration) String() string {
+	switch {
+	case decl.module != nil:
+		return strings.TrimSuffix(decl.module.String(), ";")
+	case decl.loop != nil:
+		return decl.ident.Value + " (loop variable)"
+	case decl.pattern != nil:
+		return decl.ident.Value + " (pattern variable)"
+	case decl.owner != nil:
+		return fmt.Sprintf("%s (parameter of %s)", decl.ident.Value, signature(decl.owner))
+	case decl.let != nil && decl.fn != nil:
+		return decl.let.Token.Literal + " " + decl.ident.Value + " = " + signature(decl.fn)
+	case decl.let != nil:
+		return decl.let.Token.Literal + " " + decl.ident.Value
+	}
+
+	return signature(decl.fn)
+}
+
+func signature(fn *ast.FunctionLiteral) string {
+	var params []string
+	for _, p := range fn.Parameters {
+		params = append(params, p.Value)
+	}
+
+	name := ""
+	if fn.Name != nil {
+		name = " " + fn.Name.Value
+	}
+
+	return fmt.Sprintf("%s%s(%s)", fn.Token.Literal, name, strings.Join(params, ", "))
+}
+
+// Returns location where the name under the position is declared
+func (d *document) definition(p Position) *Location {
+	ident := d.identifierAt(p)
+	if ident == nil {
+		return nil
+	}
+
+	def, ok := d.info.Definitions[ident]
+	if !ok {
+		return nil
+	}
+
+	return &Location{URI: d.uri, Range: d.tokenRange(def.Token)}
+}
+
+// Returns variables and functions declared by the statements, functions contain their own declarations
+func (d *document) symbols(stmts []ast.Statement) []DocumentSymbol {
+	result := make([]DocumentSymbol, 0)
+
+	for _, stmt := range stmts {
+		var name *ast.Identifier
+		var fn *ast.FunctionLiteral
+		first := statementToken(stmt)
+		kind := SYMBOL_VARIABLE
+
+		if export, ok := stmt.(*ast.ExportStatement); ok {
+			stmt = export.Statement
+		}
+
+		switch stmt := stmt.(type) {
+		case *ast.LetStatement:
+			name = stmt.Name
+			fn, _ = stmt.Value.(*ast.FunctionLiteral)
+		case *ast.ExpressionStatement:
+			if f, ok := stmt.Expression.(*ast.FunctionLiteral); ok && f.Name != nil {
+				name, fn = f.Name, f
+			}
+		case *ast.ImportStatement:
+			name, kind = stmt.Alias, SYMBOL_MODULE
+		}
+
+		if name == nil {
+			continue
+		}
+
+		symbol := DocumentSymbol{
+			Name:           name.Value,
+			Kind:           kind,
+			Range:          d.span(first, name.Token),
+			SelectionRange: d.tokenRange(name.Token),
+		}
+		if fn != nil {
+			symbol.Kind = SYMBOL_FUNCTION
+			symbol.Detail = signature(fn)
+			symbol.Range = d.span(first, fn.Body.Rbrace)
+			symbol.Children = d.symbols(fn.Body.Statements)
+		}
+
+		result = append(result, symbol)
+	}
+
+	return result
+}
+
+func statementToken(stmt ast.Statement) token.Token {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return stmt.Token
+	case *ast.ReturnStatement:
+		return stmt.Token
+	case *ast.ExpressionStatement:
+		return stmt.Token
+	case *ast.ImportStatement:
+		return stmt.Token
+	case *ast.ExportStatement:
+		return stmt.Token
+	case *ast.WhileStatement:
+		return stmt.Token
+	case *ast.ForStatement:
+		return stmt.Token
+	case *ast.BreakStatement:
+		return stmt.Token
+	case *ast.ContinueStatement:
+		return stmt.Token
+	}
+
+	return token.Token{}
+}
+
+// Suggests names visible at the position, builtins and keywords
+func (d *document) completion(p Position) []CompletionItem {
+	var items []CompletionItem
+	seen := make(map[string]bool)
+
+	add := func(item CompletionItem) {
+		if !seen[item.Label] {
+			seen[item.Label] = true
+			items = append(items, item)
+		}
+	}
+
+	for _, name := range d.info.VisibleNames(d.column(p)) {
+		decl := d.declaration(name)
+
+		item := CompletionItem{Label: name.Value, Kind: COMPLETION_VARIABLE, Detail: decl.String()}
+		if decl.fn != nil {
+			item.Kind = COMPLETION_FUNCTION
+		} else if decl.module != nil {
+			item.Kind = COMPLETION_MODULE
+		}
+		add(item)
+	}
+
+	for _, name := range evaluator.BuiltinNames() {
+		item := CompletionItem{Label: name, Kind: COMPLETION_FUNCTION, Detail: "builtin " + name}
+		if builtin, _ := evaluator.LookupBuiltin(name); builtin.Type() == evaluator.MODULE_OBJ {
+			item.Kind = COMPLETION_MODULE
+		}
+		add(item)
+	}
+
+	for _, keyword := range token.Keywords() {
+		add(CompletionItem{Label: keyword, Kind: COMPLETION_KEYWORD})
+	}
+
+	return items
+}
+
+// Returns edits that format the document, or nil if it has syntax errors
+func (d *document) formatting() []TextEdit {
+	formatted, err := format.Source(d.text)
+	if err != nil {
+		return nil
+	}
+
+	edits := make([]TextEdit, 0, 1)
+	if formatted != d.text {
+		edits = append(edits, TextEdit{Range: d.fullRange(), NewText: formatted})
+	}
+
+	return edits
+}
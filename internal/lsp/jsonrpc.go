@@ -0,0 +1,116 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+// Error codes defined by JSON-RPC and LSP
+const (
+	PARSE_ERROR            = -32700
+	INVALID_REQUEST        = -32600
+	METHOD_NOT_FOUND       = -32601
+	INVALID_PARAMS         = -32602
+	INTERNAL_ERROR         = -32603
+	SERVER_NOT_INITIALIZED = -32002
+)
+
+// Message is a JSON-RPC request, notification or response. Requests and responses have ID,
+// notifications don't. Response has either the result or the error
+type Message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *ResponseError   `json:"error,omitempty"`
+}
+
+// IsRequest Tells if the message expects response
+func (m *Message) IsRequest() bool {
+	return m.ID != nil && m.Method != ""
+}
+
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%d: %s", e.Code, e.Message)
+}
+
+// Conn reads and writes messages framed with "Content-Length" header, as LSP transmits them over stdio
+type Conn struct {
+	r *textproto.Reader
+	w io.Writer
+	// Messages could be written from multiple goroutines, e.g. by client in tests
+	mu sync.Mutex
+}
+
+// NewConn Creates connection that reads messages from r and writes them to w
+func NewConn(r io.Reader, w io.Writer) *Conn {
+	return &Conn{r: textproto.NewReader(bufio.NewReader(r)), w: w}
+}
+
+// Read Waits for the next message. Returns io.EOF when the input is closed between messages
+func (c *Conn) Read() (*Message, error) {
+	header, err := c.r.ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length: %q", header.Get("Content-Length"))
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.r.R, body); err != nil {
+		return nil, err
+	}
+
+	var msg Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, &ResponseError{Code: PARSE_ERROR, Message: err.Error()}
+	}
+
+	return &msg, nil
+}
+
+// Write Sends the message
+func (c *Conn) Write(msg *Message) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = c.w.Write(body)
+
+	return err
+}
+
+// Notify Sends notification with the params
+func (c *Conn) Notify(method string, params any) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	return c.Write(&Message{Method: method, Params: raw})
+}
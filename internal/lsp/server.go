@@ -0,0 +1,263 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// ErrExitWithoutShutdown is returned by Run when the client asks to exit before the shutdown request
+var ErrExitWithoutShutdown = errors.New("exit notification received before shutdown")
+
+// Handler processes params of the request or notification. Result is ignored for notifications
+type handler func(params json.RawMessage) (any, *ResponseError)
+
+// Server is a language server that works with a single client over the connection
+type Server struct {
+	conn     *Conn
+	handlers map[string]handler
+	docs     map[string]*document
+
+	initialized bool
+	shutdown    bool
+}
+
+// NewServer Creates server that reads messages from in and writes them to out, e.g. stdin and stdout
+func NewServer(in io.Reader, out io.Writer) *Server {
+	s := &Server{conn: NewConn(in, out), docs: make(map[string]*document)}
+	s.handlers = map[string]handler{
+		"initialize":  s.initialize,
+		"initialized": ignore,
+		"shutdown":    s.shutdownRequest,
+
+		"textDocument/didOpen":   s.didOpen,
+		"textDocument/didChange": s.didChange,
+		"textDocument/didClose":  s.didClose,
+
+		"textDocument/hover":          s.hover,
+		"textDocument/definition":     s.definition,
+		"textDocument/documentSymbol": s.documentSymbol,
+		"textDocument/completion":     s.completion,
+		"textDocument/formatting":     s.formatting,
+	}
+
+	return s
+}
+
+// Run Processes messages until the client sends "exit" notification or closes the input
+func (s *Server) Run() error {
+	for {
+		msg, err := s.conn.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			var rpcErr *ResponseError
+			if !errors.As(err, &rpcErr) {
+				return err
+			}
+
+			// Malformed message is reported, but it does not break the connection
+			if err := s.conn.Write(&Message{ID: nullID(), Error: rpcErr}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(msg *Message) error {
+	h, ok := s.handlers[msg.Method]
+
+	var result any
+	var rpcErr *ResponseError
+	switch {
+	case !ok:
+		rpcErr = &ResponseError{Code: METHOD_NOT_FOUND, Message: "method not found: " + msg.Method}
+	case !s.initialized && msg.Method != "initialize":
+		rpcErr = &ResponseError{Code: SERVER_NOT_INITIALIZED, Message: "server is not initialized"}
+	case s.shutdown && msg.Method != "shutdown":
+		rpcErr = &ResponseError{Code: INVALID_REQUEST, Message: "server is shut down"}
+	default:
+		result, rpcErr = h(msg.Params)
+	}
+
+	if !msg.IsRequest() {
+		return nil
+	}
+
+	response := &Message{ID: msg.ID, Error: rpcErr}
+	if rpcErr == nil {
+		raw, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		response.Result = raw
+	}
+
+	return s.conn.Write(response)
+}
+
+func (s *Server) initialize(json.RawMessage) (any, *ResponseError) {
+	s.initialized = true
+
+	result := InitializeResult{Capabilities: ServerCapabilities{
+		TextDocumentSync:           TEXT_DOCUMENT_SYNC_FULL,
+		HoverProvider:              true,
+		DefinitionProvider:         true,
+		DocumentSymbolProvider:     true,
+		CompletionProvider:         struct{}{},
+		DocumentFormattingProvider: true,
+	}}
+	result.ServerInfo.Name = "oi"
+
+	return result, nil
+}
+
+func (s *Server) shutdownRequest(json.RawMessage) (any, *ResponseError) {
+	s.shutdown = true
+	return nil, nil
+}
+
+func (s *Server) didOpen(raw json.RawMessage) (any, *ResponseError) {
+	var params DidOpenTextDocumentParams
+	if err := decode(raw, &params); err != nil {
+		return nil, err
+	}
+
+	doc := params.TextDocument
+	return nil, s.update(newDocument(doc.URI, doc.Version, doc.Text))
+}
+
+func (s *Server) didChange(raw json.RawMessage) (any, *ResponseError) {
+	var params DidChangeTextDocumentParams
+	if err := decode(raw, &params); err != nil {
+		return nil, err
+	}
+	if len(params.ContentChanges) == 0 {
+		return nil, nil
+	}
+
+	text := params.ContentChanges[len(params.ContentChanges)-1].Text
+	return nil, s.update(newDocument(params.TextDocument.URI, params.TextDocument.Version, text))
+}
+
+func (s *Server) didClose(raw json.RawMessage) (any, *ResponseError) {
+	var params DidCloseTextDocumentParams
+	if err := decode(raw, &params); err != nil {
+		return nil, err
+	}
+
+	delete(s.docs, params.TextDocument.URI)
+
+	// Diagnostics of the closed document are cleared
+	return nil, s.publish(&PublishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+}
+
+// Replaces the document and publishes its diagnostics
+func (s *Server) update(doc *document) *ResponseError {
+	s.docs[doc.uri] = doc
+	return s.publish(&PublishDiagnosticsParams{URI: doc.uri, Version: doc.version, Diagnostics: doc.publishedDiagnostics()})
+}
+
+func (s *Server) publish(params *PublishDiagnosticsParams) *ResponseError {
+	if err := s.conn.Notify("textDocument/publishDiagnostics", params); err != nil {
+		return &ResponseError{Code: INTERNAL_ERROR, Message: err.Error()}
+	}
+
+	return nil
+}
+
+func (s *Server) hover(raw json.RawMessage) (any, *ResponseError) {
+	doc, params, err := s.positionParams(raw)
+	if err != nil || doc == nil {
+		return nil, err
+	}
+
+	return doc.hover(params.Position), nil
+}
+
+func (s *Server) definition(raw json.RawMessage) (any, *ResponseError) {
+	doc, params, err := s.positionParams(raw)
+	if err != nil || doc == nil {
+		return nil, err
+	}
+
+	return doc.definition(params.Position), nil
+}
+
+func (s *Server) completion(raw json.RawMessage) (any, *ResponseError) {
+	doc, params, err := s.positionParams(raw)
+	if err != nil || doc == nil {
+		return nil, err
+	}
+
+	return doc.completion(params.Position), nil
+}
+
+func (s *Server) documentSymbol(raw json.RawMessage) (any, *ResponseError) {
+	doc, err := s.documentParams(raw)
+	if err != nil || doc == nil {
+		return nil, err
+	}
+
+	return doc.symbols(doc.program.Statements), nil
+}
+
+func (s *Server) formatting(raw json.RawMessage) (any, *ResponseError) {
+	doc, err := s.documentParams(raw)
+	if err != nil || doc == nil {
+		return nil, err
+	}
+
+	return doc.formatting(), nil
+}
+
+// Decodes params of the request about the position in the document. Document is nil if it's not opened
+func (s *Server) positionParams(raw json.RawMessage) (*document, *TextDocumentPositionParams, *ResponseError) {
+	var params TextDocumentPositionParams
+	if err := decode(raw, &params); err != nil {
+		return nil, nil, err
+	}
+
+	return s.docs[params.TextDocument.URI], &params, nil
+}
+
+// Decodes params of the request about the whole document. Document is nil if it's not opened
+func (s *Server) documentParams(raw json.RawMessage) (*document, *ResponseError) {
+	var params DocumentParams
+	if err := decode(raw, &params); err != nil {
+		return nil, err
+	}
+
+	return s.docs[params.TextDocument.URI], nil
+}
+
+func decode(raw json.RawMessage, v any) *ResponseError {
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &ResponseError{Code: INVALID_PARAMS, Message: err.Error()}
+	}
+
+	return nil
+}
+
+func ignore(json.RawMessage) (any, *ResponseError) {
+	return nil, nil
+}
+
+// Response to the message which ID could not be read
+func nullID() *json.RawMessage {
+	id := json.RawMessage("null")
+	return &id
+}
@@ -0,0 +1,393 @@
+package lsp
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strconv"
+	"testing"
+)
+
+const URI = "file:///test.oi"
+
+// client talks to the server running in the same process
+type client struct {
+	t    *testing.T
+	conn *Conn
+	// Input of the server, closing it stops the server
+	in     io.Closer
+	nextID int
+	// Notifications received while waiting for responses
+	notifications []*Message
+	done          chan error
+}
+
+func newClient(t *testing.T) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &client{t: t, conn: NewConn(clientIn, clientOut), in: clientOut, done: make(chan error, 1)}
+	go func() {
+		c.done <- NewServer(serverIn, serverOut).Run()
+		_ = serverOut.Close()
+	}()
+
+	c.call("initialize", map[string]any{}, nil)
+	c.notify("initialized", map[string]any{})
+
+	return c
+}
+
+// Sends request and decodes its result into the value. Returns error of the response
+func (c *client) call(method string, params any, result any) *ResponseError {
+	c.nextID++
+	id := json.RawMessage(strconv.Itoa(c.nextID))
+	raw, _ := json.Marshal(params)
+	assert.NoError(c.t, c.conn.Write(&Message{ID: &id, Method: method, Params: raw}))
+
+	for {
+		msg, err := c.conn.Read()
+		if !assert.NoError(c.t, err) {
+			return nil
+		}
+
+		if msg.ID == nil {
+			c.notifications = append(c.notifications, msg)
+			continue
+		}
+
+		assert.Equal(c.t, string(id), string(*msg.ID))
+		if msg.Error == nil && result != nil {
+			assert.NoError(c.t, json.Unmarshal(msg.Result, result))
+		}
+
+		return msg.Error
+	}
+}
+
+func (c *client) notify(method string, params any) {
+	assert.NoError(c.t, c.conn.Notify(method, params))
+}
+
+// Waits for diagnostics published for the document
+func (c *client) diagnostics() []Diagnostic {
+	msg, err := c.conn.Read()
+	assert.NoError(c.t, err)
+	assert.Equal(c.t, "textDocument/publishDiagnostics", msg.Method)
+
+	var params PublishDiagnosticsParams
+	assert.NoError(c.t, json.Unmarshal(msg.Params, &params))
+
+	return params.Diagnostics
+}
+
+func (c *client) open(text string) []Diagnostic {
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: URI, Version: 1, Text: text}})
+	return c.diagnostics()
+}
+
+func (c *client) close() error {
+	assert.Nil(c.t, c.call("shutdown", nil, nil))
+	c.notify("exit", nil)
+
+	return <-c.done
+}
+
+func at(line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: URI},
+		Position:     Position{Line: line, Character: character},
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	c := newClient(t)
+
+	err := c.call("unknown/method", nil, nil)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, METHOD_NOT_FOUND, err.Code)
+	}
+
+	assert.NoError(t, c.close())
+}
+
+func TestNotInitialized(t *testing.T) {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	go func() { _ = NewServer(serverIn, serverOut).Run() }()
+
+	c := &client{t: t, conn: NewConn(clientIn, clientOut)}
+	err := c.call("textDocument/hover", at(0, 0), nil)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, SERVER_NOT_INITIALIZED, err.Code)
+	}
+
+	c.notify("exit", nil)
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	c := newClient(t)
+	c.notify("exit", nil)
+
+	assert.ErrorIs(t, <-c.done, ErrExitWithoutShutdown)
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newClient(t)
+
+	ds := c.open("let x = ]\n")
+	if assert.Len(t, ds, 1) {
+		assert.Equal(t, SEVERITY_ERROR, ds[0].Severity)
+		assert.Equal(t, Range{Start: Position{0, 8}, End: Position{0, 9}}, ds[0].Range)
+	}
+
+	change := DidChangeTextDocumentParams{}
+	change.TextDocument.URI = URI
+	change.TextDocument.Version = 2
+	change.ContentChanges = append(change.ContentChanges, struct {
+		Text string `json:"text"`
+	}{"fn f() {\n    let unused = 1\n    y\n}\n"})
+	c.notify("textDocument/didChange", change)
+
+	ds = c.diagnostics()
+	if assert.Len(t, ds, 2) {
+		assert.Equal(t, SEVERITY_WARNING, ds[0].Severity)
+		assert.Equal(t, "unused variable unused\nhelp: remove it or prefix its name with \"_\"", ds[0].Message)
+		assert.Equal(t, "identifier not found: y", ds[1].Message)
+		assert.Equal(t, Range{Start: Position{2, 4}, End: Position{2, 5}}, ds[1].Range)
+	}
+
+	c.notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: URI}})
+	assert.Empty(t, c.diagnostics())
+
+	assert.NoError(t, c.close())
+}
+
+const SOURCE = `let scale = 2
+fn double(x) {
+    let y = x * scale
+    y
+}
+double(1) -> @ + "ö" -> len
+`
+
+func TestHover(t *testing.T) {
+	c := newClient(t)
+	assert.Empty(t, c.open(SOURCE))
+
+	tests := []struct {
+		position TextDocumentPositionParams
+		expected string
+	}{
+		{at(5, 2), "fn double(x)"},
+		{at(2, 17), "let scale"},
+		{at(2, 12), "x (parameter of fn double(x))"},
+		{at(5, 27), "builtin len"},
+	}
+
+	for _, test := range tests {
+		var hover Hover
+		assert.Nil(t, c.call("textDocument/hover", test.position, &hover))
+		assert.Equal(t, "```oi\n"+test.expected+"\n```", hover.Contents.Value)
+	}
+
+	var hover *Hover
+	assert.Nil(t, c.call("textDocument/hover", at(5, 16), &hover))
+	assert.Nil(t, hover)
+
+	assert.NoError(t, c.close())
+}
+
+func TestHoverWithSyntaxError(t *testing.T) {
+	c := newClient(t)
+	diagnostics := c.open("fn double(x) { x * 2 }\nlet y = )\nlet z = double(1)\n")
+	assert.Len(t, diagnostics, 1)
+
+	var hover Hover
+	assert.Nil(t, c.call("textDocument/hover", at(2, 9), &hover))
+	assert.Equal(t, "```oi\nfn double(x)\n```", hover.Contents.Value)
+
+	var symbols []DocumentSymbol
+	assert.Nil(t, c.call("textDocument/documentSymbol", DocumentParams{TextDocument: TextDocumentIdentifier{URI: URI}}, &symbols))
+	assert.Len(t, symbols, 2)
+
+	assert.NoError(t, c.close())
+}
+
+func TestDefinition(t *testing.T) {
+	c := newClient(t)
+	c.open(SOURCE)
+
+	var location Location
+	assert.Nil(t, c.call("textDocument/definition", at(5, 0), &location))
+	assert.Equal(t, Location{URI: URI, Range: Range{Start: Position{1, 3}, End: Position{1, 9}}}, location)
+
+	assert.Nil(t, c.call("textDocument/definition", at(3, 4), &location))
+	assert.Equal(t, Range{Start: Position{2, 8}, End: Position{2, 9}}, location.Range)
+
+	assert.NoError(t, c.close())
+}
+
+func TestDocumentSymbol(t *testing.T) {
+	c := newClient(t)
+	c.open(SOURCE)
+
+	var symbols []DocumentSymbol
+	assert.Nil(t, c.call("textDocument/documentSymbol", DocumentParams{TextDocument: TextDocumentIdentifier{URI: URI}}, &symbols))
+
+	assert.Equal(t, []DocumentSymbol{
+		{
+			Name: "scale", Kind: SYMBOL_VARIABLE,
+			Range:          Range{Start: Position{0, 0}, End: Position{0, 9}},
+			SelectionRange: Range{Start: Position{0, 4}, End: Position{0, 9}},
+		},
+		{
+			Name: "double", Detail: "fn double(x)", Kind: SYMBOL_FUNCTION,
+			Range:          Range{Start: Position{1, 0}, End: Position{4, 1}},
+			SelectionRange: Range{Start: Position{1, 3}, End: Position{1, 9}},
+			Children: []DocumentSymbol{{
+				Name: "y", Kind: SYMBOL_VARIABLE,
+				Range:          Range{Start: Position{2, 4}, End: Position{2, 9}},
+				SelectionRange: Range{Start: Position{2, 8}, End: Position{2, 9}},
+			}},
+		},
+	}, symbols)
+
+	assert.NoError(t, c.close())
+}
+
+func TestModuleSymbols(t *testing.T) {
+	c := newClient(t)
+	assert.Empty(t, c.open("import \"./text.oi\" as text\nexport let x = text.trim\n"))
+
+	var hover Hover
+	assert.Nil(t, c.call("textDocument/hover", at(1, 16), &hover))
+	assert.Equal(t, "```oi\nimport \"./text.oi\" as text\n```", hover.Contents.Value)
+
+	var symbols []DocumentSymbol
+	assert.Nil(t, c.call("textDocument/documentSymbol", DocumentParams{TextDocument: TextDocumentIdentifier{URI: URI}}, &symbols))
+	assert.Equal(t, []DocumentSymbol{
+		{
+			Name: "text", Kind: SYMBOL_MODULE,
+			Range:          Range{Start: Position{0, 0}, End: Position{0, 26}},
+			SelectionRange: Range{Start: Position{0, 22}, End: Position{0, 26}},
+		},
+		{
+			Name: "x", Kind: SYMBOL_VARIABLE,
+			Range:          Range{Start: Position{1, 0}, End: Position{1, 12}},
+			SelectionRange: Range{Start: Position{1, 11}, End: Position{1, 12}},
+		},
+	}, symbols)
+
+	assert.NoError(t, c.close())
+}
+
+func TestLoopVariables(t *testing.T) {
+	c := newClient(t)
+	assert.Empty(t, c.open("for i, x in [1] {\n    print(i + x)\n}\n"))
+
+	var hover Hover
+	assert.Nil(t, c.call("textDocument/hover", at(1, 14), &hover))
+	assert.Equal(t, "```oi\nx (loop variable)\n```", hover.Contents.Value)
+
+	var items []CompletionItem
+	assert.Nil(t, c.call("textDocument/completion", at(1, 4), &items))
+	labels := make(map[string]int)
+	for _, item := range items {
+		labels[item.Label] = item.Kind
+	}
+	assert.Equal(t, COMPLETION_VARIABLE, labels["i"])
+	assert.Equal(t, COMPLETION_KEYWORD, labels["while"])
+
+	assert.NoError(t, c.close())
+}
+
+func TestConstants(t *testing.T) {
+	c := newClient(t)
+
+	ds := c.open("const limit = 3\nlimit += 1\n")
+	if assert.Len(t, ds, 1) {
+		assert.Equal(t, "cannot assign to constant limit", ds[0].Message)
+		assert.Equal(t, Range{Start: Position{1, 0}, End: Position{1, 5}}, ds[0].Range)
+	}
+
+	var hover Hover
+	assert.Nil(t, c.call("textDocument/hover", at(1, 2), &hover))
+	assert.Equal(t, "```oi\nconst limit\n```", hover.Contents.Value)
+
+	assert.NoError(t, c.close())
+}
+
+func TestCompletion(t *testing.T) {
+	c := newClient(t)
+	c.open(SOURCE)
+
+	var items []CompletionItem
+	assert.Nil(t, c.call("textDocument/completion", at(3, 4), &items))
+
+	labels := make(map[string]int)
+	for _, item := range items {
+		labels[item.Label] = item.Kind
+	}
+
+	assert.Equal(t, COMPLETION_VARIABLE, labels["y"])
+	assert.Equal(t, COMPLETION_VARIABLE, labels["x"])
+	assert.Equal(t, COMPLETION_VARIABLE, labels["scale"])
+	assert.Equal(t, COMPLETION_FUNCTION, labels["double"])
+	assert.Equal(t, COMPLETION_FUNCTION, labels["print"])
+	assert.Equal(t, COMPLETION_KEYWORD, labels["let"])
+
+	assert.Nil(t, c.call("textDocument/completion", at(5, 0), &items))
+	for _, item := range items {
+		assert.NotEqual(t, "y", item.Label)
+	}
+
+	assert.NoError(t, c.close())
+}
+
+func TestFormatting(t *testing.T) {
+	c := newClient(t)
+	c.open("let x=[1,2]\n")
+
+	params := DocumentParams{TextDocument: TextDocumentIdentifier{URI: URI}}
+
+	var edits []TextEdit
+	assert.Nil(t, c.call("textDocument/formatting", params, &edits))
+	assert.Equal(t, []TextEdit{{Range: Range{End: Position{1, 0}}, NewText: "let x = [1, 2]\n"}}, edits)
+
+	c.open("let x = [1, 2]\n")
+	assert.Nil(t, c.call("textDocument/formatting", params, &edits))
+	assert.Empty(t, edits)
+
+	assert.NoError(t, c.close())
+}
+
+func TestPatternVariables(t *testing.T) {
+	c := newClient(t)
+	assert.Empty(t, c.open("let r = match [1, 2] {\n    [head, ..._] => head\n    _ => 0\n}\n"))
+
+	var hover Hover
+	assert.Nil(t, c.call("textDocument/hover", at(1, 21), &hover))
+	assert.Equal(t, "```oi\nhead (pattern variable)\n```", hover.Contents.Value)
+
+	var location Location
+	assert.Nil(t, c.call("textDocument/definition", at(1, 21), &location))
+	assert.Equal(t, Range{Start: Position{1, 5}, End: Position{1, 9}}, location.Range)
+
+	assert.NoError(t, c.close())
+}
+
+func TestSelfBoundFunctions(t *testing.T) {
+	c := newClient(t)
+	assert.Empty(t, c.open("let f = fn fact(n) {\n    if n < 2 { 1 } else { n * fact(n - 1) }\n}\n"))
+
+	var location Location
+	assert.Nil(t, c.call("textDocument/definition", at(1, 32), &location))
+	assert.Equal(t, Range{Start: Position{0, 11}, End: Position{0, 15}}, location.Range)
+
+	var hover Hover
+	assert.Nil(t, c.call("textDocument/hover", at(1, 32), &hover))
+	assert.Equal(t, "```oi\nfn fact(n)\n```", hover.Contents.Value)
+
+	assert.NoError(t, c.close())
+}
@@ -0,0 +1,179 @@
+package modules
+
+import (
+	"errors"
+	"io/fs"
+	"oilang/internal/analysis"
+	"oilang/internal/diagnostics"
+	"oilang/internal/evaluator"
+	"oilang/internal/lexer"
+	"oilang/internal/parser"
+	"oilang/internal/token"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrImportCycle is the cause of errors of the modules that import each other
+var ErrImportCycle = errors.New("import cycle")
+
+// Loader resolves, checks and evaluates modules for import statements.
+// Each file is evaluated once, later imports of the same file get the cached module
+type Loader struct {
+	cache map[string]*evaluator.Module
+	// Files that are being evaluated, in order of their imports. Importing any of them again is a cycle
+	loading []string
+	// File system modules are read from, nil if they are read from the host one
+	files fs.FS
+}
+
+// NewLoader Creates loader for the program from the main file, empty if the program does not come from a file
+func NewLoader(main string) *Loader {
+	l := &Loader{cache: make(map[string]*evaluator.Module)}
+	if main != "" {
+		l.loading = append(l.loading, absolute(main))
+	}
+
+	return l
+}
+
+// NewLoaderFS Creates loader that reads modules from the file system instead of the host one, so imports could not
+// reach files outside of it. Paths of the modules are slash-separated and relative to its root
+func NewLoaderFS(files fs.FS, main string) *Loader {
+	l := &Loader{cache: make(map[string]*evaluator.Module), files: files}
+	if main != "" {
+		l.loading = append(l.loading, path.Clean(main))
+	}
+
+	return l
+}
+
+// Import Loads the module with the path relative to the directory of the importing file.
+// Module is evaluated under the guard of the importing environment
+func (l *Loader) Import(tok token.Token, name string, from *evaluator.Environment) evaluator.Object {
+	resolved := l.resolve(name, from.File())
+
+	if module, ok := l.cache[resolved]; ok {
+		return module
+	}
+
+	for i, file := range l.loading {
+		if file == resolved {
+			cycleErr := evaluator.NewError(tok, "import cycle: %s", cycle(append(l.loading[i:], resolved)))
+			cycleErr.Cause = ErrImportCycle
+			return cycleErr
+		}
+	}
+
+	source, err := l.read(resolved)
+	if err != nil {
+		return evaluator.NewError(tok, "could not import %s: %v", display(resolved), unwrapPathError(err))
+	}
+
+	program, parseErr := parser.New(lexer.New(string(source))).Parse()
+	if parseErr != nil {
+		return moduleError(tok, resolved, parseErr[0].Token, parseErr[0].Message)
+	}
+	for _, d := range analysis.Analyze(program) {
+		if d.Severity == diagnostics.Error {
+			return moduleError(tok, resolved, d.Token, d.Message)
+		}
+	}
+
+	env := from.NewModuleEnvironment()
+	env.SetImporter(l, resolved)
+
+	l.loading = append(l.loading, resolved)
+	result := evaluator.Eval(program, env)
+	l.loading = l.loading[:len(l.loading)-1]
+
+	if err, ok := result.(*evaluator.Error); ok {
+		// The cycle already lists the files, so it's reported at the first import of it without the inner positions
+		if errors.Is(err.Cause, ErrImportCycle) {
+			return &evaluator.Error{Token: tok, Message: err.Message, Cause: err.Cause}
+		}
+
+		moduleErr := moduleError(tok, resolved, err.Token, err.Message)
+		moduleErr.Cause = err.Cause
+		return moduleErr
+	}
+
+	module := evaluator.NewModule(resolved, program, env)
+	l.cache[resolved] = module
+
+	return module
+}
+
+// Returns path of the module imported by the file, which is the same for all imports of the module
+func (l *Loader) resolve(name, from string) string {
+	if l.files != nil {
+		if !path.IsAbs(name) && from != "" {
+			name = path.Join(path.Dir(from), name)
+		}
+
+		return path.Clean(name)
+	}
+
+	if !filepath.IsAbs(name) && from != "" {
+		name = filepath.Join(filepath.Dir(from), name)
+	}
+
+	return absolute(name)
+}
+
+func (l *Loader) read(name string) ([]byte, error) {
+	if l.files != nil {
+		return fs.ReadFile(l.files, name)
+	}
+
+	return os.ReadFile(name)
+}
+
+// Errors inside the module are reported at the import statement, so their position is added to the message
+func moduleError(tok token.Token, path string, at token.Token, message string) *evaluator.Error {
+	return evaluator.NewError(tok, "%s:%d:%d: %s", display(path), at.Line+1, at.Col+1, message)
+}
+
+func cycle(files []string) string {
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, display(f))
+	}
+
+	return strings.Join(names, " -> ")
+}
+
+func absolute(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+
+	return filepath.Clean(path)
+}
+
+// Files are shown relative to the working directory when they are inside of it
+func display(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+
+	return rel
+}
+
+// Path is already shown in the message, so only the reason is left
+func unwrapPathError(err error) error {
+	if pathErr, ok := err.(*fs.PathError); ok {
+		return pathErr.Err
+	}
+
+	return err
+}
+
+var _ evaluator.Importer = (*Loader)(nil)
@@ -0,0 +1,114 @@
+package modules
+
+import (
+	"github.com/stretchr/testify/assert"
+	"oilang/internal/evaluator"
+	"oilang/internal/lexer"
+	"oilang/internal/parser"
+	"oilang/internal/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Writes the files into a temporary directory and returns path of the directory
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, text := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(text), 0o644))
+	}
+
+	return dir
+}
+
+// Evaluates the file with its imports
+func run(t *testing.T, path string) evaluator.Object {
+	source, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	program, parseErr := parser.New(lexer.New(string(source))).Parse()
+	assert.Nil(t, parseErr)
+
+	env := evaluator.NewEnvironment()
+	env.SetImporter(NewLoader(path), path)
+
+	return evaluator.Eval(program, env)
+}
+
+func TestImport(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.oi":        "import \"./lib/text.oi\" as text\ntext.shout(\"hi\") + text.suffix",
+		"lib/text.oi":    "import \"./suffix.oi\" as s\nexport let suffix = s.value\nexport fn shout(x) { x + \"!\" }\nlet hidden = 1",
+		"lib/suffix.oi":  "export let value = \"?\"",
+		"hidden.oi":      "import \"./lib/text.oi\" as text\ntext.hidden",
+		"missing.oi":     "import \"./nothing.oi\" as nothing",
+		"broken.oi":      "import \"./lib/broken.oi\" as broken",
+		"lib/broken.oi":  "export let x = 1\nexport let y = x + true",
+		"invalid.oi":     "import \"./lib/invalid.oi\" as invalid",
+		"lib/invalid.oi": "export let x = y",
+	})
+
+	result := run(t, filepath.Join(dir, "main.oi"))
+	assert.Equal(t, `"hi!?"`, result.Inspect())
+
+	tests := []struct {
+		file     string
+		expected string
+	}{
+		{"hidden.oi", "module text.oi has no export hidden"},
+		{"missing.oi", "could not import " + filepath.Join(dir, "nothing.oi") + ": no such file or directory"},
+		{"broken.oi", filepath.Join(dir, "lib/broken.oi") + ":2:18: type mismatch: INTEGER + BOOLEAN"},
+		{"invalid.oi", filepath.Join(dir, "lib/invalid.oi") + ":1:16: identifier not found: y"},
+	}
+
+	for _, test := range tests {
+		result := run(t, filepath.Join(dir, test.file))
+		if assert.IsType(t, &evaluator.Error{}, result, test.file) {
+			assert.Equal(t, test.expected, result.(*evaluator.Error).Message, test.file)
+		}
+	}
+}
+
+func TestImportCache(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.oi": "export let xs = [1]",
+	})
+
+	loader := NewLoader(filepath.Join(dir, "main.oi"))
+	main := evaluator.NewEnvironment()
+	main.SetImporter(loader, filepath.Join(dir, "main.oi"))
+	other := evaluator.NewEnvironment()
+	other.SetImporter(loader, filepath.Join(dir, "other.oi"))
+
+	first := loader.Import(token.Token{}, "./a.oi", main)
+	second := loader.Import(token.Token{}, "a.oi", other)
+
+	assert.IsType(t, &evaluator.Module{}, first)
+	assert.Same(t, first, second)
+}
+
+func TestImportCycle(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.oi": "import \"./a.oi\" as a",
+		"a.oi":    "import \"./b.oi\" as b",
+		"b.oi":    "import \"./a.oi\" as a",
+		"self.oi": "import \"./self.oi\" as self",
+	})
+
+	a, b := filepath.Join(dir, "a.oi"), filepath.Join(dir, "b.oi")
+	result := run(t, filepath.Join(dir, "main.oi"))
+	if assert.IsType(t, &evaluator.Error{}, result) {
+		// Cycle is reported at the import of the main file, without positions of the imports inside the modules
+		assert.Equal(t, "import cycle: "+a+" -> "+b+" -> "+a, result.(*evaluator.Error).Message)
+		assert.Equal(t, 0, result.(*evaluator.Error).Token.Line)
+		assert.ErrorIs(t, result.(*evaluator.Error).Cause, ErrImportCycle)
+	}
+
+	self := filepath.Join(dir, "self.oi")
+	result = run(t, self)
+	if assert.IsType(t, &evaluator.Error{}, result) {
+		assert.Equal(t, "import cycle: "+self+" -> "+self, result.(*evaluator.Error).Message)
+	}
+}
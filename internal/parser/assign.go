@@ -0,0 +1,39 @@
+package parser
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/token"
+)
+
+// parseAssignExpression is right associative, so in "a = b = 0" value of "a" is the assignment "b = 0"
+func (p *Parser) parseAssignExpression(target ast.Expression) (ast.Expression, *ParsingError) {
+	exp := &ast.AssignExpression{Token: p.curToken, Target: target}
+
+	if !isAssignable(target) {
+		return nil, p.createCurrentTokenError("invalid assignment target")
+	}
+
+	// Value could be placed on the next line after the operator
+	p.nextToken()
+	p.skipNewlines()
+
+	value, err := p.parseExpression(ASSIGN - 1)
+	if err != nil {
+		return nil, err
+	}
+
+	exp.Value = value
+	return exp, nil
+}
+
+// Only variables, elements of collections and members could be assigned. Pipeline context "@" is bound by the pipeline itself
+func isAssignable(target ast.Expression) bool {
+	switch target := target.(type) {
+	case *ast.Identifier:
+		return target.Token.Type == token.IDENT
+	case *ast.IndexExpression, *ast.MemberExpression:
+		return true
+	}
+
+	return false
+}
@@ -8,16 +8,21 @@ import (
 func (p *Parser) parseBlockStatement() (*ast.BlockStatement, *ParsingError) {
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
+	defer p.allowHashLiterals()()
 
 	p.nextToken()
 
 	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
 		stmt, err := p.parseStatement()
 		if err != nil {
-			return nil, err
-		}
-
-		if stmt != nil {
+			// Continue with the next statement, so errors in the rest of the block are reported as well
+			p.recover(err)
+
+			// Recovery could stop at the end of the block
+			if p.curTokenIs(token.RBRACE) {
+				break
+			}
+		} else if stmt != nil {
 			block.Statements = append(block.Statements, stmt)
 		}
 
@@ -28,6 +33,7 @@ func (p *Parser) parseBlockStatement() (*ast.BlockStatement, *ParsingError) {
 	if !p.curTokenIs(token.RBRACE) {
 		return nil, p.createCurrentTokenError("expected } at the end of block")
 	}
+	block.Rbrace = p.curToken
 
 	return block, nil
 }
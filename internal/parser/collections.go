@@ -0,0 +1,163 @@
+package parser
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/token"
+)
+
+func (p *Parser) parseArrayLiteral() (ast.Expression, *ParsingError) {
+	array := &ast.ArrayLiteral{Token: p.curToken}
+
+	elements, err := p.parseExpressionList(token.RBRACKET, "]")
+	if err != nil {
+		return nil, err
+	}
+	array.Elements = elements
+
+	return array, nil
+}
+
+// parseHashLiteral is registered as prefix parser for "{".
+// Block statements are parsed only after keywords that expect them (e.g. if and fn),
+// so "{" at the start of an expression is always a hash
+func (p *Parser) parseHashLiteral() (ast.Expression, *ParsingError) {
+	if p.noHashLiterals {
+		return nil, p.createCurrentTokenError("unexpected token")
+	}
+
+	hash := &ast.HashLiteral{Token: p.curToken}
+
+	for p.skipPeekNewlines(); !p.peekTokenIs(token.RBRACE); p.skipPeekNewlines() {
+		p.nextToken()
+
+		key, err := p.parseHashKey()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.tryPeek(token.COLON) {
+			return nil, p.createPeekError("expected : after hash key")
+		}
+		p.nextToken()
+
+		val, err := p.parseExpression(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: val})
+
+		p.skipPeekNewlines()
+		if !p.tryPeek(token.COMMA) {
+			break
+		}
+	}
+
+	if !p.tryPeek(token.RBRACE) {
+		return nil, p.createPeekError("expected }")
+	}
+
+	return hash, nil
+}
+
+// Identifier used as a hash key is a shorthand for the string, so { name: 1 } is the same as { "name": 1 }
+func (p *Parser) parseHashKey() (ast.Expression, *ParsingError) {
+	if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.COLON) {
+		return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}, nil
+	}
+
+	return p.parseExpression(LOWEST)
+}
+
+// parseIndexExpression parses either index access (xs[i]) or slice (xs[low:high])
+func (p *Parser) parseIndexExpression(left ast.Expression) (ast.Expression, *ParsingError) {
+	tok := p.curToken
+	var low ast.Expression
+	var err *ParsingError
+	defer p.allowHashLiterals()()
+
+	if p.peekTokenIs(token.RBRACKET) {
+		return nil, p.createPeekError("expected index")
+	}
+
+	if !p.peekTokenIs(token.COLON) {
+		p.nextToken()
+
+		low, err = p.parseExpression(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !p.tryPeek(token.COLON) {
+		if !p.tryPeek(token.RBRACKET) {
+			return nil, p.createPeekError("expected ]")
+		}
+
+		return &ast.IndexExpression{Token: tok, Left: left, Index: low}, nil
+	}
+
+	slice := &ast.SliceExpression{Token: tok, Left: left, Low: low}
+	if !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+
+		slice.High, err = p.parseExpression(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !p.tryPeek(token.RBRACKET) {
+		return nil, p.createPeekError("expected ]")
+	}
+
+	return slice, nil
+}
+
+// parseExpressionList parses comma-separated expressions until the end token.
+// Elements could be placed on separate lines and the last one could have a trailing comma
+func (p *Parser) parseExpressionList(end token.TokenType, endLiteral string) ([]ast.Expression, *ParsingError) {
+	var list []ast.Expression
+	defer p.allowHashLiterals()()
+
+	for p.skipPeekNewlines(); !p.peekTokenIs(end); p.skipPeekNewlines() {
+		p.nextToken()
+
+		exp, err := p.parseExpression(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, exp)
+
+		p.skipPeekNewlines()
+		if !p.tryPeek(token.COMMA) {
+			break
+		}
+	}
+
+	if !p.tryPeek(end) {
+		return nil, p.createPeekError("expected " + endLiteral)
+	}
+
+	return list, nil
+}
+
+// Advances tokens until peek token is not a newline
+func (p *Parser) skipPeekNewlines() {
+	for p.peekTokenIs(token.NEWLINE) {
+		p.nextToken()
+	}
+}
+
+// Allows hash literals until returned function is called, e.g. inside parentheses in if condition
+func (p *Parser) allowHashLiterals() func() {
+	return p.setNoHashLiterals(false)
+}
+
+// Sets whether hash literals are disallowed and returns function that restores previous state
+func (p *Parser) setNoHashLiterals(v bool) func() {
+	prev := p.noHashLiterals
+	p.noHashLiterals = v
+
+	return func() { p.noHashLiterals = prev }
+}
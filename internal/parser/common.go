@@ -37,7 +37,9 @@ func (p *Parser) parseInfixExpression(left ast.Expression) (ast.Expression, *Par
 	exp := &ast.InfixExpression{Token: p.curToken, Left: left}
 	precedence := p.curPrecedence()
 
+	// Right operand could be placed on the next line after the operator
 	p.nextToken()
+	p.skipNewlines()
 
 	right, err := p.parseExpression(precedence)
 	if err != nil {
@@ -49,6 +51,7 @@ func (p *Parser) parseInfixExpression(left ast.Expression) (ast.Expression, *Par
 }
 
 func (p *Parser) parseGroupedExpression() (ast.Expression, *ParsingError) {
+	defer p.allowHashLiterals()()
 	p.nextToken()
 
 	// Create something like a local scope inside parentheses and reset precedence
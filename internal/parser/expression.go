@@ -31,7 +31,15 @@ func (p *Parser) parseExpression(precedence int) (ast.Expression, *ParsingError)
 		return nil, err
 	}
 
-	for !p.isEndOfStatementToken(p.peekToken) && precedence < p.peekPrecedence() {
+	for {
+		if precedence < PIPE && p.isPipelineContinuation() {
+			p.skipPeekNewlines()
+		}
+
+		if p.isEndOfStatementToken(p.peekToken) || precedence >= p.peekPrecedence() {
+			break
+		}
+
 		infix, ok := p.infixParsers[p.peekToken.Type]
 		if !ok {
 			break
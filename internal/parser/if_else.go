@@ -9,7 +9,9 @@ func (p *Parser) parseIfExpression() (ast.Expression, *ParsingError) {
 	exp := &ast.IfExpression{Token: p.curToken}
 	p.nextToken()
 
+	restore := p.setNoHashLiterals(true)
 	cond, err := p.parseExpression(LOWEST)
+	restore()
 	if err != nil {
 		return nil, err
 	}
@@ -27,11 +29,14 @@ func (p *Parser) parseIfExpression() (ast.Expression, *ParsingError) {
 	if p.peekTokenIs(token.ELSE) {
 		p.nextToken()
 
-		if !p.tryPeek(token.LBRACE) {
-			return nil, p.createPeekError("expected { for else branch")
+		if p.tryPeek(token.IF) {
+			exp.Alternative, err = p.parseElseIf()
+		} else if p.tryPeek(token.LBRACE) {
+			exp.Alternative, err = p.parseBlockStatement()
+		} else {
+			return nil, p.createPeekError("expected { or if for else branch")
 		}
 
-		exp.Alternative, err = p.parseBlockStatement()
 		if err != nil {
 			return nil, err
 		}
@@ -39,3 +44,19 @@ func (p *Parser) parseIfExpression() (ast.Expression, *ParsingError) {
 
 	return exp, nil
 }
+
+// parseElseIf wraps the if expression that follows else into the block, so "else if" is the same as "else { if ... }".
+// Block starts with the if token, so it could be told apart from the written one
+func (p *Parser) parseElseIf() (*ast.BlockStatement, *ParsingError) {
+	tok := p.curToken
+
+	next, err := p.parseIfExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	block := &ast.BlockStatement{Token: tok, Rbrace: p.curToken}
+	block.Statements = []ast.Statement{&ast.ExpressionStatement{Token: tok, Expression: next}}
+
+	return block, nil
+}
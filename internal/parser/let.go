@@ -8,7 +8,7 @@ import (
 // TODO: Allow not setting values
 // parseLetStatement expects peek token to be an identifier followed by ASSIGN token
 //
-// After this, it assign statement's value to expression after the ASSIGN token
+// After this, it assign statement's value to expression after the ASSIGN token. Constants are declared the same way with const keyword
 func (p *Parser) parseLetStatement() (*ast.LetStatement, *ParsingError) {
 	stmt := &ast.LetStatement{Token: p.curToken}
 
@@ -23,6 +23,7 @@ func (p *Parser) parseLetStatement() (*ast.LetStatement, *ParsingError) {
 	}
 
 	p.nextToken()
+	p.skipNewlines()
 
 	val, err := p.parseExpression(LOWEST)
 
@@ -0,0 +1,156 @@
+package parser
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/token"
+)
+
+// parseLabeledStatement expects the label followed by colon and the loop it names: outer: for x in xs { ... }
+func (p *Parser) parseLabeledStatement() (ast.Statement, *ParsingError) {
+	label := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	p.nextToken()
+	p.nextToken()
+	p.skipNewlines()
+
+	switch p.curToken.Type {
+	case token.WHILE:
+		stmt, err := p.parseWhileStatement()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Label = label
+
+		return stmt, nil
+	case token.FOR:
+		stmt, err := p.parseForStatement()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Label = label
+
+		return stmt, nil
+	}
+
+	return nil, p.createCurrentTokenError("expected loop after label")
+}
+
+func (p *Parser) parseWhileStatement() (*ast.WhileStatement, *ParsingError) {
+	stmt := &ast.WhileStatement{Token: p.curToken}
+	p.nextToken()
+
+	cond, err := p.parseLoopHeader()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Condition = cond
+
+	stmt.Body, err = p.parseLoopBody()
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+// parseForStatement expects one or two names separated by comma, followed by the "in" keyword and the iterable
+func (p *Parser) parseForStatement() (*ast.ForStatement, *ParsingError) {
+	stmt := &ast.ForStatement{Token: p.curToken}
+
+	if !p.tryPeek(token.IDENT) {
+		return nil, p.createPeekError("expected name of the loop variable")
+	}
+	stmt.Value = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.tryPeek(token.COMMA) {
+		if !p.tryPeek(token.IDENT) {
+			return nil, p.createPeekError("expected name of the loop variable")
+		}
+		stmt.Key = stmt.Value
+		stmt.Value = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	if !p.tryPeek(token.IN) {
+		return nil, p.createPeekError("expected in after loop variables")
+	}
+	p.nextToken()
+
+	iterable, err := p.parseLoopHeader()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Iterable = iterable
+
+	stmt.Body, err = p.parseLoopBody()
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+// Expression before the body of the loop is parsed as if condition, so "{" starts the body
+func (p *Parser) parseLoopHeader() (ast.Expression, *ParsingError) {
+	restore := p.setNoHashLiterals(true)
+	defer restore()
+
+	return p.parseExpression(LOWEST)
+}
+
+func (p *Parser) parseLoopBody() (*ast.BlockStatement, *ParsingError) {
+	if !p.tryPeek(token.LBRACE) {
+		return nil, p.createPeekError("expected { for body of the loop")
+	}
+
+	body, err := p.parseBlockStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.isEndOfStatementToken(p.peekToken) {
+		p.nextToken()
+	}
+
+	return body, nil
+}
+
+func (p *Parser) parseBreakStatement() (*ast.BreakStatement, *ParsingError) {
+	stmt := &ast.BreakStatement{Token: p.curToken}
+
+	label, err := p.parseJumpLabel()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Label = label
+
+	return stmt, nil
+}
+
+func (p *Parser) parseContinueStatement() (*ast.ContinueStatement, *ParsingError) {
+	stmt := &ast.ContinueStatement{Token: p.curToken}
+
+	label, err := p.parseJumpLabel()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Label = label
+
+	return stmt, nil
+}
+
+// Label of break or continue is optional, statement could end right after the keyword
+func (p *Parser) parseJumpLabel() (*ast.Identifier, *ParsingError) {
+	keyword := p.curToken.Literal
+
+	var label *ast.Identifier
+	if p.tryPeek(token.IDENT) {
+		label = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	if p.isEndOfStatementToken(p.peekToken) {
+		p.nextToken()
+	} else if !p.peekTokenIs(token.RBRACE) {
+		return nil, p.createPeekError("expected label or end of statement after " + keyword)
+	}
+
+	return label, nil
+}
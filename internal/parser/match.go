@@ -0,0 +1,220 @@
+package parser
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/token"
+)
+
+// parseMatchExpression expects the value followed by arms in braces. Arms are separated by commas or newlines
+func (p *Parser) parseMatchExpression() (ast.Expression, *ParsingError) {
+	exp := &ast.MatchExpression{Token: p.curToken}
+	p.nextToken()
+
+	restore := p.setNoHashLiterals(true)
+	subject, err := p.parseExpression(LOWEST)
+	restore()
+	if err != nil {
+		return nil, err
+	}
+	exp.Subject = subject
+
+	if !p.tryPeek(token.LBRACE) {
+		return nil, p.createPeekError("expected { for arms of match")
+	}
+	defer p.allowHashLiterals()()
+
+	for p.skipPeekNewlines(); !p.peekTokenIs(token.RBRACE); p.skipPeekNewlines() {
+		p.nextToken()
+
+		arm, err := p.parseMatchArm()
+		if err != nil {
+			return nil, err
+		}
+		exp.Arms = append(exp.Arms, arm)
+
+		if !p.tryPeek(token.COMMA) && !p.peekTokenIs(token.NEWLINE) && !p.peekTokenIs(token.RBRACE) {
+			return nil, p.createPeekError("expected , or new line after match arm")
+		}
+	}
+
+	if !p.tryPeek(token.RBRACE) {
+		return nil, p.createPeekError("expected }")
+	}
+	exp.Rbrace = p.curToken
+
+	return exp, nil
+}
+
+// parseMatchArm expects patterns separated by "|", optional guard and either value or block after "=>"
+func (p *Parser) parseMatchArm() (*ast.MatchArm, *ParsingError) {
+	arm := &ast.MatchArm{}
+
+	for {
+		pattern, err := p.parsePattern()
+		if err != nil {
+			return nil, err
+		}
+		arm.Patterns = append(arm.Patterns, pattern)
+
+		if !p.tryPeek(token.BAR) {
+			break
+		}
+		p.nextToken()
+		p.skipNewlines()
+	}
+
+	if p.tryPeek(token.IF) {
+		p.nextToken()
+
+		guard, err := p.parseExpression(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+		arm.Guard = guard
+	}
+
+	if !p.tryPeek(token.FAT_ARROW) {
+		return nil, p.createPeekError("expected => after pattern")
+	}
+	p.nextToken()
+	p.skipNewlines()
+
+	var err *ParsingError
+	if p.curTokenIs(token.LBRACE) {
+		arm.Body, err = p.parseBlockStatement()
+	} else {
+		arm.Value, err = p.parseExpression(LOWEST)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return arm, nil
+}
+
+func (p *Parser) parsePattern() (ast.Pattern, *ParsingError) {
+	switch p.curToken.Type {
+	case token.IDENT:
+		return p.parseNamePattern(), nil
+	case token.INT, token.FLOAT, token.STRING, token.TRUE, token.FALSE:
+		value, err := p.prefixParsers[p.curToken.Type]()
+		if err != nil {
+			return nil, err
+		}
+
+		return &ast.LiteralPattern{Value: value}, nil
+	case token.MINUS:
+		// Only numbers could be negated, other prefix expressions are not literals
+		if !p.peekTokenIs(token.INT) && !p.peekTokenIs(token.FLOAT) {
+			return nil, p.createPeekError("expected number after -")
+		}
+
+		exp := &ast.PrefixExpression{Token: p.curToken}
+		p.nextToken()
+
+		operand, err := p.prefixParsers[p.curToken.Type]()
+		if err != nil {
+			return nil, err
+		}
+		exp.Operand = operand
+
+		return &ast.LiteralPattern{Value: exp}, nil
+	case token.LBRACKET:
+		return p.parseArrayPattern()
+	case token.LBRACE:
+		return p.parseHashPattern()
+	}
+
+	return nil, p.createCurrentTokenError("expected pattern")
+}
+
+// Name "_" matches any value without binding it
+func (p *Parser) parseNamePattern() ast.Pattern {
+	if p.curToken.Literal == "_" {
+		return &ast.WildcardPattern{Token: p.curToken}
+	}
+
+	return &ast.BindingPattern{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+}
+
+// parseArrayPattern expects patterns of the elements, the last one could be the rest of the array: [head, ...tail]
+func (p *Parser) parseArrayPattern() (ast.Pattern, *ParsingError) {
+	pattern := &ast.ArrayPattern{Token: p.curToken}
+
+	for p.skipPeekNewlines(); !p.peekTokenIs(token.RBRACKET); p.skipPeekNewlines() {
+		p.nextToken()
+
+		if p.curTokenIs(token.ELLIPSIS) {
+			if !p.tryPeek(token.IDENT) {
+				return nil, p.createPeekError("expected name after ...")
+			}
+			pattern.Rest = p.parseNamePattern()
+
+			p.skipPeekNewlines()
+			p.tryPeek(token.COMMA)
+			p.skipPeekNewlines()
+			if !p.peekTokenIs(token.RBRACKET) {
+				return nil, p.createPeekError("rest of the array should be the last pattern")
+			}
+			break
+		}
+
+		element, err := p.parsePattern()
+		if err != nil {
+			return nil, err
+		}
+		pattern.Elements = append(pattern.Elements, element)
+
+		p.skipPeekNewlines()
+		if !p.tryPeek(token.COMMA) {
+			break
+		}
+	}
+
+	if !p.tryPeek(token.RBRACKET) {
+		return nil, p.createPeekError("expected ]")
+	}
+
+	return pattern, nil
+}
+
+// parseHashPattern expects keys with patterns of their values. Key without pattern binds the value to its name: { name }
+func (p *Parser) parseHashPattern() (ast.Pattern, *ParsingError) {
+	pattern := &ast.HashPattern{Token: p.curToken}
+
+	for p.skipPeekNewlines(); !p.peekTokenIs(token.RBRACE); p.skipPeekNewlines() {
+		p.nextToken()
+
+		if !p.curTokenIs(token.IDENT) && !p.curTokenIs(token.STRING) {
+			return nil, p.createCurrentTokenError("expected hash key")
+		}
+		key := &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+
+		var value ast.Pattern
+		if p.tryPeek(token.COLON) {
+			p.nextToken()
+
+			var err *ParsingError
+			if value, err = p.parsePattern(); err != nil {
+				return nil, err
+			}
+		} else if key.Token.Type == token.IDENT {
+			value = p.parseNamePattern()
+		} else {
+			return nil, p.createPeekError("expected : after hash key")
+		}
+		pattern.Pairs = append(pattern.Pairs, ast.HashPatternPair{Key: key, Value: value})
+
+		p.skipPeekNewlines()
+		if !p.tryPeek(token.COMMA) {
+			break
+		}
+	}
+
+	if !p.tryPeek(token.RBRACE) {
+		return nil, p.createPeekError("expected }")
+	}
+
+	return pattern, nil
+}
@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/token"
+)
+
+// parseImportStatement expects the path of the module followed by its alias: import "./lib/text.oi" as text
+func (p *Parser) parseImportStatement() (*ast.ImportStatement, *ParsingError) {
+	stmt := &ast.ImportStatement{Token: p.curToken}
+
+	if !p.tryPeek(token.STRING) {
+		return nil, p.createPeekError("expected path of the module")
+	}
+	stmt.Path = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.tryPeek(token.AS) {
+		return nil, p.createPeekError("expected as after path of the module")
+	}
+
+	if !p.tryPeek(token.IDENT) {
+		return nil, p.createPeekError("expected name of the module")
+	}
+	stmt.Alias = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.isEndOfStatementToken(p.peekToken) {
+		p.nextToken()
+	}
+
+	return stmt, nil
+}
+
+// parseExportStatement expects let or const statement or named function declaration after the "export" keyword
+func (p *Parser) parseExportStatement() (*ast.ExportStatement, *ParsingError) {
+	stmt := &ast.ExportStatement{Token: p.curToken}
+	p.nextToken()
+
+	var err *ParsingError
+	switch {
+	case p.curTokenIs(token.LET) || p.curTokenIs(token.CONST):
+		stmt.Statement, err = p.parseLetStatement()
+	case (p.curTokenIs(token.FN) || p.curTokenIs(token.STAGE_FN)) && p.peekTokenIs(token.IDENT):
+		stmt.Statement, err = p.parseExpressionStatement()
+	default:
+		return nil, p.createCurrentTokenError("expected let, const or named function after export")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+func (p *Parser) parseMemberExpression(object ast.Expression) (ast.Expression, *ParsingError) {
+	exp := &ast.MemberExpression{Token: p.curToken, Object: object}
+
+	if !p.tryPeek(token.IDENT) {
+		return nil, p.createPeekError("expected member name after .")
+	}
+	exp.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return exp, nil
+}
@@ -1,9 +1,12 @@
 package parser
 
 import (
+	"fmt"
 	"oilang/internal/ast"
+	"oilang/internal/diagnostics"
 	"oilang/internal/lexer"
 	"oilang/internal/token"
+	"strings"
 )
 
 // ParsingError represents an error that occurring during parsing, as well as token that has caused an error
@@ -12,9 +15,58 @@ type ParsingError struct {
 	Token   token.Token
 }
 
+func (e *ParsingError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Token.Line+1, e.Token.Col+1, e.Message)
+}
+
+// Diagnostic Converts the error to diagnostic that could be shown to the user
+func (e *ParsingError) Diagnostic() diagnostics.Diagnostic {
+	return diagnostics.New(diagnostics.Error, e.Token, e.Message)
+}
+
+// IsUnexpectedEOF Tells if the error is caused by the input that ended too early, so it could be fixed by adding more input
+func (e *ParsingError) IsUnexpectedEOF() bool {
+	return e.Token.Type == token.EOF
+}
+
+// ParsingErrors is a list of all errors found in the source, in order of their appearance
+type ParsingErrors []*ParsingError
+
+// Diagnostics Converts all errors to diagnostics
+func (e ParsingErrors) Diagnostics() []diagnostics.Diagnostic {
+	var result []diagnostics.Diagnostic
+	for _, err := range e {
+		result = append(result, err.Diagnostic())
+	}
+
+	return result
+}
+
+// Incomplete Tells if all errors are caused by the unexpected end of input
+func (e ParsingErrors) Incomplete() bool {
+	for _, err := range e {
+		if !err.IsUnexpectedEOF() {
+			return false
+		}
+	}
+
+	return len(e) > 0
+}
+
+func (e ParsingErrors) Error() string {
+	var messages []string
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "\n")
+}
+
 // Operator precedence levels
 const (
 	LOWEST = iota
+	ASSIGN
+	PIPE
 	OR
 	AND
 	NOT
@@ -25,6 +77,7 @@ const (
 	UNARY
 	EXP
 	CALL
+	INDEX
 )
 
 // Maps each token that could appear in infix position to its precedence level
@@ -46,7 +99,42 @@ var precedences = map[token.TokenType]int{
 	token.MULTIPLY: PRODUCT,
 	token.POWER:    EXP,
 
-	token.LPAREN: CALL,
+	token.LPAREN:   CALL,
+	token.LBRACKET: INDEX,
+	token.DOT:      INDEX,
+
+	token.PIPE_OP: PIPE,
+
+	token.ASSIGN:          ASSIGN,
+	token.PLUS_ASSIGN:     ASSIGN,
+	token.MINUS_ASSIGN:    ASSIGN,
+	token.MULTIPLY_ASSIGN: ASSIGN,
+	token.DIVIDE_ASSIGN:   ASSIGN,
+	token.POWER_ASSIGN:    ASSIGN,
+}
+
+// Maps each operator that could appear in prefix position to precedence of its operand
+var prefixPrecedences = map[token.TokenType]int{
+	token.NOT:   NOT,
+	token.MINUS: UNARY,
+}
+
+// Precedence Returns precedence of the token in infix position, or LOWEST if it's not an infix operator
+func Precedence(t token.TokenType) int {
+	if p, ok := precedences[t]; ok {
+		return p
+	}
+
+	return LOWEST
+}
+
+// PrefixPrecedence Returns precedence the operand of prefix operator is parsed with, or LOWEST if it's not a prefix operator
+func PrefixPrecedence(t token.TokenType) int {
+	if p, ok := prefixPrecedences[t]; ok {
+		return p
+	}
+
+	return LOWEST
 }
 
 // Generic function for parsing expressions for different token positions
@@ -60,11 +148,15 @@ type (
 type Parser struct {
 	l *lexer.Lexer
 
-	errors ParsingError
+	errors ParsingErrors
 
 	curToken  token.Token
 	peekToken token.Token
 
+	// Set while parsing expressions that are followed by a block (e.g. if condition),
+	// so "{" is treated as the start of the block instead of a hash literal
+	noHashLiterals bool
+
 	prefixParsers map[token.TokenType]prefixParseFn
 	infixParsers  map[token.TokenType]infixParseFn
 }
@@ -81,33 +173,87 @@ func New(l *lexer.Lexer) *Parser {
 	return p
 }
 
-// Parse Goes through lexical tokens and turns them into AST
-func (p *Parser) Parse() (*ast.Program, *ParsingError) {
+// Parse Goes through lexical tokens and turns them into AST.
+//
+// Parser does not stop on the first error, but skips to the next statement and continues,
+// so all errors in the source are reported at once. Program holds the statements that are parsed without errors,
+// so tools like language server could still work with the rest of the source while it's being edited
+func (p *Parser) Parse() (*ast.Program, ParsingErrors) {
 	program := &ast.Program{}
 	program.Statements = []ast.Statement{}
 
 	for p.curToken.Type != token.EOF {
 		stmt, err := p.parseStatement()
 		if err != nil {
-			return nil, err
-		}
-		if stmt != nil {
+			p.recover(err)
+		} else if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
 		p.nextToken()
 	}
 
+	if len(p.errors) > 0 {
+		return program, p.errors
+	}
+
 	return program, nil
 }
 
+// recover remembers the error and skips tokens until the end of the statement or enclosing block,
+// so parsing could continue from the next statement. Blocks opened after the error are skipped entirely
+func (p *Parser) recover(err *ParsingError) {
+	// Error caused by the previous one is not useful
+	if len(p.errors) == 0 || p.errors[len(p.errors)-1].Token != err.Token {
+		p.errors = append(p.errors, err)
+	}
+
+	depth := 0
+	for {
+		switch p.curToken.Type {
+		case token.EOF:
+			return
+		case token.NEWLINE, token.SEMICOLON:
+			if depth == 0 {
+				return
+			}
+		case token.LBRACE:
+			depth += 1
+		case token.RBRACE:
+			if depth == 0 {
+				return
+			}
+			depth -= 1
+		}
+
+		p.nextToken()
+	}
+}
+
 // Decides which function to call to turn given token into program statement
 func (p *Parser) parseStatement() (ast.Statement, *ParsingError) {
 	switch p.curToken.Type {
-	case token.LET:
+	case token.LET, token.CONST:
 		return p.parseLetStatement()
-	// TODO: Return statement should not appear outside of function body
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.IMPORT:
+		return p.parseImportStatement()
+	case token.EXPORT:
+		return p.parseExportStatement()
+	case token.WHILE:
+		return p.parseWhileStatement()
+	case token.FOR:
+		return p.parseForStatement()
+	case token.BREAK:
+		return p.parseBreakStatement()
+	case token.CONTINUE:
+		return p.parseContinueStatement()
+	case token.IDENT:
+		if p.peekTokenIs(token.COLON) {
+			return p.parseLabeledStatement()
+		}
+
+		return p.parseExpressionStatement()
 	case token.NEWLINE, token.EOF:
 		break
 	default:
@@ -130,12 +276,18 @@ func (p *Parser) setupParsers() {
 
 	p.registerPrefixParser(token.INT, p.parseInt)
 	p.registerPrefixParser(token.FLOAT, p.parseFloat)
+	p.registerPrefixParser(token.STRING, p.parseString)
+	p.registerPrefixParser(token.TEMPLATE, p.parseTemplate)
 
-	p.registerPrefixParser(token.NOT, p.createPrefixParserWithPrecedence(NOT))
-	p.registerPrefixParser(token.MINUS, p.createPrefixParserWithPrecedence(UNARY))
+	for k, precedence := range prefixPrecedences {
+		p.registerPrefixParser(k, p.createPrefixParserWithPrecedence(precedence))
+	}
 
 	p.registerPrefixParser(token.LPAREN, p.parseGroupedExpression)
+	p.registerPrefixParser(token.LBRACKET, p.parseArrayLiteral)
+	p.registerPrefixParser(token.LBRACE, p.parseHashLiteral)
 	p.registerPrefixParser(token.IF, p.parseIfExpression)
+	p.registerPrefixParser(token.MATCH, p.parseMatchExpression)
 	p.registerPrefixParser(token.FN, p.parseFunctionLiteral)
 	p.registerPrefixParser(token.STAGE_FN, p.parseFunctionLiteral)
 
@@ -145,6 +297,14 @@ func (p *Parser) setupParsers() {
 	}
 	// Override for call expression
 	p.registerInfixParser(token.LPAREN, p.parseCallExpression)
+	p.registerInfixParser(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfixParser(token.DOT, p.parseMemberExpression)
+	p.registerInfixParser(token.PIPE_OP, p.parsePipelineExpression)
+	for k, precedence := range precedences {
+		if precedence == ASSIGN {
+			p.registerInfixParser(k, p.parseAssignExpression)
+		}
+	}
 }
 
 func (p *Parser) registerPrefixParser(tokenType token.TokenType, fn prefixParseFn) {
@@ -163,11 +323,20 @@ func (p *Parser) nextToken() {
 
 // Creates and error for the peek token
 func (p *Parser) createPeekError(msg string) *ParsingError {
-	return &ParsingError{msg, p.peekToken}
+	return newTokenError(msg, p.peekToken)
 }
 
 func (p *Parser) createCurrentTokenError(msg string) *ParsingError {
-	return &ParsingError{msg, p.curToken}
+	return newTokenError(msg, p.curToken)
+}
+
+// Illegal tokens already contain the description of the problem from the lexer, so it's more useful than parser's message
+func newTokenError(msg string, tok token.Token) *ParsingError {
+	if tok.Type == token.ILLEGAL && tok.Issue != "" {
+		msg = tok.Issue
+	}
+
+	return &ParsingError{msg, tok}
 }
 
 // Peeks next token if it matches the supplied type and returns whether it matched
@@ -182,11 +351,7 @@ func (p *Parser) tryPeek(t token.TokenType) bool {
 
 // Returns precedence of the supplied token
 func (p *Parser) tokPrecedence(tok token.Token) int {
-	if p, ok := precedences[tok.Type]; ok {
-		return p
-	}
-
-	return LOWEST
+	return Precedence(tok.Type)
 }
 
 // Returns precedence of the peek token
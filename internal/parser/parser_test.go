@@ -8,7 +8,7 @@ import (
 	"testing"
 )
 
-func testValidProgram(t *testing.T, program *ast.Program, err *ParsingError, statementsLen int) {
+func testValidProgram(t *testing.T, program *ast.Program, err ParsingErrors, statementsLen int) {
 	assert.Nil(t, err)
 	assert.NotNil(t, program)
 	assert.Len(t, program.Statements, statementsLen)
@@ -49,11 +49,21 @@ func TestInvalidStatements(t *testing.T) {
 		l := lexer.New(test)
 		p, err := New(l).Parse()
 
-		assert.Nil(t, p)
+		assert.NotNil(t, p)
 		assert.NotNil(t, err, "Should show an error")
 	}
 }
 
+func TestPartialProgram(t *testing.T) {
+	p, err := New(lexer.New("let x = 1\nlet y = )\nlet z = x")).Parse()
+
+	assert.Len(t, err, 1)
+	if assert.Len(t, p.Statements, 2) {
+		assert.Equal(t, "x", p.Statements[0].(*ast.LetStatement).Name.Value)
+		assert.Equal(t, "z", p.Statements[1].(*ast.LetStatement).Name.Value)
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	input := `
 return 0
@@ -314,16 +324,18 @@ func TestBadIfSyntax(t *testing.T) {
 		{"if { true } else { false}", "unexpected token"},
 		{"if true  128 }", "expected { for main if branch"},
 		{"if true  { 128 ", "expected } at the end of block"},
-		{"if true  { 128 } else ", "expected { for else branch"},
+		{"if true  { 128 } else ", "expected { or if for else branch"},
+		{"if a { 1 } else if { 2 }", "unexpected token"},
+		{"if a { 1 } else if b 2", "expected { for main if branch"},
 	}
 
 	for _, test := range tests {
 		l := lexer.New(test.input)
 		p, err := New(l).Parse()
 
-		assert.Nil(t, p)
-		assert.NotNil(t, err)
-		assert.Equal(t, test.error, err.Message)
+		assert.NotNil(t, p)
+		assert.NotEmpty(t, err)
+		assert.Equal(t, test.error, err[0].Message)
 	}
 }
 
@@ -336,7 +348,7 @@ func TestExpressionCall(t *testing.T) {
 		{"a()", "a()"},
 		{"a + b(12)", "(a + b(12))"},
 		{"(c + d)(a, b, 12)", "(c + d)(a, b, 12)"},
-		{"@fn (x, y) { return x > y }(1, 2)", "@fn (x, y) { return (x > y); }(1, 2)"},
+		{"fn (x, y) { return x > y }(1, 2)", "fn (x, y) { return (x > y); }(1, 2)"},
 	}
 
 	for _, test := range tests {
@@ -362,9 +374,9 @@ func TestBadExpressionCallSyntax(t *testing.T) {
 		l := lexer.New(test.input)
 		p, err := New(l).Parse()
 
-		assert.Nil(t, p)
-		assert.NotNil(t, err)
-		assert.Equal(t, test.error, err.Message)
+		assert.NotNil(t, p)
+		assert.NotEmpty(t, err)
+		assert.Equal(t, test.error, err[0].Message)
 	}
 }
 
@@ -383,8 +395,526 @@ func TestBadFNSyntax(t *testing.T) {
 		l := lexer.New(test.input)
 		p, err := New(l).Parse()
 
-		assert.Nil(t, p)
-		assert.NotNil(t, err)
-		assert.Equal(t, test.error, err.Message)
+		assert.NotNil(t, p)
+		assert.NotEmpty(t, err)
+		assert.Equal(t, test.error, err[0].Message)
+	}
+}
+
+func TestPipelines(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x -> f(@)", "(x -> f(@))"},
+		{"x -> f -> g(@, 1)", "(x -> f -> g(@, 1))"},
+		{"a + b -> @ * 2", "((a + b) -> (@ * 2))"},
+		{"x -> @ > 1 or @ < 0", "(x -> ((@ > 1) or (@ < 0)))"},
+		{"x -> @fn (v) { v }", "(x -> @fn (v) { v })"},
+		{"let y = x -> f", "let y = (x -> f);"},
+		{"x ->\n f", "(x -> f)"},
+		{"x\n\n  -> f\n  -> g", "(x -> f -> g)"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p, err := New(l).Parse()
+		testValidProgram(t, p, err, 1)
+
+		assert.Equal(t, test.expected, p.String())
+	}
+}
+
+func TestPipelineStages(t *testing.T) {
+	l := lexer.New("1 -> f -> g -> h")
+	p, err := New(l).Parse()
+	testValidProgram(t, p, err, 1)
+
+	stmt := getAsInstanceOf[ast.ExpressionStatement](t, p.Statements[0])
+	pipeline := getAsInstanceOf[ast.PipelineExpression](t, stmt.Expression)
+
+	assert.Equal(t, "1", pipeline.Source.String())
+	assert.Len(t, pipeline.Stages, 3)
+}
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"hello"`, `"hello"`},
+		{`"a" + "b"`, `("a" + "b")`},
+		{"`raw\nstring`", `"raw\nstring"`},
+		{`"Hello, ${name}!"`, `"Hello, ${name}!"`},
+		{`"${a + b * 2}"`, `"${(a + (b * 2))}"`},
+		{`"${f("x")} \${escaped}"`, `"${f("x")} \${escaped}"`},
+		{`let s = "sum: ${x -> @ + 1}"`, `let s = "sum: ${(x -> (@ + 1))}";`},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p, err := New(l).Parse()
+		testValidProgram(t, p, err, 1)
+
+		assert.Equal(t, test.expected, p.String())
+	}
+}
+
+func TestTemplateParts(t *testing.T) {
+	l := lexer.New(`"a ${b} c"`)
+	p, err := New(l).Parse()
+	testValidProgram(t, p, err, 1)
+
+	stmt := getAsInstanceOf[ast.ExpressionStatement](t, p.Statements[0])
+	tmpl := getAsInstanceOf[ast.TemplateLiteral](t, stmt.Expression)
+
+	assert.Len(t, tmpl.Parts, 3)
+	getAsInstanceOf[ast.StringLiteral](t, tmpl.Parts[0])
+	ident := getAsInstanceOf[ast.Identifier](t, tmpl.Parts[1])
+	getAsInstanceOf[ast.StringLiteral](t, tmpl.Parts[2])
+
+	// Position of the interpolated identifier points to the original source
+	assert.Equal(t, 5, ident.Token.Col)
+}
+
+func TestBadTemplateSyntax(t *testing.T) {
+	tests := []struct {
+		input string
+		error string
+	}{
+		{`"${1 +}"`, "unexpected token"},
+		{`"${a b}"`, "expected single expression in interpolation"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p, err := New(l).Parse()
+
+		assert.NotNil(t, p)
+		assert.NotEmpty(t, err)
+		assert.Equal(t, test.error, err[0].Message)
+	}
+}
+
+func TestCollections(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[]", "[]"},
+		{"[1, 2 * 2, a]", "[1, (2 * 2), a]"},
+		{"[\n  1,\n  2,\n]", "[1, 2]"},
+		{"{}", "{}"},
+		{`{ name: "oi", "key": 1, 2: [3] }`, `{"name": "oi", "key": 1, 2: [3]}`},
+		{"{\n  a: 1,\n  b: { c: 2 }\n}", `{"a": 1, "b": {"c": 2}}`},
+		{"{ a: 1 }[\"a\"]", `{"a": 1}["a"]`},
+		{"xs[1]", "xs[1]"},
+		{"xs[i + 1] * 2", "(xs[(i + 1)] * 2)"},
+		{"-xs[0]", "(- xs[0])"},
+		{"f(x)[0]", "f(x)[0]"},
+		{"m[\"k\"][0](1)", `m["k"][0](1)`},
+		{"xs[1:3]", "xs[1:3]"},
+		{"xs[:2]", "xs[:2]"},
+		{"xs[1:]", "xs[1:]"},
+		{"xs[:]", "xs[:]"},
+		{"if ({ a: 1 }) { 1 }", `if {"a": 1} { 1 }`},
+		{"f(\n  1,\n  2\n)", "f(1, 2)"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p, err := New(l).Parse()
+		testValidProgram(t, p, err, 1)
+
+		assert.Equal(t, test.expected, p.String())
+	}
+}
+
+func TestBadCollectionsSyntax(t *testing.T) {
+	tests := []struct {
+		input string
+		error string
+	}{
+		{"[1, 2", "expected ]"},
+		{"[1 2]", "expected ]"},
+		{"{ a 1 }", "expected : after hash key"},
+		{"{ a: 1", "expected }"},
+		{"xs[]", "expected index"},
+		{"xs[1", "expected ]"},
+		{"xs[1:2", "expected ]"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p, err := New(l).Parse()
+
+		assert.NotNil(t, p)
+		assert.NotEmpty(t, err)
+		assert.Equal(t, test.error, err[0].Message, test.input)
+	}
+}
+
+func TestModules(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "./lib/text.oi" as text`, `import "./lib/text.oi" as text;`},
+		{"export let x = 1", "export let x = 1;"},
+		{"export fn f(a) { a }", "export fn f(a) { a }"},
+		{"text.trim(s)", "text.trim(s)"},
+		{"a.b.c[0]", "a.b.c[0]"},
+		{"-a.b ** 2", "(- (a.b ** 2))"},
+		{"s -> text.trim", "(s -> text.trim)"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p, err := New(l).Parse()
+		testValidProgram(t, p, err, 1)
+
+		assert.Equal(t, test.expected, p.String())
+	}
+
+	p, err := New(lexer.New(`import "a.oi" as a`)).Parse()
+	testValidProgram(t, p, err, 1)
+
+	stmt := getAsInstanceOf[ast.ImportStatement](t, p.Statements[0])
+	assert.Equal(t, "a.oi", stmt.Path.Value)
+	assert.Equal(t, "a", stmt.Alias.Value)
+}
+
+func TestBadModulesSyntax(t *testing.T) {
+	tests := []struct {
+		input string
+		error string
+	}{
+		{"import text", "expected path of the module"},
+		{`import "a.oi"`, "expected as after path of the module"},
+		{`import "a.oi" as "b"`, "expected name of the module"},
+		{"export 1", "expected let, const or named function after export"},
+		{"export fn() {}", "expected let, const or named function after export"},
+		{"a.1", "expected member name after ."},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p, err := New(l).Parse()
+
+		assert.NotNil(t, p)
+		assert.NotEmpty(t, err)
+		assert.Equal(t, test.error, err[0].Message, test.input)
+	}
+}
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"while x > 0 { x }", "while (x > 0) { x }"},
+		{"for x in xs { x }", "for x in xs { x }"},
+		{"for i, x in [1, 2] { i }", "for i, x in [1, 2] { i }"},
+		{"for k in ({a: 1}) { k }", `for k in {"a": 1} { k }`},
+		{"outer: for x in xs { break outer }", "outer: for x in xs { break outer; }"},
+		{"outer:\nwhile true { continue outer; }", "outer: while true { continue outer; }"},
+		{"while true { if x { break }\ncontinue }", "while true { if x { break; }continue; }"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p, err := New(l).Parse()
+		testValidProgram(t, p, err, 1)
+
+		assert.Equal(t, test.expected, p.String())
+	}
+
+	p, err := New(lexer.New("loop: for i, x in xs {}\nx")).Parse()
+	testValidProgram(t, p, err, 2)
+
+	stmt := getAsInstanceOf[ast.ForStatement](t, p.Statements[0])
+	assert.Equal(t, "loop", stmt.Label.Value)
+	assert.Equal(t, "i", stmt.Key.Value)
+	assert.Equal(t, "x", stmt.Value.Value)
+	assert.Empty(t, stmt.Body.Statements)
+}
+
+func TestBadLoopsSyntax(t *testing.T) {
+	tests := []struct {
+		input string
+		error string
+	}{
+		{"while true", "expected { for body of the loop"},
+		{"for in xs {}", "expected name of the loop variable"},
+		{"for i, in xs {}", "expected name of the loop variable"},
+		{"for x xs {}", "expected in after loop variables"},
+		{"for x in {}", "unexpected token"},
+		{"outer: 1", "expected loop after label"},
+		{"break 1", "expected label or end of statement after break"},
+		{"continue a b", "expected label or end of statement after continue"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p, err := New(l).Parse()
+
+		assert.NotNil(t, p)
+		assert.NotEmpty(t, err)
+		assert.Equal(t, test.error, err[0].Message, test.input)
+	}
+}
+
+func TestAssignments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 1", "(x = 1)"},
+		{"x = y = 1 + 2", "(x = (y = (1 + 2)))"},
+		{"xs[0] += 1", "(xs[0] += 1)"},
+		{"obj.field -= 2 * 3", "(obj.field -= (2 * 3))"},
+		{"x *= 2; x /= 2; x **= 2", "(x *= 2)(x /= 2)(x **= 2)"},
+		{"x =\n xs -> len", "(x = (xs -> len))"},
+		{"const x = 1", "const x = 1;"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p, err := New(l).Parse()
+		assert.Nil(t, err, test.input)
+
+		assert.Equal(t, test.expected, p.String())
+	}
+
+	p, err := New(lexer.New("xs[i] += 1")).Parse()
+	testValidProgram(t, p, err, 1)
+
+	stmt := getAsInstanceOf[ast.ExpressionStatement](t, p.Statements[0])
+	assign := getAsInstanceOf[ast.AssignExpression](t, stmt.Expression)
+	getAsInstanceOf[ast.IndexExpression](t, assign.Target)
+
+	op, ok := assign.Compound()
+	assert.True(t, ok)
+	assert.Equal(t, "+", op.Literal)
+	assert.Equal(t, assign.Token.Col, op.Col)
+}
+
+func TestBadAssignmentsSyntax(t *testing.T) {
+	tests := []struct {
+		input string
+		error string
+	}{
+		{"1 = 2", "invalid assignment target"},
+		{"f() += 1", "invalid assignment target"},
+		{"@ = 1", "invalid assignment target"},
+		{"xs[1:2] = []", "invalid assignment target"},
+		{"x = ", "unexpected token"},
+		{"const x", "Assign operator expected"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p, err := New(l).Parse()
+
+		assert.NotNil(t, p)
+		assert.NotEmpty(t, err)
+		assert.Equal(t, test.error, err[0].Message, test.input)
+	}
+}
+
+func TestElseIf(t *testing.T) {
+	p, err := New(lexer.New("if a { 1 } else if b { 2 } else { 3 }")).Parse()
+	testValidProgram(t, p, err, 1)
+	assert.Equal(t, "if a { 1 } else if b { 2 } else { 3 }", p.String())
+
+	stmt := getAsInstanceOf[ast.ExpressionStatement](t, p.Statements[0])
+	exp := getAsInstanceOf[ast.IfExpression](t, stmt.Expression)
+	next := exp.ElseIf()
+	if assert.NotNil(t, next) {
+		assert.Equal(t, "b", next.Condition.String())
+		assert.Nil(t, next.ElseIf())
+		assert.Equal(t, 0, exp.Alternative.Rbrace.Col-next.Alternative.Rbrace.Col)
+	}
+
+	p, err = New(lexer.New("if a { 1 } else { if b { 2 } }")).Parse()
+	testValidProgram(t, p, err, 1)
+	assert.Nil(t, p.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IfExpression).ElseIf())
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match x { 1 | 2 => a, _ => b }", "match x { 1 | 2 => a, _ => b }"},
+		{"match x {\n  -1 => \"neg\"\n  1.5 | true | \"s\" => 0\n}", `match x { (- 1) => "neg", 1.5 | true | "s" => 0 }`},
+		{"match xs { [] => 0, [head, ...rest] => head, [_, ..._] => 1 }", "match xs { [] => 0, [head, ...rest] => head, [_, ..._] => 1 }"},
+		{`match h { { name: n, "age": 1, id } => n, {} => 0 }`, `match h { {"name": n, "age": 1, "id": id} => n, {} => 0 }`},
+		{"match n { n if n > 10 => { let m = n; m }, _ => ({}) }", "match n { n if (n > 10) => { let m = n;m }, _ => {} }"},
+		{"match [1] { [[a], {b}] => a + b }", "match [1] { [[a], {\"b\": b}] => (a + b) }"},
+		{"match x {}", "match x {  }"},
+		{"let y = match x { _ => 1 } + 1", "let y = (match x { _ => 1 } + 1);"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p, err := New(l).Parse()
+		testValidProgram(t, p, err, 1)
+
+		assert.Equal(t, test.expected, p.String())
+	}
+
+	p, err := New(lexer.New("match x { [a, ...b] | {a} if a => a }")).Parse()
+	testValidProgram(t, p, err, 1)
+
+	stmt := getAsInstanceOf[ast.ExpressionStatement](t, p.Statements[0])
+	arm := getAsInstanceOf[ast.MatchExpression](t, stmt.Expression).Arms[0]
+	assert.Len(t, arm.Patterns, 2)
+	assert.NotNil(t, arm.Guard)
+
+	var names []string
+	for _, n := range ast.PatternNames(arm.Patterns[0]) {
+		names = append(names, n.Value)
+	}
+	assert.Equal(t, []string{"a", "b"}, names)
+}
+
+func TestBadMatchSyntax(t *testing.T) {
+	tests := []struct {
+		input string
+		error string
+	}{
+		{"match x", "expected { for arms of match"},
+		{"match x { 1 }", "expected => after pattern"},
+		{"match x { 1 => 2 3 => 4 }", "expected , or new line after match arm"},
+		{"match x { a + 1 => 2 }", "expected => after pattern"},
+		{"match x { f(a) => 2 }", "expected => after pattern"},
+		{"match x { -a => 2 }", "expected number after -"},
+		{`match x { "${a}" => 2 }`, "expected pattern"},
+		{"match x { [...a, b] => 2 }", "rest of the array should be the last pattern"},
+		{"match x { [...] => 2 }", "expected name after ..."},
+		{"match x { {1: a} => 2 }", "expected hash key"},
+		{`match x { {"a"} => 2 }`, "expected : after hash key"},
+		{"match x { 1 => 2", "expected , or new line after match arm"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p, err := New(l).Parse()
+
+		assert.NotNil(t, p)
+		assert.NotEmpty(t, err)
+		assert.Equal(t, test.error, err[0].Message, test.input)
+	}
+}
+
+func TestMultipleErrors(t *testing.T) {
+	input := `let x 5
+let y = 10
+let = 1; let z = (1 + 2
+fn f() {
+	let a = ]
+	a + 1
+	if { 1 }
+}
+f(1, 2`
+
+	l := lexer.New(input)
+	p, err := New(l).Parse()
+
+	// Statements between the errors are kept, and blocks keep statements that have no errors
+	if assert.Len(t, p.Statements, 2) {
+		assert.Equal(t, "let y = 10;", p.Statements[0].String())
+		assert.Equal(t, "fn f() { (a + 1) }", p.Statements[1].String())
+	}
+
+	expected := []struct {
+		line    int
+		col     int
+		message string
+	}{
+		{0, 6, "Assign operator expected"},
+		{2, 4, "Identifier expected"},
+		{2, 23, "expected to get closing parenthesis"},
+		{4, 9, "unexpected token"},
+		{6, 4, "unexpected token"},
+		{8, 6, "expected )"},
+	}
+
+	assert.Len(t, err, len(expected))
+	for i, e := range expected {
+		if i >= len(err) {
+			break
+		}
+
+		assert.Equal(t, e.message, err[i].Message)
+		assert.Equal(t, e.line, err[i].Token.Line, e.message)
+		assert.Equal(t, e.col, err[i].Token.Col, e.message)
+	}
+}
+
+func TestErrorsFormatting(t *testing.T) {
+	_, err := New(lexer.New("let x 5\n(1")).Parse()
+
+	assert.EqualError(t, err, "1:7: Assign operator expected\n2:3: expected to get closing parenthesis")
+}
+
+func TestIllegalTokenErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		error string
+	}{
+		{`let s = "abc`, "unterminated string"},
+		{"1 + $", "unexpected character"},
+		{`"\q"`, `unknown escape sequence \q`},
+	}
+
+	for _, test := range tests {
+		p, err := New(lexer.New(test.input)).Parse()
+
+		assert.NotNil(t, p)
+		assert.NotEmpty(t, err)
+		assert.Equal(t, test.error, err[0].Message, test.input)
+	}
+}
+
+func TestOperandsOnNextLine(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 +\n2", "(1 + 2)"},
+		{"a and\n\n b", "(a and b)"},
+		{"let x =\n 5", "let x = 5;"},
+	}
+
+	for _, test := range tests {
+		p, err := New(lexer.New(test.input)).Parse()
+		testValidProgram(t, p, err, 1)
+
+		assert.Equal(t, test.expected, p.String())
+	}
+}
+
+func TestIncompleteInput(t *testing.T) {
+	tests := []struct {
+		input      string
+		incomplete bool
+	}{
+		{"fn f() {", true},
+		{"1 +", true},
+		{"f(1, ", true},
+		{"[1, 2", true},
+		{"x ->", true},
+		{"let x", true},
+		{"let x = ]", false},
+		{"let x 5\nfn f() {", false},
+	}
+
+	for _, test := range tests {
+		_, err := New(lexer.New(test.input)).Parse()
+
+		assert.NotEmpty(t, err, test.input)
+		assert.Equal(t, test.incomplete, err.Incomplete(), test.input)
 	}
 }
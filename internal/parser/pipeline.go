@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/token"
+)
+
+// parsePipelineExpression parses next stage of the pipeline.
+// Consecutive stages are collected into the single pipeline instead of nesting pipelines into each other
+func (p *Parser) parsePipelineExpression(left ast.Expression) (ast.Expression, *ParsingError) {
+	pipeline, ok := left.(*ast.PipelineExpression)
+	if !ok {
+		pipeline = &ast.PipelineExpression{Token: p.curToken, Source: left}
+	}
+
+	// Stage could be placed on the next line after the operator
+	p.nextToken()
+	p.skipNewlines()
+
+	stage, err := p.parseExpression(PIPE)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline.Stages = append(pipeline.Stages, stage)
+	return pipeline, nil
+}
+
+// Checks if the pipeline continues on the next line, e.g.:
+//
+//	source
+//		-> stage
+func (p *Parser) isPipelineContinuation() bool {
+	if !p.peekTokenIs(token.NEWLINE) {
+		return false
+	}
+
+	// Lexer holds only its position, so copy of it could be used for looking ahead without affecting the parser
+	lookahead := *p.l
+	tok := lookahead.NextToken()
+	for tok.Type == token.NEWLINE {
+		tok = lookahead.NextToken()
+	}
+
+	return tok.Type == token.PIPE_OP
+}
+
+// Advances current token until it's not a newline
+func (p *Parser) skipNewlines() {
+	for p.curTokenIs(token.NEWLINE) {
+		p.nextToken()
+	}
+}
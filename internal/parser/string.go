@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"oilang/internal/ast"
+	"oilang/internal/lexer"
+	"oilang/internal/token"
+)
+
+func (p *Parser) parseString() (ast.Expression, *ParsingError) {
+	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}, nil
+}
+
+// parseTemplate parses each interpolated expression of the string with separate parser.
+// Positions of the tokens inside interpolation are shifted, so they point to the original source
+func (p *Parser) parseTemplate() (ast.Expression, *ParsingError) {
+	tmpl := &ast.TemplateLiteral{Token: p.curToken}
+
+	parts, err := lexer.SplitTemplate(p.curToken.Literal)
+	if err != nil {
+		return nil, p.createCurrentTokenError(err.Error())
+	}
+
+	for _, part := range parts {
+		if !part.IsExpr {
+			tok := p.curToken
+			tok.Type = token.STRING
+			tok.Literal = part.Value
+			tmpl.Parts = append(tmpl.Parts, &ast.StringLiteral{Token: tok, Value: part.Value})
+			continue
+		}
+
+		exp, err := p.parseInterpolation(part)
+		if err != nil {
+			return nil, err
+		}
+		tmpl.Parts = append(tmpl.Parts, exp)
+	}
+
+	return tmpl, nil
+}
+
+func (p *Parser) parseInterpolation(part lexer.TemplatePart) (ast.Expression, *ParsingError) {
+	// Skip opening quote of the string
+	col := p.curToken.Col + 1 + part.Offset
+
+	sub := New(lexer.NewAt(part.Value, p.curToken.Line, col))
+	sub.skipNewlines()
+
+	exp, err := sub.parseExpression(LOWEST)
+	if err != nil {
+		return nil, err
+	}
+
+	if !sub.peekTokenIs(token.EOF) {
+		return nil, sub.createPeekError("expected single expression in interpolation")
+	}
+
+	return exp, nil
+}
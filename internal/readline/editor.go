@@ -0,0 +1,431 @@
+package readline
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ErrInterrupted is returned when the line is cancelled with Ctrl+C
+var ErrInterrupted = errors.New("interrupted")
+
+// Completer returns all candidates that could replace the word before the cursor
+type Completer func(word string) []string
+
+// Editor reads lines from the terminal, allowing to edit them before submitting. Supported keys:
+//   - Left/Right, Ctrl+B/Ctrl+F, Home/End, Ctrl+A/Ctrl+E move the cursor
+//   - Up/Down, Ctrl+P/Ctrl+N browse the history
+//   - Ctrl+R searches the history backwards
+//   - Backspace, Delete, Ctrl+K, Ctrl+U, Ctrl+W remove text
+//   - Tab completes the word before the cursor
+//   - Ctrl+C cancels the line, Ctrl+D on the empty line ends the input
+type Editor struct {
+	in  *bufio.Reader
+	out io.Writer
+	// Descriptor of the terminal that is switched to raw mode while reading, or -1 if input is already raw
+	fd int
+
+	History   *History
+	Completer Completer
+}
+
+// New Creates editor for the terminal. Use IsTerminal to check if the file is a terminal before creating the editor
+func New(in *os.File, out io.Writer) *Editor {
+	return &Editor{in: bufio.NewReader(in), out: out, fd: int(in.Fd()), History: NewHistory("")}
+}
+
+// IsTerminal Checks if the file is connected to the terminal
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// ReadLine Shows the prompt and reads the line. Submitted line is added to the history
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	if e.fd >= 0 {
+		state, err := term.MakeRaw(e.fd)
+		if err != nil {
+			return "", err
+		}
+		defer term.Restore(e.fd, state)
+	}
+
+	s := &lineState{editor: e, prompt: prompt, historyPos: e.History.Len()}
+	s.refresh()
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		line, done, err := s.handle(r)
+		if err != nil || done {
+			if err == nil {
+				_ = e.History.Add(line)
+			}
+			return line, err
+		}
+	}
+}
+
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyCtrlH     = 8
+	keyTab       = 9
+	keyLF        = 10
+	keyCtrlK     = 11
+	keyCR        = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// Keys that are produced by escape sequences are mapped to these values
+const (
+	keyUp rune = iota + unicode.MaxRune + 1
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyDelete
+	keyUnknown
+)
+
+// lineState is the state of the line that is being edited
+type lineState struct {
+	editor *Editor
+	prompt string
+	buf    []rune
+	pos    int
+
+	// Position in the history, equal to history length when editing the new line
+	historyPos int
+	// Line that was edited before browsing the history
+	draft []rune
+
+	searching   bool
+	query       []rune
+	searchMatch int
+}
+
+// Handles a single key. Returns the line and true when it is submitted
+func (s *lineState) handle(r rune) (string, bool, error) {
+	if r == keyEscape {
+		r = s.readEscapeSequence()
+	}
+
+	if s.searching {
+		return s.handleSearch(r)
+	}
+
+	switch r {
+	case keyCR, keyLF:
+		return s.submit()
+	case keyCtrlC:
+		s.write("^C\r\n")
+		return "", false, ErrInterrupted
+	case keyCtrlD:
+		if len(s.buf) == 0 {
+			s.write("\r\n")
+			return "", false, io.EOF
+		}
+		s.deleteAt(s.pos)
+	case keyCtrlA, keyHome:
+		s.pos = 0
+	case keyCtrlE, keyEnd:
+		s.pos = len(s.buf)
+	case keyCtrlB, keyLeft:
+		if s.pos > 0 {
+			s.pos -= 1
+		}
+	case keyCtrlF, keyRight:
+		if s.pos < len(s.buf) {
+			s.pos += 1
+		}
+	case keyCtrlP, keyUp:
+		s.moveHistory(-1)
+	case keyCtrlN, keyDown:
+		s.moveHistory(1)
+	case keyBackspace, keyCtrlH:
+		if s.pos > 0 {
+			s.pos -= 1
+			s.deleteAt(s.pos)
+		}
+	case keyDelete:
+		s.deleteAt(s.pos)
+	case keyCtrlK:
+		s.buf = s.buf[:s.pos]
+	case keyCtrlU:
+		s.buf = s.buf[s.pos:]
+		s.pos = 0
+	case keyCtrlW:
+		start := s.wordStart(func(r rune) bool { return !unicode.IsSpace(r) })
+		s.buf = append(s.buf[:start], s.buf[s.pos:]...)
+		s.pos = start
+	case keyCtrlR:
+		s.searching = true
+		s.query = nil
+		s.searchMatch = -1
+	case keyTab:
+		s.complete()
+	default:
+		if unicode.IsPrint(r) {
+			s.insert(r)
+		}
+	}
+
+	s.refresh()
+	return "", false, nil
+}
+
+// Handles key in the reverse search mode, the same way as handle
+func (s *lineState) handleSearch(r rune) (string, bool, error) {
+	switch r {
+	case keyCR, keyLF:
+		s.acceptSearch()
+		return s.submit()
+	case keyCtrlG, keyCtrlC:
+		s.searching = false
+	case keyCtrlR:
+		s.search(s.searchMatch)
+	case keyBackspace, keyCtrlH:
+		if len(s.query) > 0 {
+			s.query = s.query[:len(s.query)-1]
+			s.search(s.editor.History.Len())
+		}
+	default:
+		if unicode.IsPrint(r) {
+			s.query = append(s.query, r)
+			s.search(s.editor.History.Len())
+			break
+		}
+
+		// Any other key ends the search and keeps the found line for editing
+		s.acceptSearch()
+		return s.handle(r)
+	}
+
+	s.refresh()
+	return "", false, nil
+}
+
+// Finds the match before the supplied history position. Keeps the current match if nothing is found
+func (s *lineState) search(before int) {
+	if before < 0 {
+		before = s.editor.History.Len()
+	}
+
+	if i := s.editor.History.Search(string(s.query), before); i >= 0 {
+		s.searchMatch = i
+	}
+}
+
+func (s *lineState) acceptSearch() {
+	s.searching = false
+	if s.searchMatch >= 0 {
+		s.buf = []rune(s.editor.History.At(s.searchMatch))
+		s.pos = len(s.buf)
+	}
+}
+
+func (s *lineState) submit() (string, bool, error) {
+	s.write("\r\n")
+	return string(s.buf), true, nil
+}
+
+// Reads the rest of the escape sequence and converts it to the key
+func (s *lineState) readEscapeSequence() rune {
+	in := s.editor.in
+
+	kind, _, err := in.ReadRune()
+	if err != nil || (kind != '[' && kind != 'O') {
+		return keyUnknown
+	}
+
+	var params []rune
+	for {
+		r, _, err := in.ReadRune()
+		if err != nil {
+			return keyUnknown
+		}
+
+		// Final byte of the sequence
+		if r >= 0x40 && r <= 0x7E {
+			switch {
+			case r == 'A':
+				return keyUp
+			case r == 'B':
+				return keyDown
+			case r == 'C':
+				return keyRight
+			case r == 'D':
+				return keyLeft
+			case r == 'H':
+				return keyHome
+			case r == 'F':
+				return keyEnd
+			case r == '~' && string(params) == "3":
+				return keyDelete
+			case r == '~' && (string(params) == "1" || string(params) == "7"):
+				return keyHome
+			case r == '~' && (string(params) == "4" || string(params) == "8"):
+				return keyEnd
+			}
+
+			return keyUnknown
+		}
+
+		params = append(params, r)
+	}
+}
+
+func (s *lineState) insert(r rune) {
+	s.buf = append(s.buf, 0)
+	copy(s.buf[s.pos+1:], s.buf[s.pos:])
+	s.buf[s.pos] = r
+	s.pos += 1
+}
+
+func (s *lineState) insertString(str string) {
+	for _, r := range str {
+		s.insert(r)
+	}
+}
+
+func (s *lineState) deleteAt(pos int) {
+	if pos < len(s.buf) {
+		s.buf = append(s.buf[:pos], s.buf[pos+1:]...)
+	}
+}
+
+// Returns start of the word before the cursor, where the word consists of characters matching the predicate
+func (s *lineState) wordStart(isWordChar func(rune) bool) int {
+	start := s.pos
+	// Skip spaces right before the cursor, so the previous word is removed
+	for start > 0 && unicode.IsSpace(s.buf[start-1]) && !isWordChar(s.buf[start-1]) {
+		start -= 1
+	}
+	for start > 0 && isWordChar(s.buf[start-1]) {
+		start -= 1
+	}
+
+	return start
+}
+
+func (s *lineState) moveHistory(step int) {
+	history := s.editor.History
+	next := s.historyPos + step
+	if next < 0 || next > history.Len() {
+		return
+	}
+
+	if s.historyPos == history.Len() {
+		s.draft = s.buf
+	}
+
+	s.historyPos = next
+	if next == history.Len() {
+		s.buf = s.draft
+	} else {
+		s.buf = []rune(history.At(next))
+	}
+	s.pos = len(s.buf)
+}
+
+func isIdentifierChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Completes the identifier before the cursor. If there are several candidates, their common prefix is inserted,
+// and if it does not change the word, candidates are listed below the line
+func (s *lineState) complete() {
+	if s.editor.Completer == nil {
+		return
+	}
+
+	start := s.wordStart(isIdentifierChar)
+	if start == s.pos {
+		return
+	}
+	word := string(s.buf[start:s.pos])
+
+	var candidates []string
+	seen := map[string]bool{}
+	for _, c := range s.editor.Completer(word) {
+		if strings.HasPrefix(c, word) && !seen[c] {
+			seen[c] = true
+			candidates = append(candidates, c)
+		}
+	}
+	sort.Strings(candidates)
+
+	if len(candidates) == 0 {
+		return
+	}
+
+	prefix := commonPrefix(candidates)
+	if len(prefix) > len(word) {
+		s.insertString(prefix[len(word):])
+		return
+	}
+
+	if len(candidates) > 1 {
+		s.write("\r\n" + strings.Join(candidates, "  ") + "\r\n")
+	}
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	return prefix
+}
+
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// Redraws the line and places the cursor
+func (s *lineState) refresh() {
+	prompt, buf, pos := s.prompt, s.buf, s.pos
+	if s.searching {
+		match := ""
+		if s.searchMatch >= 0 {
+			match = s.editor.History.At(s.searchMatch)
+		}
+		prompt = fmt.Sprintf("(reverse-i-search)'%s': ", string(s.query))
+		buf = []rune(match)
+		pos = len(buf)
+	}
+
+	out := "\r" + prompt + string(buf) + "\x1b[K\r"
+	if col := len([]rune(ansiSequence.ReplaceAllString(prompt, ""))) + pos; col > 0 {
+		out += fmt.Sprintf("\x1b[%dC", col)
+	}
+
+	s.write(out)
+}
+
+func (s *lineState) write(str string) {
+	_, _ = io.WriteString(s.editor.out, str)
+}
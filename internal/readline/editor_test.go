@@ -0,0 +1,182 @@
+package readline
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestEditor(input string) (*Editor, *bytes.Buffer) {
+	var out bytes.Buffer
+	e := &Editor{in: bufio.NewReader(strings.NewReader(input)), out: &out, fd: -1, History: NewHistory("")}
+
+	return e, &out
+}
+
+const (
+	up    = "\x1b[A"
+	down  = "\x1b[B"
+	right = "\x1b[C"
+	left  = "\x1b[D"
+	home  = "\x1b[H"
+	del   = "\x1b[3~"
+)
+
+func TestEditing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"hello\r", "hello"},
+		{"héllo\n", "héllo"},
+		{"helo" + left + "l\r", "hello"},
+		{"world\x01hello \r", "hello world"},
+		{"ac" + home + right + "b\x05d\r", "abcd"},
+		{"abc\x7f\x7f\r", "a"},
+		{"abc" + home + del + "\x04\r", "c"},
+		{"hello world\x02\x02\x0b\r", "hello wor"},
+		{"hello world" + left + left + "\x15\r", "ld"},
+		{"let x = foo\x17\r", "let x = "},
+		{"let x = foo  \x17\r", "let x = "},
+		{"a\tb\x1b[5~\r", "ab"},
+	}
+
+	for _, test := range tests {
+		e, _ := newTestEditor(test.input)
+		line, err := e.ReadLine("> ")
+
+		assert.Nil(t, err, test.input)
+		assert.Equal(t, test.expected, line, test.input)
+	}
+}
+
+func TestControlKeys(t *testing.T) {
+	e, out := newTestEditor("abc\x03\x04")
+
+	_, err := e.ReadLine("> ")
+	assert.ErrorIs(t, err, ErrInterrupted)
+	assert.Contains(t, out.String(), "^C")
+
+	// Ctrl+D on the empty line ends the input
+	_, err = e.ReadLine("> ")
+	assert.ErrorIs(t, err, io.EOF)
+
+	// Input ended without submitting the line
+	_, err = e.ReadLine("> ")
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestHistoryNavigation(t *testing.T) {
+	e, _ := newTestEditor("first\rsecond\r" + up + up + "!\r" + up + down + down + "draft" + up + down + "\r")
+
+	var lines []string
+	for i := 0; i < 4; i++ {
+		line, err := e.ReadLine("> ")
+		assert.Nil(t, err)
+		lines = append(lines, line)
+	}
+
+	assert.Equal(t, []string{"first", "second", "first!", "draft"}, lines)
+	assert.Equal(t, 4, e.History.Len())
+}
+
+func TestReverseSearch(t *testing.T) {
+	e, out := newTestEditor("let x = 1\rfn f() {}\rlet y = 2\r\x12let\x12\r\x12fn" + right + "!\r\x12zzz\x07ok\r")
+
+	var lines []string
+	for i := 0; i < 6; i++ {
+		line, err := e.ReadLine("> ")
+		assert.Nil(t, err)
+		lines = append(lines, line)
+	}
+
+	assert.Equal(t, []string{"let x = 1", "fn f() {}", "let y = 2", "let x = 1", "fn f() {}!", "ok"}, lines)
+	assert.Contains(t, out.String(), "(reverse-i-search)'let': let y = 2")
+}
+
+func TestReverseSearchPassesKeysOn(t *testing.T) {
+	e, _ := newTestEditor("\x12zzz\x04next\r")
+
+	_, err := e.ReadLine("> ")
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestCompletion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"re\t\r", "return"},
+		{"x = len\t\r", "x = length"},
+		{"l\t\r", "le"},
+		{"q\t\r", "q"},
+		{"\t\r", ""},
+	}
+
+	for _, test := range tests {
+		e, _ := newTestEditor(test.input)
+		e.Completer = func(word string) []string { return []string{"return", "length", "let", "length"} }
+
+		line, err := e.ReadLine("> ")
+		assert.Nil(t, err)
+		assert.Equal(t, test.expected, line, test.input)
+	}
+}
+
+func TestCompletionListsCandidates(t *testing.T) {
+	e, out := newTestEditor("le\t\r")
+	e.Completer = func(word string) []string { return []string{"let", "len"} }
+
+	line, _ := e.ReadLine("> ")
+
+	assert.Equal(t, "le", line)
+	assert.Contains(t, out.String(), "len  let")
+}
+
+func TestRefreshIgnoresColorsInPrompt(t *testing.T) {
+	e, out := newTestEditor("ab" + left + "\r")
+	_, _ = e.ReadLine("\x1b[35m> \x1b[0m")
+
+	// Cursor is placed after the prompt and the first character
+	assert.Contains(t, out.String(), "\x1b[3C")
+}
+
+func TestPersistentHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oi", "history")
+
+	h := NewHistory(path)
+	assert.Nil(t, h.Load())
+	assert.Nil(t, h.Add("one"))
+	assert.Nil(t, h.Add("one"))
+	assert.Nil(t, h.Add("  "))
+	assert.Nil(t, h.Add("two"))
+
+	loaded := NewHistory(path)
+	assert.Nil(t, loaded.Load())
+	assert.Equal(t, 2, loaded.Len())
+	assert.Equal(t, "two", loaded.At(1))
+	assert.Equal(t, 0, loaded.Search("on", loaded.Len()))
+	assert.Equal(t, -1, loaded.Search("three", loaded.Len()))
+}
+
+func TestHistoryFileIsTrimmed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	h := NewHistory(path)
+	for i := 0; i < HISTORY_LIMIT+10; i++ {
+		assert.Nil(t, h.Add(fmt.Sprintf("line %d", i)))
+	}
+
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	assert.Equal(t, HISTORY_LIMIT, len(lines))
+	assert.Equal(t, "line 10", lines[0])
+	assert.Equal(t, fmt.Sprintf("line %d", HISTORY_LIMIT+9), lines[len(lines)-1])
+}
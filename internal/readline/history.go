@@ -0,0 +1,132 @@
+package readline
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Maximum amount of entries kept in the history
+const HISTORY_LIMIT = 1000
+
+// History keeps previously entered lines. If the path is set, entries are persisted to the file
+type History struct {
+	entries []string
+	path    string
+	// Amount of lines in the file, which is rewritten with the kept entries once it has more than HISTORY_LIMIT
+	saved int
+}
+
+// NewHistory Creates history that is stored in the file. File is created on the first added entry
+func NewHistory(path string) *History {
+	return &History{path: path}
+}
+
+// DefaultHistoryPath Returns path of the history file in the user's config directory
+func DefaultHistoryPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "oi", "history"), nil
+}
+
+// Load Reads entries from the history file. Missing file is not an error
+func (h *History) Load() error {
+	if h.path == "" {
+		return nil
+	}
+
+	f, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		h.push(scanner.Text())
+		h.saved++
+	}
+
+	return scanner.Err()
+}
+
+// Add Appends the entry to the history and the file. Empty entries and repeats of the last entry are ignored
+func (h *History) Add(entry string) error {
+	if strings.TrimSpace(entry) == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry) {
+		return nil
+	}
+
+	h.push(entry)
+	if h.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+
+	if h.saved >= HISTORY_LIMIT {
+		return h.save()
+	}
+
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(entry + "\n")
+	if err == nil {
+		h.saved++
+	}
+	return err
+}
+
+// Rewrites the file with the kept entries, dropping the older ones
+func (h *History) save() error {
+	err := os.WriteFile(h.path, []byte(strings.Join(h.entries, "\n")+"\n"), 0600)
+	if err == nil {
+		h.saved = len(h.entries)
+	}
+	return err
+}
+
+// Len Returns amount of entries
+func (h *History) Len() int {
+	return len(h.entries)
+}
+
+// At Returns entry by its index, where 0 is the oldest one
+func (h *History) At(i int) string {
+	return h.entries[i]
+}
+
+// Search Finds the latest entry before the supplied index that contains the query.
+// Returns index of the entry or -1 if nothing is found
+func (h *History) Search(query string, before int) int {
+	if before > len(h.entries) {
+		before = len(h.entries)
+	}
+
+	for i := before - 1; i >= 0; i-- {
+		if strings.Contains(h.entries[i], query) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (h *History) push(entry string) {
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > HISTORY_LIMIT {
+		h.entries = h.entries[len(h.entries)-HISTORY_LIMIT:]
+	}
+}
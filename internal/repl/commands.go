@@ -0,0 +1,147 @@
+package repl
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fatih/color"
+	"oilang/internal/ast"
+	"oilang/internal/lexer"
+	"oilang/internal/parser"
+	"oilang/internal/token"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Commands start with this prefix to distinguish them from the code
+const COMMAND_PREFIX = "."
+
+// Command is a meta-command of the session, e.g. ".help"
+type Command struct {
+	Name  string // Name without the prefix
+	Usage string // Arguments of the command, e.g. "<file>"
+	Help  string
+	Run   func(r *Repl, args string) error
+}
+
+// RegisterCommand Adds the command to the session, replacing existing command with the same name
+func (r *Repl) RegisterCommand(cmd *Command) {
+	r.commands[cmd.Name] = cmd
+}
+
+func isCommand(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), COMMAND_PREFIX)
+}
+
+// Finds the command by the name and runs it with the rest of the line as arguments
+func (r *Repl) runCommand(line string) {
+	name, args, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), COMMAND_PREFIX), " ")
+
+	cmd, ok := r.commands[name]
+	if !ok {
+		r.printError(fmt.Errorf("unknown command %s%s, see %shelp", COMMAND_PREFIX, name, COMMAND_PREFIX))
+		return
+	}
+
+	if err := cmd.Run(r, strings.TrimSpace(args)); err != nil {
+		r.printError(err)
+	}
+}
+
+func (r *Repl) printError(err error) {
+	_, _ = fmt.Fprintln(r.out, color.RedString("error: %v", err))
+}
+
+func defaultCommands() []*Command {
+	return []*Command{
+		{Name: "help", Help: "Show available commands", Run: helpCommand},
+		{Name: "exit", Help: "Exit the session", Run: exitCommand},
+		{Name: "tokens", Usage: "<source>", Help: "Show tokens produced by the lexer", Run: tokensCommand},
+		{Name: "ast", Usage: "<source>", Help: "Show syntax tree of the source", Run: astCommand},
+		{Name: "env", Help: "List names bound in the session", Run: envCommand},
+		{Name: "load", Usage: "<file>", Help: "Run the file in the current session", Run: loadCommand},
+		{Name: "export", Usage: "<file>", Help: "Write successfully evaluated inputs to the file", Run: exportCommand},
+	}
+}
+
+func helpCommand(r *Repl, _ string) error {
+	names := make([]string, 0, len(r.commands))
+	for name := range r.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cmd := r.commands[name]
+		usage := strings.TrimSpace(COMMAND_PREFIX + cmd.Name + " " + cmd.Usage)
+		_, _ = fmt.Fprintf(r.out, "%-20s %s\n", usage, cmd.Help)
+	}
+
+	return nil
+}
+
+func exitCommand(r *Repl, _ string) error {
+	r.exited = true
+	return nil
+}
+
+func tokensCommand(r *Repl, source string) error {
+	l := lexer.New(source)
+
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		_, _ = fmt.Fprintln(r.out, tok.String())
+	}
+
+	return nil
+}
+
+func astCommand(r *Repl, source string) error {
+	program, err := parser.New(lexer.New(source)).Parse()
+	if err != nil {
+		return err
+	}
+
+	for _, stmt := range program.Statements {
+		_, _ = fmt.Fprint(r.out, ast.Dump(stmt))
+	}
+
+	return nil
+}
+
+func envCommand(r *Repl, _ string) error {
+	for _, name := range r.env.Names() {
+		val, _ := r.env.Get(name)
+		_, _ = fmt.Fprintf(r.out, "%s = %s\n", name, val.Inspect())
+	}
+
+	return nil
+}
+
+func loadCommand(r *Repl, path string) error {
+	if path == "" {
+		return errors.New("file is not specified")
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	program, parseErr := parser.New(lexer.New(string(source))).Parse()
+	r.eval(path, string(source), program, parseErr)
+
+	return nil
+}
+
+func exportCommand(r *Repl, path string) error {
+	if path == "" {
+		return errors.New("file is not specified")
+	}
+
+	content := strings.Join(r.history, "\n")
+	if content != "" {
+		content += "\n"
+	}
+
+	return os.WriteFile(path, []byte(content), 0644)
+}
@@ -0,0 +1,63 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"oilang/internal/evaluator"
+	"oilang/internal/readline"
+	"oilang/internal/token"
+	"os"
+)
+
+// LineReader reads a single line of the input, showing the prompt before it.
+// Returns readline.ErrInterrupted if the line is cancelled and io.EOF when the input is over
+type LineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// Reads lines from non-interactive input, e.g. when input is piped into the REPL
+type scannerReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func (s *scannerReader) ReadLine(prompt string) (string, error) {
+	_, _ = fmt.Fprint(s.out, prompt)
+
+	if !s.scanner.Scan() {
+		if err := s.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+
+	return s.scanner.Text(), nil
+}
+
+// Creates line reader for the input. Terminal gets the line editor with persistent history and completion
+func (r *Repl) newLineReader(in io.Reader) LineReader {
+	if f, ok := in.(*os.File); ok && readline.IsTerminal(f) {
+		editor := readline.New(f, r.out)
+		editor.Completer = r.complete
+
+		if path, err := readline.DefaultHistoryPath(); err == nil {
+			editor.History = readline.NewHistory(path)
+			if err := editor.History.Load(); err != nil {
+				r.printError(err)
+			}
+		}
+
+		return editor
+	}
+
+	return &scannerReader{scanner: bufio.NewScanner(in), out: r.out}
+}
+
+// Returns keywords and names bound in the session that could complete the word
+func (r *Repl) complete(string) []string {
+	candidates := token.Keywords()
+	candidates = append(candidates, evaluator.BuiltinNames()...)
+
+	return append(candidates, r.env.Names()...)
+}
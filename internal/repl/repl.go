@@ -1,40 +1,146 @@
 package repl
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/fatih/color"
 	"io"
+	"oilang/internal/analysis"
+	"oilang/internal/ast"
+	"oilang/internal/diagnostics"
+	"oilang/internal/evaluator"
 	"oilang/internal/lexer"
+	"oilang/internal/modules"
 	"oilang/internal/parser"
+	"oilang/internal/readline"
+	"oilang/internal/token"
+	"strings"
 )
 
 const PROMPT = "@oi: "
 
-// TODO:
-// - Allow to use newlines
-// - Add commands: .help, .exit, .export
-// - Exit with Ctrl + D
-// - Stop execution with Ctrl + C
+// Prompt that is shown when the input is not complete yet, e.g. function body is not closed
+const CONTINUATION_PROMPT = "...: "
 
+// Name of the source that is shown in diagnostics
+const SOURCE_NAME = "<repl>"
+
+// Repl is a single interactive session. Bindings and entered statements are kept between the inputs
+type Repl struct {
+	out      io.Writer
+	env      *evaluator.Environment
+	commands map[string]*Command
+	// Sources of the inputs that were evaluated without errors
+	history []string
+	exited  bool
+}
+
+// New Creates session with the default commands
+func New(out io.Writer) *Repl {
+	r := &Repl{out: out, env: evaluator.NewEnvironment(), commands: make(map[string]*Command)}
+	// Inputs do not come from a file, so modules are imported relative to the working directory
+	r.env.SetImporter(modules.NewLoader(""), "")
+	for _, cmd := range defaultCommands() {
+		r.RegisterCommand(cmd)
+	}
+
+	return r
+}
+
+// Start Runs new session that reads input until it ends or session is exited.
+// If the input is a terminal, lines could be edited and the history is saved between sessions
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
-	for {
-		_, _ = color.New(color.FgMagenta).Print(PROMPT)
+	r := New(out)
+	r.Run(r.newLineReader(in))
+}
+
+// Run Reads statements from the input and prints result of their evaluation.
+//
+// Input that is not complete (e.g. unclosed braces or trailing operator) is continued on the next line.
+// Empty line ends the continuation, so the errors are shown. Cancelled line drops the whole incomplete input
+func (r *Repl) Run(in LineReader) {
+	var buffer []string
 
-		input := scanner.Scan()
-		if !input {
+	for !r.exited {
+		prompt := PROMPT
+		if len(buffer) > 0 {
+			prompt = CONTINUATION_PROMPT
+		}
+
+		line, err := in.ReadLine(color.New(color.FgMagenta).Sprint(prompt))
+		if errors.Is(err, readline.ErrInterrupted) {
+			buffer = nil
+			continue
+		}
+		if err != nil {
 			return
 		}
 
-		l := lexer.New(scanner.Text())
-		ast, err := parser.New(l).Parse()
+		if len(buffer) == 0 && isCommand(line) {
+			r.runCommand(line)
+			continue
+		}
 
-		if err != nil {
-			fmt.Printf("%v\n", color.RedString(err.Message))
+		forced := len(buffer) > 0 && strings.TrimSpace(line) == ""
+		buffer = append(buffer, line)
+
+		source := strings.Join(buffer, "\n")
+		program, parseErr := parser.New(lexer.New(source)).Parse()
+		if parseErr != nil && !forced && isIncomplete(source, parseErr) {
 			continue
 		}
 
-		fmt.Println(ast.String())
+		buffer = nil
+		if result, ok := r.eval(SOURCE_NAME, source, program, parseErr); ok && result != nil {
+			_, _ = fmt.Fprintln(r.out, result.Inspect())
+		}
+	}
+}
+
+// Evaluates parsed program and prints errors if there are any. Returns result and whether it was successful
+func (r *Repl) eval(name, source string, program *ast.Program, err parser.ParsingErrors) (evaluator.Object, bool) {
+	renderer := diagnostics.NewRenderer(name, source, !color.NoColor)
+
+	if err != nil {
+		_, _ = fmt.Fprint(r.out, renderer.RenderAll(err.Diagnostics()))
+		return nil, false
+	}
+
+	// Names bound by the previous inputs are already defined
+	ds := analysis.Analyze(program, r.env.Names()...)
+	if len(ds) > 0 {
+		_, _ = fmt.Fprint(r.out, renderer.RenderAll(ds))
+	}
+	if ds.HasErrors() {
+		return nil, false
+	}
+
+	// Each input starts with the fresh depth of calls
+	r.env.SetGuard(evaluator.NewGuard(context.Background(), evaluator.Limits{}))
+	result := evaluator.Eval(program, r.env)
+	if err, ok := result.(*evaluator.Error); ok {
+		_, _ = fmt.Fprint(r.out, renderer.Render(err.Diagnostic()))
+		return nil, false
+	}
+
+	r.history = append(r.history, source)
+	return result, true
+}
+
+// Checks if the input could become valid after adding more lines
+func isIncomplete(source string, err parser.ParsingErrors) bool {
+	if err.Incomplete() {
+		return true
 	}
+
+	// Raw strings and block comments can span multiple lines, so unterminated ones are not finished yet
+	l := lexer.New(source)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Type == token.ILLEGAL && (strings.HasPrefix(tok.Literal, "`") || strings.HasPrefix(tok.Literal, "/*")) {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,117 @@
+package repl
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runSession(input string) string {
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	return out.String()
+}
+
+func TestEvaluation(t *testing.T) {
+	out := runSession("let x = 2\nx * 21\n")
+
+	assert.Equal(t, PROMPT+PROMPT+"42\n"+PROMPT, out)
+}
+
+func TestMultilineInput(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn f(x) {\n  x * 2\n}\nf(2)\n", PROMPT + CONTINUATION_PROMPT + CONTINUATION_PROMPT + PROMPT + "4\n"},
+		{"1 +\n2\n", PROMPT + CONTINUATION_PROMPT + "3\n"},
+		{"[1, 2]\n  -> sum(@)\n", PROMPT + "[1, 2]\n" + PROMPT},
+		{"[1, 2] ->\n  sum(@)\n", PROMPT + CONTINUATION_PROMPT + "3\n"},
+		{"f(1,\n2,\n", PROMPT + CONTINUATION_PROMPT + CONTINUATION_PROMPT},
+		{"`raw\nstring`\n", PROMPT + CONTINUATION_PROMPT + "\"raw\\nstring\"\n"},
+		{"/* long\ncomment */ 1\n", PROMPT + CONTINUATION_PROMPT + "1\n"},
+	}
+
+	for _, test := range tests {
+		assert.True(t, strings.HasPrefix(runSession(test.input), test.expected), test.input)
+	}
+}
+
+func TestEmptyLineEndsContinuation(t *testing.T) {
+	out := runSession("fn f() {\n\n1\n")
+
+	assert.Contains(t, out, "expected } at the end of block")
+	assert.True(t, strings.HasSuffix(out, PROMPT+"1\n"+PROMPT))
+}
+
+func TestErrorsInMultilineInput(t *testing.T) {
+	out := runSession("fn f() {\n  let x = ]\n")
+
+	assert.Contains(t, out, "2 |   let x = ]")
+}
+
+func TestAnalysisErrors(t *testing.T) {
+	out := runSession("print(x)\nlet x = 1\nx + 1\n")
+
+	assert.Contains(t, out, "identifier not found: x")
+	assert.True(t, strings.HasSuffix(out, PROMPT+"2\n"+PROMPT))
+}
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{".help\n", []string{".exit", ".load <file>", "Show available commands"}},
+		{".tokens let x = 1\n", []string{"[0:0] LET let\n", "[0:4] IDENT x\n", "[0:8] INT 1\n"}},
+		{".ast 1 + 2\n", []string{"ExpressionStatement \"1\"\n└─ Expression: InfixExpression \"+\"\n   ├─ Left: IntegerLiteral \"1\""}},
+		{".ast 1 +\n", []string{"error: 1:4: unexpected token"}},
+		{"let b = 2\nlet a = [1]\n.env\n", []string{"a = [1]\nb = 2\n"}},
+		{".unknown\n", []string{"error: unknown command .unknown, see .help"}},
+	}
+
+	for _, test := range tests {
+		out := runSession(test.input)
+
+		for _, e := range test.expected {
+			assert.Contains(t, out, e, test.input)
+		}
+	}
+}
+
+func TestExitCommand(t *testing.T) {
+	out := runSession(".exit\n1\n")
+
+	assert.Equal(t, PROMPT, out)
+}
+
+func TestLoadAndExport(t *testing.T) {
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib.oi")
+	exported := filepath.Join(dir, "session.oi")
+
+	assert.Nil(t, os.WriteFile(lib, []byte("fn double(x) {\n  x * 2\n}\n"), 0644))
+
+	out := runSession(fmt.Sprintf(".load %s\ndouble(21)\n1 + true\nlet x = 1\n.export %s\n", lib, exported))
+	assert.Contains(t, out, "42\n")
+
+	content, err := os.ReadFile(exported)
+	assert.Nil(t, err)
+	assert.Equal(t, "fn double(x) {\n  x * 2\n}\n\ndouble(21)\nlet x = 1\n", string(content))
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "bad.oi")
+	assert.Nil(t, os.WriteFile(lib, []byte("let x = 1\nlet = 2\n"), 0644))
+
+	out := runSession(fmt.Sprintf(".load %s\n.load %s\n", lib, filepath.Join(dir, "missing.oi")))
+
+	assert.Contains(t, out, lib+":2:5")
+	assert.Contains(t, out, "no such file or directory")
+}
@@ -1,6 +1,9 @@
 package token
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 //go:generate stringer -type=TokenType
 type TokenType int
@@ -16,6 +19,7 @@ const (
 	TRUE
 	FALSE
 	STRING
+	TEMPLATE // String with interpolated expressions, e.g. "${x}"
 
 	COMMA
 	DOT
@@ -24,6 +28,12 @@ const (
 	RPAREN
 	LBRACE
 	RBRACE
+	LBRACKET
+	RBRACKET
+	COLON
+	BAR       // |
+	FAT_ARROW // =>
+	ELLIPSIS  // ...
 
 	ASSIGN
 	PLUS
@@ -32,6 +42,13 @@ const (
 	DIVIDE
 	POWER
 
+	// Compound assignment operators
+	PLUS_ASSIGN     // +=
+	MINUS_ASSIGN    // -=
+	MULTIPLY_ASSIGN // *=
+	DIVIDE_ASSIGN   // /=
+	POWER_ASSIGN    // **=
+
 	// Logical operators
 	AND // &&
 	OR  // ||
@@ -46,10 +63,24 @@ const (
 	GTE // >=
 
 	LET
+	CONST
 	FN
 	RETURN
 	IF
 	ELSE
+	MATCH
+
+	// Loops
+	WHILE
+	FOR
+	IN
+	BREAK
+	CONTINUE
+
+	// Modules
+	IMPORT
+	EXPORT
+	AS
 
 	// Piping
 	PIPE_CTX // @
@@ -58,16 +89,26 @@ const (
 )
 
 var keywords = map[string]TokenType{
-	"let":    LET,
-	"fn":     FN,
-	"return": RETURN,
-	"if":     IF,
-	"else":   ELSE,
-	"true":   TRUE,
-	"false":  FALSE,
-	"and":    AND,
-	"or":     OR,
-	"not":    NOT,
+	"let":      LET,
+	"const":    CONST,
+	"fn":       FN,
+	"return":   RETURN,
+	"if":       IF,
+	"else":     ELSE,
+	"match":    MATCH,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"true":     TRUE,
+	"false":    FALSE,
+	"and":      AND,
+	"or":       OR,
+	"not":      NOT,
+	"import":   IMPORT,
+	"export":   EXPORT,
+	"as":       AS,
 }
 
 type Token struct {
@@ -76,6 +117,33 @@ type Token struct {
 	Line    int
 	Col     int
 	Issue   string
+	// Comments around the token, nil if there are none
+	Trivia *Trivia
+}
+
+// Comment is a line ("// text") or block ("/* text */") comment from the source.
+// Comments do not affect the program, so they are not tokens themselves, but are attached to the nearest token
+type Comment struct {
+	Text string // Text of the comment including delimiters
+	Line int
+	Col  int
+}
+
+// Trivia holds comments that belong to the token, so tools like formatter could reproduce them
+type Trivia struct {
+	// Comments before the token, either on the previous lines or on the same line
+	Leading []Comment
+	// Comments after the token on the same line
+	Trailing []Comment
+}
+
+// Comments Returns all comments of the token in order of their appearance
+func (t Token) Comments() []Comment {
+	if t.Trivia == nil {
+		return nil
+	}
+
+	return append(append([]Comment{}, t.Trivia.Leading...), t.Trivia.Trailing...)
 }
 
 func (t Token) String() string {
@@ -90,3 +158,14 @@ func LookupTokenType(ident string) TokenType {
 
 	return IDENT
 }
+
+// Keywords Returns all keywords of the language in alphabetical order
+func Keywords() []string {
+	result := make([]string, 0, len(keywords))
+	for k := range keywords {
+		result = append(result, k)
+	}
+
+	sort.Strings(result)
+	return result
+}
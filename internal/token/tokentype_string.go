@@ -17,41 +17,63 @@ func _() {
 	_ = x[TRUE-6]
 	_ = x[FALSE-7]
 	_ = x[STRING-8]
-	_ = x[COMMA-9]
-	_ = x[DOT-10]
-	_ = x[SEMICOLON-11]
-	_ = x[LPAREN-12]
-	_ = x[RPAREN-13]
-	_ = x[LBRACE-14]
-	_ = x[RBRACE-15]
-	_ = x[ASSIGN-16]
-	_ = x[PLUS-17]
-	_ = x[MINUS-18]
-	_ = x[MULTIPLY-19]
-	_ = x[DIVIDE-20]
-	_ = x[POWER-21]
-	_ = x[AND-22]
-	_ = x[OR-23]
-	_ = x[NOT-24]
-	_ = x[EQ-25]
-	_ = x[NEQ-26]
-	_ = x[LT-27]
-	_ = x[GT-28]
-	_ = x[LTE-29]
-	_ = x[GTE-30]
-	_ = x[LET-31]
-	_ = x[FN-32]
-	_ = x[RETURN-33]
-	_ = x[IF-34]
-	_ = x[ELSE-35]
-	_ = x[PIPE_CTX-36]
-	_ = x[STAGE_FN-37]
-	_ = x[PIPE_OP-38]
+	_ = x[TEMPLATE-9]
+	_ = x[COMMA-10]
+	_ = x[DOT-11]
+	_ = x[SEMICOLON-12]
+	_ = x[LPAREN-13]
+	_ = x[RPAREN-14]
+	_ = x[LBRACE-15]
+	_ = x[RBRACE-16]
+	_ = x[LBRACKET-17]
+	_ = x[RBRACKET-18]
+	_ = x[COLON-19]
+	_ = x[BAR-20]
+	_ = x[FAT_ARROW-21]
+	_ = x[ELLIPSIS-22]
+	_ = x[ASSIGN-23]
+	_ = x[PLUS-24]
+	_ = x[MINUS-25]
+	_ = x[MULTIPLY-26]
+	_ = x[DIVIDE-27]
+	_ = x[POWER-28]
+	_ = x[PLUS_ASSIGN-29]
+	_ = x[MINUS_ASSIGN-30]
+	_ = x[MULTIPLY_ASSIGN-31]
+	_ = x[DIVIDE_ASSIGN-32]
+	_ = x[POWER_ASSIGN-33]
+	_ = x[AND-34]
+	_ = x[OR-35]
+	_ = x[NOT-36]
+	_ = x[EQ-37]
+	_ = x[NEQ-38]
+	_ = x[LT-39]
+	_ = x[GT-40]
+	_ = x[LTE-41]
+	_ = x[GTE-42]
+	_ = x[LET-43]
+	_ = x[CONST-44]
+	_ = x[FN-45]
+	_ = x[RETURN-46]
+	_ = x[IF-47]
+	_ = x[ELSE-48]
+	_ = x[MATCH-49]
+	_ = x[WHILE-50]
+	_ = x[FOR-51]
+	_ = x[IN-52]
+	_ = x[BREAK-53]
+	_ = x[CONTINUE-54]
+	_ = x[IMPORT-55]
+	_ = x[EXPORT-56]
+	_ = x[AS-57]
+	_ = x[PIPE_CTX-58]
+	_ = x[STAGE_FN-59]
+	_ = x[PIPE_OP-60]
 }
 
-const _TokenType_name = "ILLEGALEOFNEWLINEIDENTINTFLOATTRUEFALSESTRINGCOMMADOTSEMICOLONLPARENRPARENLBRACERBRACEASSIGNPLUSMINUSMULTIPLYDIVIDEPOWERANDORNOTEQNEQLTGTLTEGTELETFNRETURNIFELSEPIPE_CTXPIPE_FNPIPE_OP"
+const _TokenType_name = "ILLEGALEOFNEWLINEIDENTINTFLOATTRUEFALSESTRINGTEMPLATECOMMADOTSEMICOLONLPARENRPARENLBRACERBRACELBRACKETRBRACKETCOLONBARFAT_ARROWELLIPSISASSIGNPLUSMINUSMULTIPLYDIVIDEPOWERPLUS_ASSIGNMINUS_ASSIGNMULTIPLY_ASSIGNDIVIDE_ASSIGNPOWER_ASSIGNANDORNOTEQNEQLTGTLTEGTELETCONSTFNRETURNIFELSEMATCHWHILEFORINBREAKCONTINUEIMPORTEXPORTASPIPE_CTXSTAGE_FNPIPE_OP"
 
-var _TokenType_index = [...]uint8{0, 7, 10, 17, 22, 25, 30, 34, 39, 45, 50, 53, 62, 68, 74, 80, 86, 92, 96, 101, 109, 115, 120, 123, 125, 128, 130, 133, 135, 137, 140, 143, 146, 148, 154, 156, 160, 168, 175, 182}
+var _TokenType_index = [...]uint16{0, 7, 10, 17, 22, 25, 30, 34, 39, 45, 53, 58, 61, 70, 76, 82, 88, 94, 102, 110, 115, 118, 127, 135, 141, 145, 150, 158, 164, 169, 180, 192, 207, 220, 232, 235, 237, 240, 242, 245, 247, 249, 252, 255, 258, 263, 265, 271, 273, 277, 282, 287, 290, 292, 297, 305, 311, 317, 319, 327, 335, 342}
 
 func (i TokenType) String() string {
 	if i < 0 || i >= TokenType(len(_TokenType_index)-1) {
@@ -0,0 +1,41 @@
+package vm
+
+import (
+	"oilang/internal/compiler"
+	"oilang/internal/evaluator"
+)
+
+// Closure is a compiled function together with the scope it was created in
+type Closure struct {
+	Fn *compiler.CompiledFunction
+	// Locals of the function call the closure was created in, nil for closures created at top level
+	Scope *Scope
+}
+
+func (*Closure) Type() evaluator.ObjectType { return evaluator.FUNCTION_OBJ }
+func (c *Closure) Inspect() string          { return c.Fn.Inspect() }
+
+// Scope holds locals of a single function call or a block that has its own scope, e.g. iteration of the loop.
+// Closures keep the scope they were created in, so free variables are read from it and changes made after
+// the closure is created are visible
+type Scope struct {
+	Locals []evaluator.Object
+	Outer  *Scope
+}
+
+// Frame is a call of the function that is being executed
+type Frame struct {
+	cl *Closure
+	// Position of the next instruction
+	ip    int
+	scope *Scope
+	// Stack pointer before the call, so the stack is restored on return
+	base int
+}
+
+func newFrame(cl *Closure, locals []evaluator.Object, base int) *Frame {
+	scope := &Scope{Locals: make([]evaluator.Object, cl.Fn.NumLocals), Outer: cl.Scope}
+	copy(scope.Locals, locals)
+
+	return &Frame{cl: cl, scope: scope, base: base}
+}
@@ -0,0 +1,533 @@
+package vm
+
+import (
+	"oilang/internal/compiler"
+	"oilang/internal/evaluator"
+	"oilang/internal/token"
+	"strings"
+)
+
+// Maximum amount of values on the stack
+const STACK_SIZE = 2048
+
+// Maximum depth of the function calls
+const MAX_FRAMES = 1024
+
+// VM executes compiled bytecode. Values and operations on them are shared with the tree-walking evaluator,
+// so both engines produce the same results
+type VM struct {
+	constants   []evaluator.Object
+	globals     []evaluator.Object
+	globalNames []string
+
+	stack []evaluator.Object
+	// Points to the next free slot of the stack
+	sp int
+
+	frames []*Frame
+	// Value the program has finished with
+	result evaluator.Object
+	// Limits depth of the calls and stops builtin functions that pull streams, nil if they run without limits
+	guard *evaluator.Guard
+}
+
+// New Creates VM for the program. Globals that are named as builtin functions refer to them until they are redefined
+func New(bytecode *compiler.Bytecode) *VM {
+	globals := make([]evaluator.Object, len(bytecode.Globals))
+	for i, name := range bytecode.Globals {
+		if builtin, ok := evaluator.LookupBuiltin(name); ok {
+			globals[i] = builtin
+		}
+	}
+
+	main := &Frame{cl: &Closure{Fn: bytecode.Main}}
+
+	return &VM{
+		constants:   bytecode.Constants,
+		globals:     globals,
+		globalNames: bytecode.Globals,
+		stack:       make([]evaluator.Object, STACK_SIZE),
+		frames:      []*Frame{main},
+	}
+}
+
+// SetGlobal Sets value of the global slot, e.g. the one declared with compiler.Define
+func (vm *VM) SetGlobal(index int, val evaluator.Object) {
+	vm.globals[index] = val
+}
+
+// SetGuard Limits depth of the calls by the guard, and makes builtin functions and pipelines that pull streams
+// stop on cancellation of the guard's context or when they exceed its limits
+func (vm *VM) SetGuard(guard *evaluator.Guard) {
+	vm.guard = guard
+}
+
+// Run Executes the program. Returns value of the last expression statement, nil if the program has ended without value,
+// or runtime error the same way evaluator.Eval does
+func (vm *VM) Run() evaluator.Object {
+	if err := vm.run(0); err != nil {
+		return err
+	}
+
+	return vm.result
+}
+
+// Executes instructions until amount of frames drops to the depth, so function could be called from the Go code
+func (vm *VM) run(depth int) *evaluator.Error {
+	for len(vm.frames) > depth {
+		frame := vm.frames[len(vm.frames)-1]
+		ins := frame.cl.Fn.Instructions
+		ip := frame.ip
+		op := compiler.Opcode(ins[ip])
+
+		def, _ := compiler.Lookup(op)
+		operands, read := compiler.ReadOperands(def, ins[ip+1:])
+		frame.ip = ip + 1 + read
+
+		// Token is needed only for errors and operations that depend on it, so it's looked up lazily
+		tok := func() token.Token { return frame.cl.Fn.TokenAt(ip) }
+
+		var err *evaluator.Error
+		switch op {
+		case compiler.OpConstant:
+			err = vm.push(tok, vm.constants[operands[0]])
+		case compiler.OpPop:
+			vm.pop()
+		case compiler.OpDup:
+			err = vm.push(tok, vm.stack[vm.sp-1])
+		case compiler.OpDupTwo:
+			if err = vm.push(tok, vm.stack[vm.sp-2]); err == nil {
+				err = vm.push(tok, vm.stack[vm.sp-2])
+			}
+		case compiler.OpTrue:
+			err = vm.push(tok, evaluator.TRUE)
+		case compiler.OpFalse:
+			err = vm.push(tok, evaluator.FALSE)
+		case compiler.OpNull:
+			err = vm.push(tok, evaluator.NULL)
+
+		case compiler.OpAdd, compiler.OpSub, compiler.OpMul, compiler.OpDiv, compiler.OpPow,
+			compiler.OpEqual, compiler.OpNotEqual, compiler.OpLess, compiler.OpGreater, compiler.OpLessEqual, compiler.OpGreaterEqual:
+			right := vm.pop()
+			left := vm.pop()
+			err = vm.pushResult(tok, evaluator.Infix(tok(), left, right))
+		case compiler.OpMinus, compiler.OpNot:
+			err = vm.pushResult(tok, evaluator.Prefix(tok(), vm.pop()))
+		case compiler.OpToBool:
+			err = vm.push(tok, evaluator.NativeBool(evaluator.IsTruthy(vm.pop())))
+
+		case compiler.OpJump:
+			frame.ip = operands[0]
+		case compiler.OpJumpNotTruthy:
+			if !evaluator.IsTruthy(vm.pop()) {
+				frame.ip = operands[0]
+			}
+
+		case compiler.OpIterator:
+			err = vm.pushResult(tok, evaluator.Iterate(tok(), vm.pop()))
+		case compiler.OpIterNext:
+			it := vm.pop().(*evaluator.Iterator)
+			key, value, ok := it.Next()
+			switch {
+			case !ok:
+				frame.ip = operands[0]
+			case value.Type() == evaluator.ERROR_OBJ:
+				err = value.(*evaluator.Error)
+			case operands[1] == 2:
+				if err = vm.push(tok, key); err == nil {
+					err = vm.push(tok, value)
+				}
+			default:
+				err = vm.push(tok, it.Element(key, value))
+			}
+
+		case compiler.OpGetGlobal:
+			val := vm.globals[operands[0]]
+			if val == nil {
+				return evaluator.NewError(tok(), "identifier not found: %s", vm.globalNames[operands[0]])
+			}
+			err = vm.push(tok, val)
+		case compiler.OpSetGlobal:
+			vm.globals[operands[0]] = vm.pop()
+		case compiler.OpGetLocal:
+			err = vm.pushVariable(tok, frame.scope, operands[0])
+		case compiler.OpSetLocal:
+			frame.scope.Locals[operands[0]] = vm.pop()
+		case compiler.OpGetFree:
+			scope := frame.scope
+			for i := 0; i < operands[0]; i++ {
+				scope = scope.Outer
+			}
+			err = vm.pushVariable(tok, scope, operands[1])
+		case compiler.OpAssignGlobal:
+			if vm.globals[operands[0]] == nil {
+				return evaluator.NewError(tok(), "identifier not found: %s", vm.globalNames[operands[0]])
+			}
+			vm.globals[operands[0]] = vm.stack[vm.sp-1]
+		case compiler.OpAssignLocal:
+			err = vm.assignVariable(tok, frame.scope, operands[0])
+		case compiler.OpAssignFree:
+			scope := frame.scope
+			for i := 0; i < operands[0]; i++ {
+				scope = scope.Outer
+			}
+			err = vm.assignVariable(tok, scope, operands[1])
+
+		case compiler.OpArray:
+			elements := make([]evaluator.Object, operands[0])
+			copy(elements, vm.stack[vm.sp-operands[0]:vm.sp])
+			vm.sp -= operands[0]
+			err = vm.push(tok, &evaluator.Array{Elements: elements})
+		case compiler.OpHash:
+			err = vm.buildHash(tok, operands[0])
+		case compiler.OpIndex:
+			index := vm.pop()
+			left := vm.pop()
+			err = vm.pushResult(tok, evaluator.Index(tok(), left, index))
+		case compiler.OpSetIndex:
+			val := vm.pop()
+			index := vm.pop()
+			left := vm.pop()
+			err = vm.pushResult(tok, evaluator.SetIndex(tok(), left, index, val))
+		case compiler.OpMember:
+			name := vm.constants[operands[0]].(*evaluator.String).Value
+			err = vm.pushResult(tok, evaluator.Member(tok(), vm.pop(), name))
+		case compiler.OpSetMember:
+			name := vm.constants[operands[0]].(*evaluator.String).Value
+			val := vm.pop()
+			err = vm.pushResult(tok, evaluator.SetMember(tok(), vm.pop(), name, val))
+		case compiler.OpSlice:
+			var low, high evaluator.Object
+			if operands[0]&2 != 0 {
+				high = vm.pop()
+			}
+			if operands[0]&1 != 0 {
+				low = vm.pop()
+			}
+			err = vm.pushResult(tok, evaluator.Slice(tok(), vm.pop(), low, high))
+		case compiler.OpTemplate:
+			var out strings.Builder
+			for _, part := range vm.stack[vm.sp-operands[0] : vm.sp] {
+				out.WriteString(evaluator.DisplayString(part))
+			}
+			vm.sp -= operands[0]
+			err = vm.push(tok, &evaluator.String{Value: out.String()})
+
+		case compiler.OpMatch:
+			pattern := vm.constants[operands[0]].(*compiler.Pattern).Pattern
+			bindings, ok := evaluator.Match(pattern, vm.pop())
+			for _, val := range bindings {
+				if err = vm.push(tok, val); err != nil {
+					break
+				}
+			}
+			if err == nil {
+				err = vm.push(tok, evaluator.NativeBool(ok))
+			}
+
+		case compiler.OpEnterScope:
+			frame.scope = &Scope{Locals: make([]evaluator.Object, operands[0]), Outer: frame.scope}
+		case compiler.OpLeaveScope:
+			for i := 0; i < operands[0]; i++ {
+				frame.scope = frame.scope.Outer
+			}
+
+		case compiler.OpClosure:
+			fn := vm.constants[operands[0]].(*compiler.CompiledFunction)
+			err = vm.push(tok, &Closure{Fn: fn, Scope: frame.scope})
+		case compiler.OpCurrentClosure:
+			err = vm.push(tok, frame.cl)
+		case compiler.OpCall:
+			err = vm.callFromStack(tok, operands[0])
+		case compiler.OpReturnValue:
+			vm.returnFromFrame(vm.pop())
+		case compiler.OpReturn:
+			vm.returnFromFrame(nil)
+
+		case compiler.OpPipeStage:
+			result := vm.pop()
+			val := vm.pop()
+			switch result.(type) {
+			case *Closure, *evaluator.Builtin:
+				result, err = vm.applyStage(tok(), result, val, nil)
+			}
+			if err == nil {
+				err = vm.push(tok, result)
+			}
+		case compiler.OpPipeCall:
+			args := make([]evaluator.Object, operands[0])
+			copy(args, vm.stack[vm.sp-operands[0]:vm.sp])
+			vm.sp -= operands[0]
+			fn := vm.pop()
+			val := vm.pop()
+
+			var result evaluator.Object
+			if isStageFunction(fn) || operands[1]&compiler.PIPE_CALL_STAGE_ONLY == 0 && !fillsParameters(fn, len(args)) {
+				result, err = vm.applyStage(tok(), fn, val, args)
+			} else if result, err = vm.call(tok(), fn, args); err == nil {
+				switch result.(type) {
+				case *Closure, *evaluator.Builtin:
+					result, err = vm.applyStage(tok(), result, val, nil)
+				default:
+					if operands[1]&compiler.PIPE_CALL_STAGE_ONLY == 0 {
+						err = evaluator.NewError(tok(), evaluator.COMPLETE_STAGE_CALL)
+					}
+				}
+			}
+
+			if err == nil {
+				err = vm.push(tok, result)
+			}
+		case compiler.OpCollect:
+			err = vm.pushResult(tok, evaluator.Collect(tok(), vm.pop(), vm.guard))
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (vm *VM) push(tok func() token.Token, obj evaluator.Object) *evaluator.Error {
+	if vm.sp >= STACK_SIZE {
+		return evaluator.NewError(tok(), "stack overflow")
+	}
+
+	vm.stack[vm.sp] = obj
+	vm.sp++
+
+	return nil
+}
+
+// Pushes result of the operation, unless it's an error
+func (vm *VM) pushResult(tok func() token.Token, obj evaluator.Object) *evaluator.Error {
+	if err, ok := obj.(*evaluator.Error); ok {
+		return err
+	}
+
+	return vm.push(tok, obj)
+}
+
+func (vm *VM) pop() evaluator.Object {
+	vm.sp--
+	return vm.stack[vm.sp]
+}
+
+// Local is empty when it's used before its definition was executed (e.g. defined in a branch that was not taken)
+func (vm *VM) pushVariable(tok func() token.Token, scope *Scope, index int) *evaluator.Error {
+	val := scope.Locals[index]
+	if val == nil {
+		t := tok()
+		return evaluator.NewError(t, "identifier not found: %s", t.Literal)
+	}
+
+	return vm.push(tok, val)
+}
+
+// Stores the value from the top of the stack in the local, which should be defined already. Value is kept on the stack
+func (vm *VM) assignVariable(tok func() token.Token, scope *Scope, index int) *evaluator.Error {
+	if scope.Locals[index] == nil {
+		t := tok()
+		return evaluator.NewError(t, "identifier not found: %s", t.Literal)
+	}
+
+	scope.Locals[index] = vm.stack[vm.sp-1]
+	return nil
+}
+
+func (vm *VM) buildHash(tok func() token.Token, pairs int) *evaluator.Error {
+	hash := evaluator.NewHash()
+
+	start := vm.sp - pairs*2
+	for i := start; i < vm.sp; i += 2 {
+		if err := evaluator.SetHashPair(tok(), hash, vm.stack[i], vm.stack[i+1]); err != nil {
+			return err
+		}
+	}
+	vm.sp = start
+
+	return vm.push(tok, hash)
+}
+
+// Calls the function that is placed on the stack before its arguments.
+// Compiled functions continue in the same loop, so deep recursion does not grow the Go stack
+func (vm *VM) callFromStack(tok func() token.Token, argc int) *evaluator.Error {
+	base := vm.sp - argc - 1
+	fn := vm.stack[base]
+	args := vm.stack[base+1 : vm.sp]
+
+	if cl, ok := fn.(*Closure); ok {
+		if err := checkCall(tok(), cl, args); err != nil {
+			return err
+		}
+		if err := vm.pushFrame(tok(), cl, args, base); err != nil {
+			return err
+		}
+		vm.sp = base
+		return nil
+	}
+
+	result, err := vm.call(tok(), fn, append([]evaluator.Object{}, args...))
+	if err != nil {
+		return err
+	}
+
+	vm.sp = base
+	return vm.push(tok, result)
+}
+
+// Stage functions could be called only by the pipeline, which passes the value along with the arguments
+func checkCall(tok token.Token, cl *Closure, args []evaluator.Object) *evaluator.Error {
+	if cl.Fn.IsPipelineStage {
+		return evaluator.NewError(tok, "@fn could be called only as pipeline stage")
+	}
+
+	return checkArguments(tok, cl, args)
+}
+
+func checkArguments(tok token.Token, cl *Closure, args []evaluator.Object) *evaluator.Error {
+	if len(args) != len(cl.Fn.Parameters) {
+		return evaluator.NewError(tok, "wrong number of arguments: expected %d, got %d", len(cl.Fn.Parameters), len(args))
+	}
+
+	return nil
+}
+
+// Pushes frame with the locals initialized from the values
+func (vm *VM) pushFrame(tok token.Token, cl *Closure, locals []evaluator.Object, base int) *evaluator.Error {
+	if len(vm.frames) >= MAX_FRAMES {
+		return evaluator.NewError(tok, "stack overflow")
+	}
+	if err := evaluator.EnterCall(tok, vm.guard); err != nil {
+		return err
+	}
+
+	vm.frames = append(vm.frames, newFrame(cl, locals, base))
+	return nil
+}
+
+// Finishes the current frame. Function without value returns null, while the program just ends
+func (vm *VM) returnFromFrame(val evaluator.Object) {
+	frame := vm.frames[len(vm.frames)-1]
+	vm.frames = vm.frames[:len(vm.frames)-1]
+	vm.sp = frame.base
+
+	if len(vm.frames) == 0 {
+		vm.result = val
+		return
+	}
+	evaluator.LeaveCall(vm.guard)
+
+	if val == nil {
+		val = evaluator.NULL
+	}
+
+	vm.stack[vm.sp] = val
+	vm.sp++
+}
+
+// Calls the function with arguments and waits for its result
+func (vm *VM) call(tok token.Token, fn evaluator.Object, args []evaluator.Object) (evaluator.Object, *evaluator.Error) {
+	switch fn := fn.(type) {
+	case *Closure:
+		if err := checkCall(tok, fn, args); err != nil {
+			return nil, err
+		}
+
+		return vm.runClosure(tok, fn, args)
+	case *evaluator.Builtin:
+		result := fn.Call(tok, vm.caller, vm.guard, args...)
+		if err, ok := result.(*evaluator.Error); ok {
+			return nil, err
+		}
+
+		return result, nil
+	}
+
+	return nil, evaluator.NewError(tok, "not a function: %s", evaluator.TypeOf(fn))
+}
+
+// Calls the function for the builtin function that has got it, e.g. map
+func (vm *VM) caller(tok token.Token, fn evaluator.Object, args ...evaluator.Object) evaluator.Object {
+	result, err := vm.call(tok, fn, args)
+	if err != nil {
+		return err
+	}
+
+	return result
+}
+
+// Runs the closure until it returns, locals are initialized from the values
+func (vm *VM) runClosure(tok token.Token, cl *Closure, locals []evaluator.Object) (evaluator.Object, *evaluator.Error) {
+	depth := len(vm.frames)
+	if err := vm.pushFrame(tok, cl, locals, vm.sp); err != nil {
+		return nil, err
+	}
+
+	if err := vm.run(depth); err != nil {
+		return nil, err
+	}
+
+	return vm.pop(), nil
+}
+
+// Calls the function with the value followed by additional arguments.
+// Stage function is called with the value bound to "@" and arguments passed to its parameters,
+// stage function that receives an array is applied to each element, and the one that receives a stream is applied lazily
+func (vm *VM) applyStage(tok token.Token, fn, val evaluator.Object, args []evaluator.Object) (evaluator.Object, *evaluator.Error) {
+	cl, ok := fn.(*Closure)
+	if !ok || !cl.Fn.IsPipelineStage {
+		return vm.call(tok, fn, append([]evaluator.Object{val}, args...))
+	}
+
+	if err := checkArguments(tok, cl, args); err != nil {
+		return nil, err
+	}
+
+	if stream, ok := val.(*evaluator.Stream); ok {
+		return evaluator.MapStream(stream, func(e evaluator.Object) evaluator.Object {
+			result, err := vm.callStage(tok, cl, e, args)
+			if err != nil {
+				return err
+			}
+
+			return result
+		}), nil
+	}
+
+	array, isArray := val.(*evaluator.Array)
+	if !isArray {
+		return vm.callStage(tok, cl, val, args)
+	}
+
+	elements := make([]evaluator.Object, 0, len(array.Elements))
+	for _, e := range array.Elements {
+		result, err := vm.callStage(tok, cl, e, args)
+		if err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, result)
+	}
+
+	return &evaluator.Array{Elements: elements}, nil
+}
+
+// "@" is the local that follows the parameters of the stage function
+func (vm *VM) callStage(tok token.Token, cl *Closure, val evaluator.Object, args []evaluator.Object) (evaluator.Object, *evaluator.Error) {
+	locals := append(append(make([]evaluator.Object, 0, len(args)+1), args...), val)
+	return vm.runClosure(tok, cl, locals)
+}
+
+// Tells if the call is complete without the value passed through pipeline, see evaluator.FillsParameters
+func fillsParameters(fn evaluator.Object, args int) bool {
+	cl, ok := fn.(*Closure)
+	return ok && len(cl.Fn.Parameters) == args
+}
+
+func isStageFunction(obj evaluator.Object) bool {
+	cl, ok := obj.(*Closure)
+	return ok && cl.Fn.IsPipelineStage
+}
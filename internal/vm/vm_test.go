@@ -0,0 +1,73 @@
+package vm
+
+import (
+	"github.com/stretchr/testify/assert"
+	"oilang/internal/compiler"
+	"oilang/internal/evaluator"
+	"oilang/internal/lexer"
+	"oilang/internal/parser"
+	"testing"
+)
+
+func run(t *testing.T, input string, globals map[string]evaluator.Object) evaluator.Object {
+	program, perr := parser.New(lexer.New(input)).Parse()
+	assert.Nil(t, perr)
+
+	c := compiler.New()
+	indexes := make(map[string]int)
+	for name := range globals {
+		indexes[name] = c.Define(name)
+	}
+
+	bytecode, err := c.Compile(program)
+	assert.Nil(t, err)
+
+	machine := New(bytecode)
+	for name, val := range globals {
+		machine.SetGlobal(indexes[name], val)
+	}
+
+	return machine.Run()
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 + 2 * 3", "7"},
+		{"let xs = [1, 2, 3]; xs -> @fn() { @ * @ } -> sum", "14"},
+		{"fn fib(n) { if n < 2 { n } else { fib(n - 1) + fib(n - 2) } }; fib(15)", "610"},
+		{"fn loop(n) { if n == 0 { 0 } else { loop(n - 1) } }; loop(1000)", "0"},
+		{"fn make() { let n = 1; fn() { n } }; let get = make(); get()", "1"},
+		{`args -> len`, "2"},
+		{"let i = 0; while i < 5000 { i = i + 1; if i > 10 { continue }; i }; i", "5000"},
+	}
+
+	globals := map[string]evaluator.Object{
+		"args": &evaluator.Array{Elements: []evaluator.Object{&evaluator.String{Value: "a"}, &evaluator.String{Value: "b"}}},
+	}
+
+	for _, test := range tests {
+		result := run(t, test.input, globals)
+		if assert.NotNil(t, result, test.input) {
+			assert.Equal(t, test.expected, result.Inspect(), test.input)
+		}
+	}
+}
+
+func TestRunWithoutResult(t *testing.T) {
+	assert.Nil(t, run(t, "let x = 1", nil))
+	assert.Nil(t, run(t, "fn f() { 1 }", nil))
+}
+
+func TestRuntimeError(t *testing.T) {
+	result := run(t, "let f = fn(x) { x / 0 }\nf(1)", nil)
+
+	err, ok := result.(*evaluator.Error)
+	if assert.True(t, ok) {
+		assert.Equal(t, "division by zero", err.Message)
+		assert.Equal(t, 0, err.Token.Line)
+		assert.Equal(t, 18, err.Token.Col)
+	}
+}
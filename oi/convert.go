@@ -0,0 +1,510 @@
+package oi
+
+import (
+	"fmt"
+	"math"
+	"oilang/internal/evaluator"
+	"oilang/internal/token"
+	"path"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+var (
+	valueType = reflect.TypeOf(Value{})
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// ToValue Converts Go value to oi:
+//   - nil is null
+//   - Booleans, integers, floats and strings are converted to the values of the same type.
+//     Unsigned integers that do not fit int64 could not be converted
+//   - Slices and arrays are converted to arrays, nil slices are empty arrays
+//   - Maps are converted to hashes, with pairs sorted by their keys. Nil maps are empty hashes
+//   - Structs are converted to hashes with exported fields. Field is named by "oi" tag, e.g. `oi:"name"`,
+//     or by its Go name if there is no tag. Fields with `oi:"-"` tag are skipped
+//   - Pointers and interfaces are converted to the values they point to, or null if they are nil.
+//     Values that contain themselves, e.g. node of the cyclic list, could not be converted
+//   - Functions are converted the same way Runtime.RegisterFunc does
+//   - Value is used as is
+func ToValue(v any) (Value, error) {
+	obj, err := toObject(reflect.ValueOf(v))
+	if err != nil {
+		return Value{}, err
+	}
+
+	return Value{obj: obj}, nil
+}
+
+func toObject(rv reflect.Value) (evaluator.Object, error) {
+	return convert(rv, make(map[visit]bool))
+}
+
+// Pointer, map or slice that is being converted. Slices of the same array with different length are different values
+type visit struct {
+	ptr    uintptr
+	typ    reflect.Type
+	length int
+}
+
+// Converts the value, which is one of the values that contain each other on the path. Value that is found on its own
+// path contains itself, so it could not be converted
+func convert(rv reflect.Value, path map[visit]bool) (evaluator.Object, error) {
+	if !rv.IsValid() {
+		return evaluator.NULL, nil
+	}
+	if rv.Type() == valueType {
+		return rv.Interface().(Value).object(), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if !rv.IsNil() {
+			v := visit{ptr: rv.Pointer(), typ: rv.Type()}
+			if rv.Kind() == reflect.Slice {
+				v.length = rv.Len()
+			}
+			if path[v] {
+				return nil, fmt.Errorf("%s contains itself", rv.Type())
+			}
+
+			path[v] = true
+			defer delete(path, v)
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		return evaluator.NativeBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &evaluator.Integer{Value: rv.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("%d overflows integer", rv.Uint())
+		}
+		return &evaluator.Integer{Value: int64(rv.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return &evaluator.Float{Value: rv.Float()}, nil
+	case reflect.String:
+		return &evaluator.String{Value: rv.String()}, nil
+	case reflect.Slice, reflect.Array:
+		elements := make([]evaluator.Object, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			e, err := convert(rv.Index(i), path)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			elements = append(elements, e)
+		}
+		return &evaluator.Array{Elements: elements}, nil
+	case reflect.Map:
+		return mapToHash(rv, path)
+	case reflect.Struct:
+		return structToHash(rv, path)
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return evaluator.NULL, nil
+		}
+		return convert(rv.Elem(), path)
+	case reflect.Func:
+		return wrapFunc(funcName(rv), rv)
+	}
+
+	return nil, fmt.Errorf("cannot convert %s to oi value", rv.Type())
+}
+
+// Go maps have no order, so pairs are sorted to make the result the same each time
+func mapToHash(rv reflect.Value, path map[visit]bool) (evaluator.Object, error) {
+	type pair struct {
+		key   evaluator.Hashable
+		value evaluator.Object
+	}
+
+	pairs := make([]pair, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		key, err := convert(iter.Key(), path)
+		if err != nil {
+			return nil, fmt.Errorf("key %v: %w", iter.Key(), err)
+		}
+		hashable, ok := key.(evaluator.Hashable)
+		if !ok {
+			return nil, fmt.Errorf("unusable as hash key: %s", typeName(key))
+		}
+
+		value, err := convert(iter.Value(), path)
+		if err != nil {
+			return nil, fmt.Errorf("value of %v: %w", iter.Key(), err)
+		}
+
+		pairs = append(pairs, pair{key: hashable, value: value})
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return lessKey(pairs[i].key, pairs[j].key)
+	})
+
+	hash := evaluator.NewHash()
+	for _, p := range pairs {
+		hash.Set(p.key, p.value)
+	}
+
+	return hash, nil
+}
+
+// Orders numbers by their values, strings and others by how they look. Keys of Go map share the type, so mixed keys
+// come only from interface maps and are ordered by their type first
+func lessKey(a, b evaluator.Hashable) bool {
+	if a.Type() != b.Type() {
+		return a.Type() < b.Type()
+	}
+
+	switch a := a.(type) {
+	case *evaluator.Integer:
+		return a.Value < b.(*evaluator.Integer).Value
+	case *evaluator.Float:
+		return a.Value < b.(*evaluator.Float).Value
+	case *evaluator.String:
+		return a.Value < b.(*evaluator.String).Value
+	}
+
+	return a.Inspect() < b.Inspect()
+}
+
+func structToHash(rv reflect.Value, path map[visit]bool) (evaluator.Object, error) {
+	hash := evaluator.NewHash()
+
+	for i := 0; i < rv.NumField(); i++ {
+		name, ok := fieldName(rv.Type().Field(i))
+		if !ok {
+			continue
+		}
+
+		value, err := convert(rv.Field(i), path)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+
+		hash.Set(&evaluator.String{Value: name}, value)
+	}
+
+	return hash, nil
+}
+
+// Returns name of the struct field in oi, false if the field is not converted
+func fieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+
+	tag := field.Tag.Get("oi")
+	switch tag {
+	case "-":
+		return "", false
+	case "":
+		return field.Name, true
+	}
+
+	return tag, true
+}
+
+// Returns name of the Go function without the path of its package, e.g. "strings.ToUpper"
+func funcName(fn reflect.Value) string {
+	if f := runtime.FuncForPC(fn.Pointer()); f != nil {
+		return path.Base(f.Name())
+	}
+
+	return "go"
+}
+
+// Stores the object in the target, see Value.Decode for the conversion rules
+func decode(obj evaluator.Object, target reflect.Value) error {
+	if target.Type() == valueType {
+		target.Set(reflect.ValueOf(Value{obj: obj}))
+		return nil
+	}
+	if obj == evaluator.NULL {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.Interface:
+		native := reflect.ValueOf(toNative(obj))
+		if !native.Type().AssignableTo(target.Type()) {
+			return conversionError(obj, target.Type())
+		}
+		target.Set(native)
+		return nil
+	case reflect.Pointer:
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		return decode(obj, target.Elem())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := obj.(*evaluator.Integer)
+		if !ok {
+			return conversionError(obj, target.Type())
+		}
+		if target.OverflowInt(i.Value) {
+			return fmt.Errorf("%d overflows %s", i.Value, target.Type())
+		}
+		target.SetInt(i.Value)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, ok := obj.(*evaluator.Integer)
+		if !ok {
+			return conversionError(obj, target.Type())
+		}
+		if i.Value < 0 || target.OverflowUint(uint64(i.Value)) {
+			return fmt.Errorf("%d overflows %s", i.Value, target.Type())
+		}
+		target.SetUint(uint64(i.Value))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		switch obj := obj.(type) {
+		case *evaluator.Float:
+			target.SetFloat(obj.Value)
+			return nil
+		case *evaluator.Integer:
+			target.SetFloat(float64(obj.Value))
+			return nil
+		}
+	case reflect.String:
+		if s, ok := obj.(*evaluator.String); ok {
+			target.SetString(s.Value)
+			return nil
+		}
+	case reflect.Bool:
+		if b, ok := obj.(*evaluator.Boolean); ok {
+			target.SetBool(b.Value)
+			return nil
+		}
+	case reflect.Slice:
+		if array, ok := obj.(*evaluator.Array); ok {
+			target.Set(reflect.MakeSlice(target.Type(), len(array.Elements), len(array.Elements)))
+			return decodeElements(array, target)
+		}
+	case reflect.Array:
+		if array, ok := obj.(*evaluator.Array); ok {
+			if len(array.Elements) != target.Len() {
+				return fmt.Errorf("cannot convert array of %d elements to %s", len(array.Elements), target.Type())
+			}
+			return decodeElements(array, target)
+		}
+	case reflect.Map:
+		if hash, ok := obj.(*evaluator.Hash); ok {
+			return decodeMap(hash, target)
+		}
+	case reflect.Struct:
+		if hash, ok := obj.(*evaluator.Hash); ok {
+			return decodeStruct(hash, target)
+		}
+	}
+
+	return conversionError(obj, target.Type())
+}
+
+func decodeElements(array *evaluator.Array, target reflect.Value) error {
+	for i, e := range array.Elements {
+		if err := decode(e, target.Index(i)); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func decodeMap(hash *evaluator.Hash, target reflect.Value) error {
+	t := target.Type()
+	target.Set(reflect.MakeMapWithSize(t, len(hash.Keys)))
+
+	for _, k := range hash.Keys {
+		pair := hash.Pairs[k]
+
+		key := reflect.New(t.Key()).Elem()
+		if err := decode(pair.Key, key); err != nil {
+			return fmt.Errorf("key %s: %w", pair.Key.Inspect(), err)
+		}
+
+		value := reflect.New(t.Elem()).Elem()
+		if err := decode(pair.Value, value); err != nil {
+			return fmt.Errorf("value of %s: %w", pair.Key.Inspect(), err)
+		}
+
+		target.SetMapIndex(key, value)
+	}
+
+	return nil
+}
+
+func decodeStruct(hash *evaluator.Hash, target reflect.Value) error {
+	for i := 0; i < target.NumField(); i++ {
+		name, ok := fieldName(target.Type().Field(i))
+		if !ok {
+			continue
+		}
+
+		value, ok := hashField(hash, name)
+		if !ok {
+			continue
+		}
+
+		if err := decode(value, target.Field(i)); err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Looks up the pair with the string key that has the name, preferring the exact match
+func hashField(hash *evaluator.Hash, name string) (evaluator.Object, bool) {
+	if value, ok := hash.Get(&evaluator.String{Value: name}); ok {
+		return value, true
+	}
+
+	for _, k := range hash.Keys {
+		pair := hash.Pairs[k]
+		if key, ok := pair.Key.(*evaluator.String); ok && strings.EqualFold(key.Value, name) {
+			return pair.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// Converts the object to the plain Go value, see Value.Interface
+func toNative(obj evaluator.Object) any {
+	switch obj := obj.(type) {
+	case *evaluator.Integer:
+		return obj.Value
+	case *evaluator.Float:
+		return obj.Value
+	case *evaluator.String:
+		return obj.Value
+	case *evaluator.Boolean:
+		return obj.Value
+	case *evaluator.Null:
+		return nil
+	case *evaluator.Array:
+		elements := make([]any, 0, len(obj.Elements))
+		for _, e := range obj.Elements {
+			elements = append(elements, toNative(e))
+		}
+		return elements
+	case *evaluator.Hash:
+		pairs := make(map[string]any, len(obj.Keys))
+		for _, k := range obj.Keys {
+			pair := obj.Pairs[k]
+			pairs[evaluator.DisplayString(pair.Key)] = toNative(pair.Value)
+		}
+		return pairs
+	}
+
+	return Value{obj: obj}
+}
+
+// Turns Go function into builtin. Arguments are converted with Value.Decode and results with ToValue
+func wrapFunc(name string, fn reflect.Value) (*evaluator.Builtin, error) {
+	if fn.Kind() != reflect.Func || fn.IsNil() {
+		return nil, fmt.Errorf("%s is not a function", describeType(fn))
+	}
+
+	t := fn.Type()
+	returnsError := t.NumOut() > 0 && t.Out(t.NumOut()-1) == errorType
+	if t.NumOut() > 2 || t.NumOut() == 2 && !returnsError {
+		return nil, fmt.Errorf("function should return a value, an error or both, got %s", t)
+	}
+
+	return &evaluator.Builtin{Name: name, Fn: func(tok token.Token, args ...evaluator.Object) evaluator.Object {
+		in, err := funcArguments(t, args)
+		if err != nil {
+			return evaluator.NewError(tok, "%s: %s", name, err)
+		}
+
+		out, panicked := callFunc(fn, in)
+		if panicked != nil {
+			callErr := evaluator.NewError(tok, "%s: panic: %v", name, panicked)
+			callErr.Cause, _ = panicked.(error)
+			return callErr
+		}
+		if returnsError {
+			if err, _ := out[len(out)-1].Interface().(error); err != nil {
+				// The error is kept, so the host could find it with errors.Is and errors.As
+				callErr := evaluator.NewError(tok, "%s: %s", name, err)
+				callErr.Cause = err
+				return callErr
+			}
+			out = out[:len(out)-1]
+		}
+
+		if len(out) == 0 {
+			return evaluator.NULL
+		}
+
+		result, err := toObject(out[0])
+		if err != nil {
+			return evaluator.NewError(tok, "%s: result: %s", name, err)
+		}
+
+		return result
+	}, Allocates: true}, nil
+}
+
+// Calls the Go function. Panic of the function is recovered and returned, so it fails only the program that called it
+func callFunc(fn reflect.Value, in []reflect.Value) (out []reflect.Value, panicked any) {
+	defer func() {
+		panicked = recover()
+	}()
+
+	return fn.Call(in), nil
+}
+
+// Converts arguments to the types of the function parameters. Extra arguments go to the variadic parameter
+func funcArguments(t reflect.Type, args []evaluator.Object) ([]reflect.Value, error) {
+	params := t.NumIn()
+	if t.IsVariadic() {
+		if len(args) < params-1 {
+			return nil, fmt.Errorf("wrong number of arguments: expected at least %d, got %d", params-1, len(args))
+		}
+	} else if len(args) != params {
+		return nil, fmt.Errorf("wrong number of arguments: expected %d, got %d", params, len(args))
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var paramType reflect.Type
+		if t.IsVariadic() && i >= params-1 {
+			paramType = t.In(params - 1).Elem()
+		} else {
+			paramType = t.In(i)
+		}
+
+		in[i] = reflect.New(paramType).Elem()
+		if err := decode(arg, in[i]); err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i+1, err)
+		}
+	}
+
+	return in, nil
+}
+
+func conversionError(obj evaluator.Object, t reflect.Type) error {
+	return fmt.Errorf("cannot convert %s to %s", typeName(obj), t)
+}
+
+// Returns name of the type as the type builtin does
+func typeName(obj evaluator.Object) string {
+	return strings.ToLower(string(obj.Type()))
+}
+
+func describeType(rv reflect.Value) string {
+	if !rv.IsValid() {
+		return "nil"
+	}
+
+	return rv.Type().String()
+}
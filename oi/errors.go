@@ -0,0 +1,72 @@
+package oi
+
+import (
+	"errors"
+	"fmt"
+	"oilang/internal/diagnostics"
+	"oilang/internal/evaluator"
+)
+
+// ErrorKind tells at which stage the program has failed
+type ErrorKind int
+
+const (
+	// SyntaxError means the source could not be parsed
+	SyntaxError ErrorKind = iota
+	// CheckError means the program has problems that are found before running it, e.g. undefined names
+	CheckError
+	// RuntimeError means the program has failed while running, e.g. on type mismatch, error of Go function
+	// or cancellation of the context
+	RuntimeError
+	// LimitExceeded means the program was stopped because it has exceeded one of the limits set by Options.Limits
+	LimitExceeded
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case SyntaxError:
+		return "syntax error"
+	case CheckError:
+		return "check error"
+	case LimitExceeded:
+		return "limit exceeded"
+	}
+
+	return "runtime error"
+}
+
+// Error is a problem of the oi program that points to the place in the source which caused it
+type Error struct {
+	Kind    ErrorKind
+	Message string
+	// Name of the source, the path for files and "<eval>" for the evaluated strings
+	Source string
+	// Position in the source, starting from 1
+	Line   int
+	Column int
+	// Go error that has caused the runtime error, e.g. context.Canceled or the error returned by Go function
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", e.Source, e.Line, e.Column, e.Kind, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func newError(kind ErrorKind, source string, d diagnostics.Diagnostic) *Error {
+	return &Error{Kind: kind, Message: d.Message, Source: source, Line: d.Token.Line + 1, Column: d.Token.Col + 1}
+}
+
+// Runtime errors caused by exceeded limits get their own kind, other causes are kept to be unwrapped
+func runtimeError(source string, err *evaluator.Error) *Error {
+	if errors.Is(err.Cause, evaluator.ErrLimitExceeded) {
+		return newError(LimitExceeded, source, err.Diagnostic())
+	}
+
+	e := newError(RuntimeError, source, err.Diagnostic())
+	e.Err = err.Cause
+	return e
+}
@@ -0,0 +1,208 @@
+// Package oi embeds the oi language into Go programs, e.g. to use it for configuration or data transformations:
+//
+//	rt := oi.NewRuntime(oi.Options{})
+//	_ = rt.Set("prices", []float64{10, 25.5})
+//	_ = rt.RegisterFunc("discount", func(total float64) float64 { return total * 0.9 })
+//
+//	result, err := rt.Eval(ctx, `prices -> sum -> discount`)
+//	total, err := result.Float()
+//
+// Go values are converted to oi values and back automatically, see ToValue and Value.Decode
+package oi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"oilang/internal/analysis"
+	"oilang/internal/diagnostics"
+	"oilang/internal/evaluator"
+	"oilang/internal/lexer"
+	"oilang/internal/modules"
+	"oilang/internal/parser"
+	"os"
+	"reflect"
+	"time"
+)
+
+// Name under which the evaluated strings are reported in errors
+const EVAL_SOURCE = "<eval>"
+
+// Options configure the runtime. Zero value is ready to use
+type Options struct {
+	// Where print writes its output, the standard output if nil
+	Stdout io.Writer
+	// What stdin module reads lines from, the standard input if nil
+	Stdin io.Reader
+	// Files programs could read with lines and import, e.g. os.DirFS("scripts") keeps them inside of the directory,
+	// and NoFiles forbids them to read any file. Paths are slash-separated and relative to its root.
+	// Programs that have files set could not read the standard input either, unless Stdin is set.
+	// Programs could read any file of the host if nil
+	Files fs.FS
+	// Resources each program could use. Programs are always stopped on cancellation of the context they run with
+	Limits Limits
+	// Runs each stage function of pipelines in its own goroutine when arrays are streamed through them.
+	// Stages still see the elements in order. Each read and change of the collections they share is synchronized,
+	// but compound assignment such as h[k] += 1 is not atomic
+	Concurrent bool
+}
+
+// Limits bound resources of each program, so untrusted code could be run safely. Zero limit means there is no limit.
+// Program that exceeds a limit is stopped with LimitExceeded error at the place it has happened
+type Limits struct {
+	// Amount of statements and loop iterations the program could run
+	Steps int64
+	// Depth of nested function calls. Deeper calls would overflow the Go stack, so zero means the default of 10000
+	CallDepth int
+	// Total amount of array elements and hash pairs the program could create
+	Elements int64
+	// Total size of the strings the program could create, in bytes
+	StringBytes int64
+	// Wall-clock time the program could run for
+	Time time.Duration
+}
+
+// Runtime runs oi programs in a single global scope, so names bound by one program are available to the next ones,
+// the same way REPL keeps them between inputs. Runtime is not safe for concurrent use
+type Runtime struct {
+	env    *evaluator.Environment
+	limits evaluator.Limits
+	// Shared by all programs, so each module is loaded once
+	loader *modules.Loader
+	// Files the programs could read, nil if they could read any file of the host
+	files fs.FS
+}
+
+// NewRuntime Creates runtime with empty global scope, where only builtin functions are defined
+func NewRuntime(opts Options) *Runtime {
+	r := &Runtime{env: evaluator.NewEnvironment(), limits: evaluator.Limits(opts.Limits), files: opts.Files}
+	if opts.Stdout != nil {
+		r.env.SetBuiltin("print", evaluator.PrintTo(opts.Stdout))
+	}
+
+	if opts.Files != nil {
+		r.loader = modules.NewLoaderFS(opts.Files, "")
+		r.env.SetBuiltin("lines", evaluator.LinesFrom(opts.Files))
+		r.env.SetBuiltin("stdin", evaluator.StdinFrom(deniedReader{}))
+	} else {
+		r.loader = modules.NewLoader("")
+	}
+	if opts.Stdin != nil {
+		r.env.SetBuiltin("stdin", evaluator.StdinFrom(opts.Stdin))
+	}
+	r.env.SetConcurrent(opts.Concurrent)
+
+	return r
+}
+
+// NoFiles is the file system without files, see Options.Files
+var NoFiles fs.FS = noFiles{}
+
+type noFiles struct{}
+
+func (noFiles) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+}
+
+// Standard input of the programs that are not allowed to read it
+type deniedReader struct{}
+
+func (deniedReader) Read([]byte) (int, error) {
+	return 0, fs.ErrPermission
+}
+
+// Eval Runs the source and returns value of its last expression, or null if it ends with a statement.
+// Imports are resolved relative to the working directory. Errors of the program are returned as *Error
+func (r *Runtime) Eval(ctx context.Context, src string) (Value, error) {
+	return r.run(ctx, EVAL_SOURCE, src, "")
+}
+
+// RunFile Runs the program from the file the same way Eval does. Imports are resolved relative to the file.
+// File is read from Options.Files if they are set
+func (r *Runtime) RunFile(ctx context.Context, path string) (Value, error) {
+	var src []byte
+	var err error
+	if r.files != nil {
+		src, err = fs.ReadFile(r.files, path)
+	} else {
+		src, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return Value{}, err
+	}
+
+	return r.run(ctx, path, string(src), path)
+}
+
+// Set Binds the Go value converted to oi to the global name, see ToValue for the conversion rules
+func (r *Runtime) Set(name string, value any) error {
+	if !lexer.IsIdentifier(name) {
+		return fmt.Errorf("invalid name %q", name)
+	}
+
+	v, err := ToValue(value)
+	if err != nil {
+		return err
+	}
+
+	r.env.Set(name, v.object())
+	return nil
+}
+
+// RegisterFunc Makes the Go function callable under the global name. Arguments are converted to the types of
+// its parameters the way Value.Decode does, and results are converted with ToValue.
+// Function could return nothing, a single value, an error, or a value followed by an error.
+// Returned error stops the program with RuntimeError at the call
+func (r *Runtime) RegisterFunc(name string, fn any) error {
+	if !lexer.IsIdentifier(name) {
+		return fmt.Errorf("invalid name %q", name)
+	}
+
+	builtin, err := wrapFunc(name, reflect.ValueOf(fn))
+	if err != nil {
+		return err
+	}
+
+	r.env.Set(name, builtin)
+	return nil
+}
+
+// Get Returns value of the global name
+func (r *Runtime) Get(name string) (Value, bool) {
+	obj, ok := r.env.Get(name)
+	if !ok {
+		return Value{}, false
+	}
+
+	return Value{obj: obj}, true
+}
+
+// Parses, checks and evaluates the source. File is the path imports are resolved relative to, empty if there is no file
+func (r *Runtime) run(ctx context.Context, source, src, file string) (Value, error) {
+	if err := ctx.Err(); err != nil {
+		return Value{}, err
+	}
+
+	program, parseErr := parser.New(lexer.New(src)).Parse()
+	if parseErr != nil {
+		return Value{}, newError(SyntaxError, source, parseErr[0].Diagnostic())
+	}
+
+	// Names bound by the previous programs and the host are already defined
+	for _, d := range analysis.Analyze(program, r.env.Names()...) {
+		if d.Severity == diagnostics.Error {
+			return Value{}, newError(CheckError, source, d)
+		}
+	}
+
+	// Limits are counted for each program separately
+	r.env.SetGuard(evaluator.NewGuard(ctx, r.limits))
+	r.env.SetImporter(r.loader, file)
+	result := evaluator.Eval(program, r.env)
+	if err, ok := result.(*evaluator.Error); ok {
+		return Value{}, runtimeError(source, err)
+	}
+
+	return Value{obj: result}, nil
+}
@@ -0,0 +1,290 @@
+package oi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestEval(t *testing.T) {
+	rt := NewRuntime(Options{})
+
+	result, err := rt.Eval(context.Background(), "let x = 2; x * 21")
+	if assert.NoError(t, err) {
+		i, err := result.Int()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), i)
+	}
+
+	// Names are kept between programs
+	result, err = rt.Eval(context.Background(), "x + 1")
+	assert.NoError(t, err)
+	assert.Equal(t, "3", result.String())
+
+	result, err = rt.Eval(context.Background(), "let y = 1")
+	assert.NoError(t, err)
+	assert.True(t, result.IsNull())
+
+	y, ok := rt.Get("y")
+	assert.True(t, ok)
+	assert.Equal(t, "1", y.String())
+
+	_, ok = rt.Get("missing")
+	assert.False(t, ok)
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Error
+	}{
+		{"let = 1", Error{Kind: SyntaxError, Message: "Identifier expected", Source: EVAL_SOURCE, Line: 1, Column: 5}},
+		{"1 +\nmissing", Error{Kind: CheckError, Message: "identifier not found: missing", Source: EVAL_SOURCE, Line: 2, Column: 1}},
+		{`1 + "a"`, Error{Kind: RuntimeError, Message: "type mismatch: INTEGER + STRING", Source: EVAL_SOURCE, Line: 1, Column: 3}},
+	}
+
+	for _, test := range tests {
+		_, err := NewRuntime(Options{}).Eval(context.Background(), test.input)
+
+		var oiErr *Error
+		if assert.True(t, errors.As(err, &oiErr), test.input) {
+			assert.Equal(t, test.expected, *oiErr, test.input)
+		}
+	}
+
+	err := &Error{Kind: RuntimeError, Message: "division by zero", Source: "a.oi", Line: 2, Column: 7}
+	assert.Equal(t, "a.oi:2:7: runtime error: division by zero", err.Error())
+}
+
+func TestCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewRuntime(Options{}).Eval(ctx, "1")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCancelledWhileRunning(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := NewRuntime(Options{}).Eval(ctx, "while true {}")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualError(t, err, "<eval>:1:1: runtime error: evaluation cancelled: context deadline exceeded")
+
+	// Builtin functions that pull streams are stopped too
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = NewRuntime(Options{}).Eval(ctx, "range(0, 50000000) -> last")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestLimits(t *testing.T) {
+	rt := NewRuntime(Options{Limits: Limits{Steps: 1000, CallDepth: 20}})
+
+	_, err := rt.Eval(context.Background(), "fn f(n) { f(n + 1) }\nf(0)")
+	var oiErr *Error
+	if assert.True(t, errors.As(err, &oiErr)) {
+		assert.Equal(t, Error{Kind: LimitExceeded, Message: "call depth limit of 20 exceeded", Source: EVAL_SOURCE, Line: 1, Column: 12}, *oiErr)
+	}
+
+	// Each program gets its own limits
+	for i := 0; i < 3; i++ {
+		_, err = rt.Eval(context.Background(), "let i = 0; while i < 300 { i += 1 }")
+		assert.NoError(t, err)
+	}
+
+	_, err = rt.Eval(context.Background(), "while true {}")
+	assert.EqualError(t, err, "<eval>:1:1: limit exceeded: step limit of 1000 exceeded")
+
+	// Recursion is stopped before it overflows the Go stack even without limits
+	_, err = NewRuntime(Options{}).Eval(context.Background(), "fn f(n) { f(n + 1) }\nf(0)")
+	assert.EqualError(t, err, "<eval>:1:12: limit exceeded: call depth limit of 10000 exceeded")
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "lib.oi"), []byte("export let s = \"abc\" + \"def\"\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "main.oi"), []byte("import \"./lib.oi\" as lib\n"), 0o644))
+
+	rt = NewRuntime(Options{Limits: Limits{StringBytes: 4}})
+	_, err = rt.RunFile(context.Background(), filepath.Join(dir, "main.oi"))
+	if assert.True(t, errors.As(err, &oiErr)) {
+		assert.Equal(t, LimitExceeded, oiErr.Kind)
+		assert.Contains(t, oiErr.Message, "lib.oi:1:22: string size limit of 4 bytes exceeded")
+	}
+
+	assert.NoError(t, rt.RegisterFunc("repeat", strings.Repeat))
+	_, err = rt.Eval(context.Background(), `repeat("a", 5)`)
+	assert.EqualError(t, err, "<eval>:1:7: limit exceeded: string size limit of 4 bytes exceeded")
+
+	// Stream is stopped once it's collected into more elements than allowed
+	rt = NewRuntime(Options{Limits: Limits{Elements: 100}})
+	_, err = rt.Eval(context.Background(), "range(1, 100000000) -> map(fn(x) { x })")
+	if assert.True(t, errors.As(err, &oiErr)) {
+		assert.Equal(t, LimitExceeded, oiErr.Kind)
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	var out bytes.Buffer
+	rt := NewRuntime(Options{Stdout: &out, Concurrent: true})
+	assert.NoError(t, rt.RegisterFunc("slow", func(n int) int {
+		time.Sleep(time.Millisecond)
+		return n * 2
+	}))
+
+	result, err := rt.Eval(context.Background(), "[1, 2, 3, 4, 5] -> parallel(3) -> @fn() { slow(@) } -> @fn() { print(@); @ + 1 }")
+	assert.NoError(t, err)
+	assert.Equal(t, "[3, 5, 7, 9, 11]", result.String())
+	assert.Equal(t, "2\n4\n6\n8\n10\n", out.String())
+}
+
+func TestRunFile(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "lib.oi"), []byte("export fn double(x) { x * 2 }\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "main.oi"), []byte("import \"./lib.oi\" as lib\nlib.double(limit)\n"), 0o644))
+
+	rt := NewRuntime(Options{})
+	assert.NoError(t, rt.Set("limit", 21))
+
+	result, err := rt.RunFile(context.Background(), filepath.Join(dir, "main.oi"))
+	assert.NoError(t, err)
+	assert.Equal(t, "42", result.String())
+
+	_, err = rt.RunFile(context.Background(), filepath.Join(dir, "missing.oi"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestStdout(t *testing.T) {
+	var out bytes.Buffer
+	rt := NewRuntime(Options{Stdout: &out})
+
+	_, err := rt.Eval(context.Background(), `print("a", 1); print([2])`)
+	assert.NoError(t, err)
+	assert.Equal(t, "a 1\n[2]\n", out.String())
+}
+
+func TestStdin(t *testing.T) {
+	rt := NewRuntime(Options{Stdin: strings.NewReader("a\nbb\nccc\n")})
+
+	result, err := rt.Eval(context.Background(), "stdin.lines() -> @fn() { len(@) } -> skip(1)")
+	assert.NoError(t, err)
+	assert.Equal(t, "[2, 3]", result.String())
+}
+
+func TestFiles(t *testing.T) {
+	var out bytes.Buffer
+	files := fstest.MapFS{
+		"data/items.txt": {Data: []byte("a\nbb\n")},
+		"lib/text.oi":    {Data: []byte("import \"../data/util.oi\" as util\nexport fn read(name) { print(name); lines(name) -> util.lengths }\n")},
+		"data/util.oi":   {Data: []byte("export @fn lengths() { len(@) }\n")},
+	}
+	rt := NewRuntime(Options{Stdout: &out, Files: files})
+
+	// Modules read files and print the same way the program does
+	result, err := rt.Eval(context.Background(), `import "lib/text.oi" as text; text.read("data/items.txt")`)
+	assert.NoError(t, err)
+	assert.Equal(t, "[1, 2]", result.String())
+	assert.Equal(t, "data/items.txt\n", out.String())
+
+	_, err = rt.Eval(context.Background(), `lines("/etc/hostname")`)
+	assert.EqualError(t, err, "<eval>:1:6: runtime error: could not read /etc/hostname: file does not exist")
+	_, err = rt.Eval(context.Background(), `import "../etc/hostname" as host`)
+	assert.EqualError(t, err, "<eval>:1:8: runtime error: could not import ../etc/hostname: file does not exist")
+	_, err = rt.Eval(context.Background(), `stdin.lines() -> skip(0)`)
+	assert.EqualError(t, err, "<eval>:1:12: runtime error: could not read stdin: permission denied")
+
+	rt = NewRuntime(Options{Files: NoFiles, Stdin: strings.NewReader("a\n")})
+	_, err = rt.Eval(context.Background(), `lines("data/items.txt")`)
+	assert.EqualError(t, err, "<eval>:1:6: runtime error: could not read data/items.txt: permission denied")
+	_, err = rt.Eval(context.Background(), `import "/etc/hostname" as host`)
+	assert.EqualError(t, err, "<eval>:1:8: runtime error: could not import /etc/hostname: permission denied")
+
+	// Stdin that is set explicitly could still be read
+	result, err = rt.Eval(context.Background(), `stdin.lines() -> skip(0)`)
+	assert.NoError(t, err)
+	assert.Equal(t, `["a"]`, result.String())
+}
+
+func TestSet(t *testing.T) {
+	type item struct {
+		Name  string `oi:"name"`
+		Price float64
+		Tags  []string `oi:"-"`
+	}
+
+	rt := NewRuntime(Options{})
+	assert.NoError(t, rt.Set("items", []item{{Name: "a", Price: 1.5}, {Name: "b", Price: 2}}))
+	assert.NoError(t, rt.Set("limits", map[string]int{"b": 2, "a": 1}))
+
+	result, err := rt.Eval(context.Background(), `[items -> @fn() { @.name }, items[1].Price, limits]`)
+	assert.NoError(t, err)
+	assert.Equal(t, `[["a", "b"], 2.0, {"a": 1, "b": 2}]`, result.String())
+
+	assert.EqualError(t, rt.Set("let", 1), `invalid name "let"`)
+	assert.EqualError(t, rt.Set("c", make(chan int)), "cannot convert chan int to oi value")
+}
+
+func TestRegisterFunc(t *testing.T) {
+	rt := NewRuntime(Options{})
+	assert.NoError(t, rt.RegisterFunc("upper", strings.ToUpper))
+	assert.NoError(t, rt.RegisterFunc("join", func(sep string, parts ...string) string { return strings.Join(parts, sep) }))
+	assert.NoError(t, rt.RegisterFunc("check", func(n int) (bool, error) {
+		if n < 0 {
+			return false, errors.New("negative")
+		}
+		return n > 10, nil
+	}))
+	assert.NoError(t, rt.RegisterFunc("log", func(Value) {}))
+	assert.NoError(t, rt.RegisterFunc("at", func(xs []int, i int) int { return xs[i] }))
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"oi" -> upper`, `"OI"`},
+		{`join("-", "a", "b", "c")`, `"a-b-c"`},
+		{`join(",")`, `""`},
+		{"[check(20), check(1)]", "[true, false]"},
+		{"log(fn() { 1 })", "null"},
+		{"upper", "builtin upper"},
+	}
+
+	for _, test := range tests {
+		result, err := rt.Eval(context.Background(), test.input)
+		if assert.NoError(t, err, test.input) {
+			assert.Equal(t, test.expected, result.String(), test.input)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"check(-1)", "<eval>:1:6: runtime error: check: negative"},
+		{"check(1.5)", "<eval>:1:6: runtime error: check: argument 1: cannot convert float to int"},
+		{"upper()", "<eval>:1:6: runtime error: upper: wrong number of arguments: expected 1, got 0"},
+		{"join()", "<eval>:1:5: runtime error: join: wrong number of arguments: expected at least 1, got 0"},
+		{"at([1], 2)", "<eval>:1:3: runtime error: at: panic: runtime error: index out of range [2] with length 1"},
+	}
+
+	for _, test := range errorTests {
+		_, err := rt.Eval(context.Background(), test.input)
+		assert.EqualError(t, err, test.expected, test.input)
+	}
+
+	errNotFound := errors.New("not found")
+	assert.NoError(t, rt.RegisterFunc("lookup", func(string) (int, error) { return 0, errNotFound }))
+	_, err := rt.Eval(context.Background(), `lookup("a")`)
+	assert.ErrorIs(t, err, errNotFound)
+
+	assert.EqualError(t, rt.RegisterFunc("f", 1), "int is not a function")
+	assert.EqualError(t, rt.RegisterFunc("f", func() (int, int) { return 0, 0 }), "function should return a value, an error or both, got func() (int, int)")
+}
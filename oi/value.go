@@ -0,0 +1,146 @@
+package oi
+
+import (
+	"fmt"
+	"oilang/internal/evaluator"
+	"reflect"
+)
+
+// Value is an oi value, e.g. result of the program or value of the global name. Zero value is null
+type Value struct {
+	obj evaluator.Object
+}
+
+// Null is the value oi uses for missing values
+var Null = Value{}
+
+// Returns the object the value holds, null for zero value
+func (v Value) object() evaluator.Object {
+	if v.obj == nil {
+		return evaluator.NULL
+	}
+
+	return v.obj
+}
+
+// Type Returns name of the value's type, the same that the type builtin returns, e.g. "integer" or "hash"
+func (v Value) Type() string {
+	return typeName(v.object())
+}
+
+// IsNull Tells if the value is null
+func (v Value) IsNull() bool {
+	return v.object() == evaluator.NULL
+}
+
+// String Returns the value as it's shown by REPL, e.g. `"text"` or `[1, 2]`
+func (v Value) String() string {
+	return v.object().Inspect()
+}
+
+// Int Returns integer value. Float values are not converted, because they could lose their fractional part
+func (v Value) Int() (int64, error) {
+	i, ok := v.object().(*evaluator.Integer)
+	if !ok {
+		return 0, v.typeError("integer")
+	}
+
+	return i.Value, nil
+}
+
+// Float Returns float value. Integers are converted to floats
+func (v Value) Float() (float64, error) {
+	switch obj := v.object().(type) {
+	case *evaluator.Float:
+		return obj.Value, nil
+	case *evaluator.Integer:
+		return float64(obj.Value), nil
+	}
+
+	return 0, v.typeError("float")
+}
+
+// Str Returns string value. Other values are not converted, use String to get their representation
+func (v Value) Str() (string, error) {
+	s, ok := v.object().(*evaluator.String)
+	if !ok {
+		return "", v.typeError("string")
+	}
+
+	return s.Value, nil
+}
+
+// Bool Returns boolean value. Other values are not converted, even though they could be truthy in conditions
+func (v Value) Bool() (bool, error) {
+	b, ok := v.object().(*evaluator.Boolean)
+	if !ok {
+		return false, v.typeError("boolean")
+	}
+
+	return b.Value, nil
+}
+
+// Array Returns elements of the array
+func (v Value) Array() ([]Value, error) {
+	array, ok := v.object().(*evaluator.Array)
+	if !ok {
+		return nil, v.typeError("array")
+	}
+
+	elements := make([]Value, 0, len(array.Elements))
+	for _, e := range array.Elements {
+		elements = append(elements, Value{obj: e})
+	}
+
+	return elements, nil
+}
+
+// Map Returns pairs of the hash. Hash should have only string keys
+func (v Value) Map() (map[string]Value, error) {
+	hash, ok := v.object().(*evaluator.Hash)
+	if !ok {
+		return nil, v.typeError("hash")
+	}
+
+	pairs := make(map[string]Value, len(hash.Keys))
+	for _, key := range hash.Keys {
+		pair := hash.Pairs[key]
+		s, ok := pair.Key.(*evaluator.String)
+		if !ok {
+			return nil, fmt.Errorf("hash key %s is not a string", pair.Key.Inspect())
+		}
+
+		pairs[s.Value] = Value{obj: pair.Value}
+	}
+
+	return pairs, nil
+}
+
+// Interface Returns the value converted to Go: nil, bool, int64, float64, string, []any or map[string]any.
+// Keys of the hash that are not strings are converted to their string representation.
+// Functions and modules are returned as Value, because they have no Go counterpart
+func (v Value) Interface() any {
+	return toNative(v.object())
+}
+
+// Decode Converts the value to Go and stores it in the value target points to, the same way Go functions
+// get their arguments:
+//   - Integers are stored in any integer types if they fit, and in float types
+//   - Floats, strings and booleans are stored in the types of the same kind
+//   - Arrays are stored in slices and arrays of the same length
+//   - Hashes are stored in maps and structs. Struct fields are named by "oi" tag or by the field name,
+//     the same way ToValue names them, but names are matched ignoring case. Fields without pairs are not changed
+//   - Null is stored as zero value of the type
+//   - Any value is stored in Value, and in interface types the way Interface returns it
+func (v Value) Decode(target any) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("decode target should be a non-nil pointer, got %T", target)
+	}
+
+	return decode(v.object(), rv.Elem())
+}
+
+func (v Value) typeError(expected string) error {
+	return fmt.Errorf("value is not %s: %s", expected, v.Type())
+}
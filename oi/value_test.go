@@ -0,0 +1,158 @@
+package oi
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func eval(t *testing.T, input string) Value {
+	result, err := NewRuntime(Options{}).Eval(context.Background(), input)
+	assert.NoError(t, err, input)
+
+	return result
+}
+
+func TestAccessors(t *testing.T) {
+	f, err := eval(t, "2").Float()
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, f)
+
+	s, err := eval(t, `"a" + "b"`).Str()
+	assert.NoError(t, err)
+	assert.Equal(t, "ab", s)
+
+	b, err := eval(t, "1 < 2").Bool()
+	assert.NoError(t, err)
+	assert.True(t, b)
+
+	elements, err := eval(t, "[1, \"a\"]").Array()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"integer", "string"}, []string{elements[0].Type(), elements[1].Type()})
+
+	pairs, err := eval(t, "{a: 1}").Map()
+	assert.NoError(t, err)
+	assert.Equal(t, "1", pairs["a"].String())
+
+	_, err = eval(t, "1.5").Int()
+	assert.EqualError(t, err, "value is not integer: float")
+	_, err = eval(t, "{1: 2}").Map()
+	assert.EqualError(t, err, "hash key 1 is not a string")
+
+	assert.True(t, Null.IsNull())
+	assert.Equal(t, "null", Null.Type())
+}
+
+func TestInterface(t *testing.T) {
+	assert.Equal(t, map[string]any{
+		"a": []any{int64(1), 2.5, "s", true, nil},
+		"1": map[string]any{},
+	}, eval(t, `{a: [1, 2.5, "s", true, if false {}], 1: {}}`).Interface())
+
+	fn, ok := eval(t, "fn() {}").Interface().(Value)
+	assert.True(t, ok)
+	assert.Equal(t, "function", fn.Type())
+}
+
+func TestDecode(t *testing.T) {
+	type limits struct {
+		Max uint8
+	}
+	type config struct {
+		Name    string `oi:"name"`
+		Ratio   float32
+		Tags    []string
+		Pair    [2]int
+		Limits  *limits
+		Extra   map[string]any
+		Handler Value
+		Skipped int `oi:"-"`
+	}
+
+	c := config{Skipped: 7}
+	err := eval(t, `{name: "svc", ratio: 1, tags: ["a"], pair: [1, 2], limits: {max: 200}, extra: {k: [1]}, handler: fn() {}, skipped: 1}`).Decode(&c)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "svc", c.Name)
+		assert.Equal(t, float32(1), c.Ratio)
+		assert.Equal(t, []string{"a"}, c.Tags)
+		assert.Equal(t, [2]int{1, 2}, c.Pair)
+		assert.Equal(t, &limits{Max: 200}, c.Limits)
+		assert.Equal(t, map[string]any{"k": []any{int64(1)}}, c.Extra)
+		assert.Equal(t, "function", c.Handler.Type())
+		assert.Equal(t, 7, c.Skipped)
+	}
+
+	tests := []struct {
+		input    string
+		target   any
+		expected string
+	}{
+		{"{limits: {max: 300}}", &config{}, "field Limits: field Max: 300 overflows uint8"},
+		{"{tags: [1]}", &config{}, "field Tags: element 0: cannot convert integer to string"},
+		{"{pair: [1]}", &config{}, "field Pair: cannot convert array of 1 elements to [2]int"},
+		{`{1: "a"}`, &map[string]string{}, "key 1: cannot convert integer to string"},
+		{"1", config{}, "decode target should be a non-nil pointer, got oi.config"},
+	}
+
+	for _, test := range tests {
+		assert.EqualError(t, eval(t, test.input).Decode(test.target), test.expected, test.input)
+	}
+
+	var n *int
+	assert.NoError(t, eval(t, "if false {}").Decode(&n))
+	assert.Nil(t, n)
+}
+
+func TestToValue(t *testing.T) {
+	type point struct {
+		X, y int
+	}
+
+	tests := []struct {
+		input    any
+		expected string
+	}{
+		{nil, "null"},
+		{uint16(3), "3"},
+		{[]point{{X: 1}}, `[{"X": 1}]`},
+		{[]int(nil), "[]"},
+		{map[int]bool{2: true, 1: false}, "{1: false, 2: true}"},
+		{map[int]int{10: 1, 2: 2, -1: 3}, "{-1: 3, 2: 2, 10: 1}"},
+		{&point{X: 2}, `{"X": 2}`},
+		{(*point)(nil), "null"},
+		{[]any{1.5, "a", Null}, `[1.5, "a", null]`},
+	}
+
+	for _, test := range tests {
+		v, err := ToValue(test.input)
+		if assert.NoError(t, err, test.input) {
+			assert.Equal(t, test.expected, v.String(), test.input)
+		}
+	}
+
+	_, err := ToValue(uint64(1 << 63))
+	assert.EqualError(t, err, "9223372036854775808 overflows integer")
+	_, err = ToValue(map[any]int{[2]int{}: 1})
+	assert.EqualError(t, err, "unusable as hash key: array")
+
+	type node struct {
+		Value int
+		Next  *node
+	}
+	n := &node{Value: 1}
+	n.Next = n
+	_, err = ToValue(n)
+	assert.EqualError(t, err, "field Next: *oi.node contains itself")
+
+	m := map[string]any{}
+	m["self"] = m
+	_, err = ToValue(m)
+	assert.EqualError(t, err, "value of self: map[string]interface {} contains itself")
+
+	// Value that is referenced twice without containing itself is converted each time
+	shared := &node{Value: 2}
+	v, err := ToValue([]*node{shared, shared})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `[{"Value": 2, "Next": null}, {"Value": 2, "Next": null}]`, v.String())
+	}
+}